@@ -0,0 +1,195 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// Adapter reads and writes OTIO timelines in one file format. Tools look
+// adapters up by name, file extension or content instead of special-casing
+// each format.
+type Adapter struct {
+	// Name identifies the format, e.g. "xges"
+	Name string
+	// Suffixes are the file extensions handled by the adapter, with the dot
+	Suffixes []string
+	// Sniff reports whether data, the start of a file, is in this format
+	Sniff func(data []byte) bool
+	// Read decodes a timeline
+	Read func(r io.Reader) (*gotio.Timeline, error)
+	// Write encodes a timeline
+	Write func(w io.Writer, timeline *gotio.Timeline) error
+}
+
+var (
+	adaptersMu sync.RWMutex
+	adapters   []*Adapter
+)
+
+func init() {
+	RegisterAdapter(&Adapter{
+		Name:     "xges",
+		Suffixes: []string{".xges"},
+		Sniff:    Sniff,
+		Read: func(r io.Reader) (*gotio.Timeline, error) {
+			return NewDecoder(r).Decode()
+		},
+		Write: func(w io.Writer, timeline *gotio.Timeline) error {
+			return NewEncoder(w).Encode(timeline)
+		},
+	})
+
+	RegisterAdapter(&Adapter{
+		Name:     "otio_json",
+		Suffixes: []string{".otio"},
+		Sniff:    sniffOTIOJSON,
+		Read:     readOTIOJSON,
+		Write:    writeOTIOJSON,
+	})
+}
+
+// RegisterAdapter makes a file format available to the lookup functions.
+// An adapter registered under an existing name replaces the earlier one.
+func RegisterAdapter(a *Adapter) {
+	adaptersMu.Lock()
+	defer adaptersMu.Unlock()
+
+	for i, existing := range adapters {
+		if existing.Name == a.Name {
+			adapters[i] = a
+			return
+		}
+	}
+	adapters = append(adapters, a)
+}
+
+// Adapters returns all registered adapters in registration order
+func Adapters() []*Adapter {
+	adaptersMu.RLock()
+	defer adaptersMu.RUnlock()
+
+	return append([]*Adapter(nil), adapters...)
+}
+
+// LookupAdapter returns the adapter registered under name
+func LookupAdapter(name string) (*Adapter, bool) {
+	for _, a := range Adapters() {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// AdapterForPath returns the adapter handling the file extension of path
+func AdapterForPath(path string) (*Adapter, bool) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == "" {
+		return nil, false
+	}
+
+	for _, a := range Adapters() {
+		for _, suffix := range a.Suffixes {
+			if strings.ToLower(suffix) == ext {
+				return a, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// DetectAdapter returns the first adapter whose Sniff accepts data
+func DetectAdapter(data []byte) (*Adapter, bool) {
+	for _, a := range Adapters() {
+		if a.Sniff != nil && a.Sniff(data) {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// ReadTimelineFile reads a timeline with the adapter matching the file
+// extension, falling back to content detection for unknown extensions
+func ReadTimelineFile(path string) (*gotio.Timeline, error) {
+	a, ok := AdapterForPath(path)
+	if !ok {
+		data, err := sniffFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if a, ok = DetectAdapter(data); !ok {
+			return nil, fmt.Errorf("no adapter for %s", path)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return a.Read(bufio.NewReader(f))
+}
+
+// WriteTimelineFile writes a timeline with the adapter matching the file
+// extension
+func WriteTimelineFile(path string, timeline *gotio.Timeline) error {
+	a, ok := AdapterForPath(path)
+	if !ok {
+		return fmt.Errorf("no adapter for %s", path)
+	}
+
+	return WriteFileWith(path, func(w io.Writer) error {
+		return a.Write(w, timeline)
+	})
+}
+
+// sniffOTIOJSON reports whether data looks like OTIO JSON
+func sniffOTIOJSON(data []byte) bool {
+	data = bytes.TrimLeft(bytes.TrimPrefix(data, utf8BOM), " \t\r\n")
+	return bytes.HasPrefix(data, []byte("{")) && bytes.Contains(data, []byte(`"OTIO_SCHEMA"`))
+}
+
+// readOTIOJSON decodes an OTIO JSON document holding a timeline
+func readOTIOJSON(r io.Reader) (*gotio.Timeline, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	obj, err := gotio.FromJSONString(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode OTIO JSON: %w", err)
+	}
+
+	timeline, ok := obj.(*gotio.Timeline)
+	if !ok {
+		return nil, fmt.Errorf("OTIO JSON holds a %T, not a Timeline", obj)
+	}
+	return timeline, nil
+}
+
+// writeOTIOJSON encodes a timeline as OTIO JSON
+func writeOTIOJSON(w io.Writer, timeline *gotio.Timeline) error {
+	data, err := gotio.ToJSONString(timeline, "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OTIO JSON: %w", err)
+	}
+
+	if _, err := io.WriteString(w, data); err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
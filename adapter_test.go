@@ -0,0 +1,162 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSniff(t *testing.T) {
+	testCases := []struct {
+		name     string
+		data     string
+		expected bool
+	}{
+		{"bare root", `<ges version='0.3'>`, true},
+		{"with declaration", "<?xml version=\"1.0\" ?>\n<ges version='0.3'>", true},
+		{"with BOM", "\xEF\xBB\xBF<?xml version=\"1.0\"?><ges>", true},
+		{"leading comments", "<!-- saved by pitivi -->\n  <!-- another -->\n<ges\n version='0.7'>", true},
+		{"doctype", "<?xml version=\"1.0\"?><!DOCTYPE ges><ges/>", true},
+		{"other root", `<fcpxml version="1.8">`, false},
+		{"prefix of another name", `<gesture>`, false},
+		{"truncated root", `<ges`, false},
+		{"unterminated comment", `<!-- <ges>`, false},
+		{"otio json", `{"OTIO_SCHEMA": "Timeline.1"}`, false},
+		{"empty", ``, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Sniff([]byte(tc.data)); got != tc.expected {
+				t.Errorf("Expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestAdapterLookup(t *testing.T) {
+	a, ok := AdapterForPath("/projects/Edit.XGES")
+	if !ok || a.Name != "xges" {
+		t.Errorf("Expected xges adapter for .XGES extension, got %v", a)
+	}
+
+	a, ok = AdapterForPath("edit.otio")
+	if !ok || a.Name != "otio_json" {
+		t.Errorf("Expected otio_json adapter for .otio extension, got %v", a)
+	}
+
+	if _, ok := AdapterForPath("edit.edl"); ok {
+		t.Error("Expected no adapter for .edl extension")
+	}
+
+	a, ok = DetectAdapter([]byte(simpleXGES))
+	if !ok || a.Name != "xges" {
+		t.Errorf("Expected xges adapter from content, got %v", a)
+	}
+
+	a, ok = DetectAdapter([]byte("{\n  \"OTIO_SCHEMA\": \"Timeline.1\"\n}"))
+	if !ok || a.Name != "otio_json" {
+		t.Errorf("Expected otio_json adapter from content, got %v", a)
+	}
+
+	if _, ok := LookupAdapter("xges"); !ok {
+		t.Error("xges adapter not registered")
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	timeline, err := ReadString(simpleXGES)
+	if err != nil {
+		t.Fatalf("ReadString failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.xges")
+	if err := WriteFile(path, timeline); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	timeline2, err := ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if timeline2.Name() != "Test Project" {
+		t.Errorf("Expected name 'Test Project', got '%s'", timeline2.Name())
+	}
+
+	// An unknown extension falls back to content detection
+	data, err := WriteString(timeline)
+	if err != nil {
+		t.Fatalf("WriteString failed: %v", err)
+	}
+	noExt := filepath.Join(dir, "project")
+	if err := os.WriteFile(noExt, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timeline3, err := ReadTimelineFile(noExt)
+	if err != nil {
+		t.Fatalf("ReadTimelineFile failed: %v", err)
+	}
+	if len(timeline3.VideoTracks()) != len(timeline.VideoTracks()) {
+		t.Errorf("Video track counts differ: %d vs %d", len(timeline3.VideoTracks()), len(timeline.VideoTracks()))
+	}
+
+	if err := WriteTimelineFile(filepath.Join(dir, "out.unknown"), timeline); err == nil {
+		t.Error("Expected error writing an unknown extension")
+	}
+}
+
+func TestWriteFile_KeepsFileOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.xges")
+	if err := os.WriteFile(path, []byte(simpleXGES), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("encoding failed")
+	err := WriteFileWith(path, func(w io.Writer) error {
+		io.WriteString(w, "<ges")
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Expected the write error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != simpleXGES {
+		t.Errorf("Expected the existing file untouched, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected the temporary file removed, got %v", entries)
+	}
+
+	if err := WriteFileWith(path, func(w io.Writer) error {
+		_, err := io.WriteString(w, "replaced")
+		return err
+	}); err != nil {
+		t.Fatalf("WriteFileWith failed: %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "replaced" || info.Mode().Perm() != 0o600 {
+		t.Errorf("Expected the file replaced with its mode kept, got %q with mode %v", data, info.Mode())
+	}
+}
+
+func TestReadTimelineFile_LongProlog(t *testing.T) {
+	declaration, body, _ := strings.Cut(simpleXGES, "\n")
+	prolog := declaration + "\n<!-- " + strings.Repeat("-> notes ", 1000) + "-->\n<!DOCTYPE ges>\n"
+	path := filepath.Join(t.TempDir(), "project")
+	if err := os.WriteFile(path, []byte(prolog+body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadTimelineFile(path); err != nil {
+		t.Errorf("Expected the project detected past its prolog, got %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"github.com/Avalanche-io/gotio"
+)
+
+// MetadataSchemaVersion is the version of the layout of the "xges" metadata
+// the decoder writes. Version 1 added the id, type name, layer priority and
+// track types of clips. Metadata without a version is version 0.
+const MetadataSchemaVersion = 1
+
+// ClipMetadata is what the "xges" metadata of an OTIO clip holds about the
+// XGES clip it came from
+type ClipMetadata struct {
+	ID                 int        // id in the XGES document, -1 if unknown
+	TypeName           string     // such as GESUriClip or GESTitleClip
+	LayerPriority      int        // -1 if unknown
+	TrackTypes         int        // GES track type flags, 0 if unknown
+	ChildrenProperties *Structure // nil when the clip has none
+	Text               string     // text of title clips
+}
+
+// TrackMetadata is what the "xges" metadata of an OTIO track holds about
+// the XGES layer and track it came from
+type TrackMetadata struct {
+	LayerPriority  int     // -1 if unknown
+	TrackID        int     // -1 if unknown
+	TrackType      int     // only kept for text and custom tracks, else 0
+	Caps           string  // only kept for text and custom tracks
+	Volume         float64 // layer volume, 1 by default
+	AutoTransition bool    // true by default
+}
+
+// SchemaVersion returns the version of the "xges" metadata layout of a
+// decoded timeline
+func SchemaVersion(timeline *gotio.Timeline) int {
+	version, _ := metadataInt(xgesMetadata(timeline.Metadata())["schema-version"])
+	return int(version)
+}
+
+// ClipInfo returns what the metadata of an OTIO clip holds about its XGES
+// clip. It returns false if the clip has no xges metadata.
+func ClipInfo(clip *gotio.Clip) (ClipMetadata, bool) {
+	info := ClipMetadata{ID: -1, LayerPriority: -1}
+	metadata := xgesMetadata(clip.Metadata())
+	if metadata == nil {
+		return info, false
+	}
+	if id, ok := metadataInt(metadata["id"]); ok {
+		info.ID = int(id)
+	}
+	info.TypeName, _ = metadata["type-name"].(string)
+	if priority, ok := metadataInt(metadata["layer-priority"]); ok {
+		info.LayerPriority = int(priority)
+	}
+	if trackTypes, ok := metadataInt(metadata["track-types"]); ok {
+		info.TrackTypes = int(trackTypes)
+	}
+	if raw, ok := metadata["children-properties"].(string); ok && raw != "" {
+		if children, err := ParseStructure(raw); err == nil {
+			info.ChildrenProperties = children
+		}
+	}
+	info.Text, _ = metadata["text"].(string)
+	return info, true
+}
+
+// SetClipInfo stores what an OTIO clip should keep of an XGES clip in its
+// metadata. Unknown values are left out.
+func SetClipInfo(clip *gotio.Clip, info ClipMetadata) {
+	metadata := setXgesMetadata(clip)
+	setMetadataValue(metadata, "id", info.ID, info.ID >= 0)
+	setMetadataValue(metadata, "type-name", info.TypeName, info.TypeName != "")
+	setMetadataValue(metadata, "layer-priority", info.LayerPriority, info.LayerPriority >= 0)
+	setMetadataValue(metadata, "track-types", info.TrackTypes, info.TrackTypes != 0)
+	if info.ChildrenProperties != nil {
+		metadata["children-properties"] = info.ChildrenProperties.String()
+	} else {
+		delete(metadata, "children-properties")
+	}
+	setMetadataValue(metadata, "text", info.Text, info.Text != "")
+}
+
+// TrackInfo returns what the metadata of an OTIO track holds about its
+// XGES layer and track. It returns false if the track has no xges metadata.
+func TrackInfo(track *gotio.Track) (TrackMetadata, bool) {
+	info := TrackMetadata{LayerPriority: -1, TrackID: -1, Volume: 1, AutoTransition: true}
+	metadata := xgesMetadata(track.Metadata())
+	if metadata == nil {
+		return info, false
+	}
+	if priority, ok := metadataInt(metadata["layer-priority"]); ok {
+		info.LayerPriority = int(priority)
+	}
+	if id, ok := metadataInt(metadata["track-id"]); ok {
+		info.TrackID = int(id)
+	}
+	if trackType, ok := metadataInt(metadata["track-type"]); ok {
+		info.TrackType = int(trackType)
+	}
+	info.Caps, _ = metadata["caps"].(string)
+	if volume, ok := metadataFloat(metadata["volume"]); ok {
+		info.Volume = volume
+	}
+	if autoTransition, ok := metadataBool(metadata["auto-transition"]); ok {
+		info.AutoTransition = autoTransition
+	}
+	return info, true
+}
+
+// SetTrackInfo stores what an OTIO track should keep of an XGES layer and
+// track in its metadata. Unknown values are left out.
+func SetTrackInfo(track *gotio.Track, info TrackMetadata) {
+	metadata := setXgesMetadata(track)
+	setMetadataValue(metadata, "layer-priority", info.LayerPriority, info.LayerPriority >= 0)
+	setMetadataValue(metadata, "track-id", info.TrackID, info.TrackID >= 0)
+	setMetadataValue(metadata, "track-type", info.TrackType, info.TrackType != 0)
+	setMetadataValue(metadata, "caps", info.Caps, info.Caps != "")
+	metadata["volume"] = info.Volume
+	metadata["auto-transition"] = info.AutoTransition
+}
+
+// setMetadataValue sets a metadata key, or removes it if the value isn't
+// known
+func setMetadataValue(metadata map[string]interface{}, key string, value interface{}, known bool) {
+	if known {
+		metadata[key] = value
+	} else {
+		delete(metadata, key)
+	}
+}
+
+// clipChildrenProperties returns the children-properties an OTIO clip
+// keeps. The title text of title clips is set in them when it was changed.
+func clipChildrenProperties(clip *gotio.Clip, title bool) string {
+	raw, _ := xgesMetadata(clip.Metadata())["children-properties"].(string)
+	info, _ := ClipInfo(clip)
+	if !title || info.Text == "" {
+		return raw
+	}
+
+	children := info.ChildrenProperties
+	if children == nil {
+		children = NewStructure("properties")
+	}
+	name := "text"
+	if existing, ok := children.ChildPropertyName(name); ok {
+		name = existing
+	}
+	if text, ok := children.GetString(name); ok && text == info.Text {
+		return raw
+	}
+	children.SetString(name, info.Text)
+	return children.String()
+}
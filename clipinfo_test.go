@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+const clipInfoXGES = `<?xml version="1.0" ?>
+<ges version='0.4'>
+  <project properties='properties;'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <layer priority='0' properties='properties, auto-transition=(boolean)false;' metadatas='metadatas, volume=(float)0.5;'>
+        <clip id='3' asset-id='GESTitleClip' type-name='GESTitleClip' layer-priority='0' track-types='4' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties, name=(string)title;' children-properties='properties, GESTextOverlay::text=(string)&quot;Hello\ World&quot;, halignment=(int)1;'/>
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestClipInfo(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(clipInfoXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if SchemaVersion(timeline) != MetadataSchemaVersion {
+		t.Errorf("Expected schema version %d, got %d", MetadataSchemaVersion, SchemaVersion(timeline))
+	}
+
+	track := timeline.VideoTracks()[0]
+	trackInfo, ok := TrackInfo(track)
+	expectedTrack := TrackMetadata{LayerPriority: 0, TrackID: 0, Volume: 0.5, AutoTransition: false}
+	if !ok || trackInfo != expectedTrack {
+		t.Errorf("Expected track info %+v, got %+v", expectedTrack, trackInfo)
+	}
+
+	clip := track.Children()[0].(*gotio.Clip)
+	info, ok := ClipInfo(clip)
+	if !ok {
+		t.Fatal("Expected clip info")
+	}
+	if info.ID != 3 || info.TypeName != ClipTypeTitle || info.LayerPriority != 0 || info.TrackTypes != TrackTypeVideo {
+		t.Errorf("Unexpected clip info %+v", info)
+	}
+	if info.Text != "Hello World" {
+		t.Errorf("Expected text 'Hello World', got %q", info.Text)
+	}
+	if halignment, ok := info.ChildrenProperties.GetInt("halignment"); !ok || halignment != 1 {
+		t.Errorf("Expected halignment 1, got %v", info.ChildrenProperties)
+	}
+
+	// A new title text is written into the children-properties
+	info.Text = "Goodbye"
+	SetClipInfo(clip, info)
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	expected := `properties, GESTextOverlay::text=(string)Goodbye, halignment=(int)1;`
+	if got := ges.Project.Timeline.Layers[0].Clips[0].ChildrenProperties; got != expected {
+		t.Errorf("Expected children-properties %s, got %s", expected, got)
+	}
+}
+
+func TestClipInfo_NoMetadata(t *testing.T) {
+	clip := gotio.NewClip("a", nil, nil, nil, nil, nil, "", nil)
+	info, ok := ClipInfo(clip)
+	if ok || info.ID != -1 || info.LayerPriority != -1 || info.ChildrenProperties != nil {
+		t.Errorf("Expected no clip info, got %+v", info)
+	}
+
+	SetClipInfo(clip, ClipMetadata{ID: -1, LayerPriority: -1, TypeName: ClipTypeURI})
+	if metadata := xgesMetadata(clip.Metadata()); len(metadata) != 1 || metadata["type-name"] != ClipTypeURI {
+		t.Errorf("Expected only the type name to be stored, got %v", metadata)
+	}
+}
@@ -0,0 +1,344 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Avalanche-io/gotio"
+
+	xges "github.com/Avalanche-io/otio-xges"
+)
+
+// Formats understood by convert, named after their file extension. launch
+// is a ges-launch-1.0 command line.
+const (
+	formatXGES   = "xges"
+	formatOTIO   = "otio"
+	formatLaunch = "launch"
+)
+
+// adapterFormats maps adapter registry names to convert formats
+var adapterFormats = map[string]string{
+	"xges":      formatXGES,
+	"otio_json": formatOTIO,
+}
+
+// remapFlag collects repeated -remap OLD=NEW flags
+type remapFlag map[string]string
+
+func (f remapFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for oldPrefix, newPrefix := range f {
+		pairs = append(pairs, oldPrefix+"="+newPrefix)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f remapFlag) Set(value string) error {
+	oldPrefix, newPrefix, ok := strings.Cut(value, "=")
+	if !ok || oldPrefix == "" {
+		return fmt.Errorf("expected OLD=NEW, got %q", value)
+	}
+	f[oldPrefix] = newPrefix
+	return nil
+}
+
+func runConvert(args []string, e *env) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	from := fs.String("from", "", "input `format`, xges, otio or launch (default: from the extension or content)")
+	to := fs.String("to", "", "output `format`, xges, otio or launch (default: from the extension, else the other format)")
+	rate := fs.Float64("rate", 0, "frame `rate` of the converted times (default: detected)")
+	strict := fs.Bool("strict", false, "fail instead of dropping content the output format can't hold")
+	proxies := fs.Bool("proxies", false, "reference proxy media instead of the originals when reading XGES")
+	formatVersion := fs.String("format-version", "", "XGES format `version` to write (default: the oldest the content fits in)")
+	autoTransitions := fs.Bool("auto-transitions", false, "add the transitions GES creates between overlapping clips when writing XGES")
+	remap := remapFlag{}
+	fs.Var(remap, "remap", "rewrite media URIs starting with `OLD=NEW` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: otio-xges convert [flags] INPUT OUTPUT")
+		fmt.Fprintln(fs.Output(), "\nConvert between .xges and .otio files. Use - for stdin or stdout.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+	input, output := fs.Arg(0), fs.Arg(1)
+
+	data, err := readInput(input, e.stdin)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+
+	inFormat, err := inputFormat(*from, input, data)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitUsage
+	}
+	outFormat, err := outputFormat(*to, output, inFormat)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitUsage
+	}
+
+	encodeOpts := xges.EncodeOptions{
+		Rate:            *rate,
+		Strict:          *strict,
+		PathMap:         remap,
+		Version:         *formatVersion,
+		AutoTransitions: *autoTransitions,
+	}
+	var warnings []string
+
+	if err := checkFlags(fs, inFormat, outFormat); err != nil {
+		e.errorf("%v", err)
+		return exitUsage
+	}
+
+	// Between XGES and ges-launch the GES model is converted directly
+	if directConversion(inFormat, outFormat) {
+		if warnings, err = convertProject(inFormat, data, output, e.stdout, encodeOpts); err != nil {
+			e.errorf("%v", err)
+			return exitFailure
+		}
+	} else {
+		timeline, decodeWarnings, err := decodeTimeline(inFormat, data, xges.DecodeOptions{
+			Rate:       *rate,
+			Strict:     *strict,
+			UseProxies: *proxies,
+			PathMap:    remap,
+		})
+		if err != nil {
+			e.errorf("reading %s: %v", input, err)
+			return exitFailure
+		}
+
+		encodeWarnings, err := writeOutput(output, e.stdout, func(w io.Writer) ([]string, error) {
+			return encodeTimeline(outFormat, timeline, w, encodeOpts)
+		})
+		if err != nil {
+			e.errorf("writing %s: %v", output, err)
+			return exitFailure
+		}
+		warnings = append(decodeWarnings, encodeWarnings...)
+	}
+
+	for _, warning := range warnings {
+		fmt.Fprintf(e.stderr, "otio-xges: warning: %s\n", warning)
+	}
+	if len(warnings) > 0 {
+		return exitLossy
+	}
+	return exitOK
+}
+
+// readInput reads a whole input file, or stdin for "-"
+func readInput(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(stdin)
+	}
+	return os.ReadFile(path)
+}
+
+// inputFormat picks the input format from the flag, the extension or the
+// content, in that order
+func inputFormat(forced, path string, data []byte) (string, error) {
+	if forced != "" {
+		return checkFormat(forced)
+	}
+	if path != "-" {
+		if a, ok := xges.AdapterForPath(path); ok {
+			if format, ok := adapterFormats[a.Name]; ok {
+				return format, nil
+			}
+		}
+	}
+	if a, ok := xges.DetectAdapter(data); ok {
+		if format, ok := adapterFormats[a.Name]; ok {
+			return format, nil
+		}
+	}
+	if line := bytes.TrimSpace(data); bytes.HasPrefix(line, []byte(xges.LaunchProgram)) || bytes.HasPrefix(line, []byte("+")) {
+		return formatLaunch, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %s, use -from", path)
+}
+
+// outputFormat picks the output format from the flag or the extension,
+// defaulting to the opposite of the input format
+func outputFormat(forced, path, inFormat string) (string, error) {
+	if forced != "" {
+		return checkFormat(forced)
+	}
+	if path != "-" {
+		if a, ok := xges.AdapterForPath(path); ok {
+			if format, ok := adapterFormats[a.Name]; ok {
+				return format, nil
+			}
+		}
+	}
+	if inFormat == formatXGES {
+		return formatOTIO, nil
+	}
+	return formatXGES, nil
+}
+
+// checkFormat validates a format name given on the command line
+func checkFormat(format string) (string, error) {
+	switch format {
+	case formatXGES, formatOTIO, formatLaunch:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected xges, otio or launch", format)
+	}
+}
+
+// decodeTimeline reads a timeline, returning the lossy conversions made
+func decodeTimeline(format string, data []byte, opts xges.DecodeOptions) (*gotio.Timeline, []string, error) {
+	if format == formatOTIO {
+		a, _ := xges.LookupAdapter("otio_json")
+		timeline, err := a.Read(bytes.NewReader(data))
+		return timeline, nil, err
+	}
+
+	decoder := xges.NewDecoder(bytes.NewReader(data))
+	decoder.SetOptions(opts)
+	if format == formatLaunch {
+		args, err := xges.ParseLaunchCommand(string(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		timeline, err := decoder.DecodeLaunchArgs(args)
+		return timeline, decoder.Warnings(), err
+	}
+	timeline, err := decoder.Decode()
+	return timeline, decoder.Warnings(), err
+}
+
+// encodeTimeline writes a timeline, returning the lossy conversions made
+func encodeTimeline(format string, timeline *gotio.Timeline, w io.Writer, opts xges.EncodeOptions) ([]string, error) {
+	if format == formatOTIO {
+		a, _ := xges.LookupAdapter("otio_json")
+		return nil, a.Write(w, timeline)
+	}
+
+	encoder := xges.NewEncoder(w)
+	encoder.SetOptions(opts)
+	if format == formatLaunch {
+		args, err := encoder.EncodeLaunchArgs(timeline)
+		if err == nil {
+			_, err = fmt.Fprintln(w, xges.FormatLaunchCommand(args))
+		}
+		return encoder.Warnings(), err
+	}
+	err := encoder.Encode(timeline)
+	return encoder.Warnings(), err
+}
+
+// directConversion reports whether the GES model is converted between the
+// formats without going through OTIO
+func directConversion(inFormat, outFormat string) bool {
+	return inFormat != formatOTIO && outFormat != formatOTIO && inFormat != outFormat
+}
+
+// checkFlags rejects the flags that would have no effect on a conversion:
+// proxies only exist in XGES input, frame rates only matter to OTIO, and
+// ges-launch has no format version and always creates transitions itself
+func checkFlags(fs *flag.FlagSet, inFormat, outFormat string) error {
+	direct := directConversion(inFormat, outFormat)
+	var unused []string
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "rate":
+			if direct {
+				unused = append(unused, "-"+f.Name)
+			}
+		case "proxies":
+			if direct || inFormat != formatXGES {
+				unused = append(unused, "-"+f.Name)
+			}
+		case "format-version", "auto-transitions":
+			if direct && outFormat == formatLaunch {
+				unused = append(unused, "-"+f.Name)
+			}
+		}
+	})
+	if len(unused) > 0 {
+		return fmt.Errorf("%s can't be used when converting %s to %s", strings.Join(unused, ", "), inFormat, outFormat)
+	}
+	return nil
+}
+
+// convertProject converts between XGES and a ges-launch command line
+// without going through OTIO, returning the lossy conversions made
+func convertProject(inFormat string, data []byte, output string, stdout io.Writer, opts xges.EncodeOptions) ([]string, error) {
+	var ges *xges.GES
+	var err error
+	if inFormat == formatLaunch {
+		var args []string
+		if args, err = xges.ParseLaunchCommand(string(data)); err == nil {
+			ges, err = xges.ParseLaunchArgs(args)
+		}
+	} else {
+		ges, err = xges.ParseDocument(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return writeOutput(output, stdout, func(w io.Writer) ([]string, error) {
+		encoder := xges.NewEncoder(w)
+		encoder.SetOptions(opts)
+		if inFormat == formatLaunch {
+			err := encoder.EncodeProject(ges)
+			return encoder.Warnings(), err
+		}
+		args, err := encoder.EncodeProjectLaunchArgs(ges)
+		if err == nil {
+			_, err = fmt.Fprintln(w, xges.FormatLaunchCommand(args))
+		}
+		return encoder.Warnings(), err
+	})
+}
+
+// writeOutput runs write against the output file, or stdout for "-". A
+// failed conversion leaves an existing output file untouched.
+func writeOutput(path string, stdout io.Writer, write func(w io.Writer) ([]string, error)) ([]string, error) {
+	if path != "-" {
+		var warnings []string
+		err := xges.WriteFileWith(path, func(w io.Writer) error {
+			var err error
+			warnings, err = write(w)
+			return err
+		})
+		return warnings, err
+	}
+
+	w := bufio.NewWriter(stdout)
+	warnings, err := write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	return warnings, err
+}
@@ -0,0 +1,75 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"text/tabwriter"
+
+	xges "github.com/Avalanche-io/otio-xges"
+)
+
+func runDiff(args []string, e *env) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	exitCode := fs.Bool("exit-code", false, "exit with code 4 if the projects differ")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: otio-xges diff [flags] OLD.xges NEW.xges")
+		fmt.Fprintln(fs.Output(), "\nList the edits between two versions of an XGES project. Use - for stdin.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	before, err := readProject(fs.Arg(0), e.stdin)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+	after, err := readProject(fs.Arg(1), e.stdin)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+
+	changes := xges.Diff(before, after)
+	if changes == nil {
+		changes = []xges.Change{}
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(changes)
+	} else {
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		for _, c := range changes {
+			fmt.Fprintf(tw, "%s\t%s\n", c.Kind, c.Message)
+		}
+		err = tw.Flush()
+	}
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+
+	if *exitCode && len(changes) > 0 {
+		return exitIssues
+	}
+	return exitOK
+}
@@ -0,0 +1,60 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	xges "github.com/Avalanche-io/otio-xges"
+)
+
+func TestDiff_Command(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.xges")
+	after := filepath.Join(dir, "after.xges")
+
+	// Renumber the clips and push clip b a second later
+	edited := strings.NewReplacer(
+		"id='0'", "id='5'",
+		"id='1'", "id='6'",
+		"start='2000000000'", "start='3000000000'",
+	).Replace(testXGES)
+	if err := os.WriteFile(before, []byte(testXGES), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(after, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, stderr := runCommand("", "diff", before, after)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "clip-moved") || !strings.Contains(stdout, "clip 6 (b.mov) moved 25 frames later") {
+		t.Errorf("Unexpected output:\n%s", stdout)
+	}
+
+	code, stdout, _ = runCommand("", "diff", "-json", "-exit-code", before, after)
+	if code != exitIssues {
+		t.Errorf("Expected exit code %d with changes, got %d", exitIssues, code)
+	}
+	var changes []xges.Change
+	if err := json.Unmarshal([]byte(stdout), &changes); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Kind != xges.ChangeClipMoved || changes[0].Delta != 1000000000 {
+		t.Errorf("Unexpected changes %+v", changes)
+	}
+
+	if code, stdout, _ := runCommand(testXGES, "diff", "-exit-code", before, "-"); code != exitOK || stdout != "" {
+		t.Errorf("Expected no changes against itself, got %d:\n%s", code, stdout)
+	}
+	if code, _, _ := runCommand("", "diff", before); code != exitUsage {
+		t.Errorf("Expected exit code %d with one file, got %d", exitUsage, code)
+	}
+}
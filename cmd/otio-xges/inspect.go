@@ -0,0 +1,335 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+
+	xges "github.com/Avalanche-io/otio-xges"
+)
+
+// inspectReport describes an XGES project as printed by inspect
+type inspectReport struct {
+	Name      string        `json:"name,omitempty"`
+	Version   string        `json:"version"`
+	FrameRate float64       `json:"frame_rate"`
+	Duration  timeReport    `json:"duration"`
+	Metadata  []fieldReport `json:"metadata"`
+	Tracks    []trackReport `json:"tracks"`
+	Layers    []layerReport `json:"layers"`
+	Groups    []groupReport `json:"groups"`
+	Assets    []assetReport `json:"assets"`
+}
+
+type fieldReport struct {
+	Name  string `json:"name"`
+	Type  string `json:"type,omitempty"`
+	Value string `json:"value"`
+}
+
+type trackReport struct {
+	ID              int    `json:"id"`
+	Type            string `json:"type"`
+	Caps            string `json:"caps"`
+	RestrictionCaps string `json:"restriction_caps,omitempty"`
+}
+
+type layerReport struct {
+	Priority    int          `json:"priority"`
+	Clips       []clipReport `json:"clips"`
+	Transitions []clipReport `json:"transitions"`
+}
+
+type clipReport struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name,omitempty"`
+	TypeName   string     `json:"type_name"`
+	Asset      string     `json:"asset"`
+	TrackTypes string     `json:"track_types"`
+	Start      timeReport `json:"start"`
+	Duration   timeReport `json:"duration"`
+	Inpoint    timeReport `json:"inpoint"`
+}
+
+type groupReport struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Children []int  `json:"children"`
+}
+
+type assetReport struct {
+	ID       string      `json:"id"`
+	Type     string      `json:"type"`
+	Duration *timeReport `json:"duration,omitempty"`
+	Proxy    string      `json:"proxy,omitempty"`
+}
+
+// timeReport is a GStreamer time shown as nanoseconds, frames and timecode
+type timeReport struct {
+	Nanoseconds uint64 `json:"ns"`
+	Frames      int64  `json:"frames"`
+	Timecode    string `json:"timecode"`
+}
+
+func (t timeReport) String() string {
+	return fmt.Sprintf("%s (%d)", t.Timecode, t.Frames)
+}
+
+func runInspect(args []string, e *env) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	asJSON := fs.Bool("json", false, "print JSON instead of tables")
+	rate := fs.Float64("rate", 0, "frame `rate` for frames and timecode (default: from the project, else 25)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: otio-xges inspect [flags] FILE.xges")
+		fmt.Fprintln(fs.Output(), "\nPrint the tracks, layers, clips, groups and assets of an XGES project. Use - for stdin.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	ges, err := readProject(fs.Arg(0), e.stdin)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+
+	report := buildInspectReport(ges, *rate)
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(report)
+	} else {
+		err = printInspectReport(e.stdout, report)
+	}
+	if err != nil {
+		e.errorf("%v", err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// readProject parses an XGES file, or stdin for "-", into the document model
+func readProject(path string, stdin io.Reader) (*xges.GES, error) {
+	data, err := readInput(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	ges, err := xges.ParseDocument(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return ges, nil
+}
+
+// projectRate returns the frame rate to show times in
+func projectRate(ges *xges.GES, forced float64) float64 {
+	if forced > 0 {
+		return forced
+	}
+	if rate := ges.Project.Timeline.FrameRate(); rate > 0 {
+		return rate
+	}
+	return 25
+}
+
+func buildInspectReport(ges *xges.GES, forcedRate float64) *inspectReport {
+	timeline := &ges.Project.Timeline
+	rate := projectRate(ges, forcedRate)
+
+	report := &inspectReport{
+		Version:   ges.Version,
+		FrameRate: rate,
+		Duration:  newTimeReport(timeline.Duration(), rate),
+		Metadata:  []fieldReport{},
+		Tracks:    []trackReport{},
+		Layers:    []layerReport{},
+		Groups:    []groupReport{},
+		Assets:    []assetReport{},
+	}
+
+	if metadatas, err := xges.ParseStructure(ges.Project.Metadatas); err == nil {
+		for _, f := range metadatas.Fields {
+			report.Metadata = append(report.Metadata, fieldReport{Name: f.Name, Type: f.Type, Value: f.Value})
+		}
+		report.Name, _ = metadatas.GetString("name")
+	}
+
+	for _, track := range timeline.Tracks {
+		tr := trackReport{ID: track.TrackID, Type: trackTypeName(track.TrackType), Caps: track.Caps}
+		if caps, ok := track.RestrictionCaps(); ok {
+			tr.RestrictionCaps = strings.TrimSuffix(caps.String(), ";")
+		}
+		report.Tracks = append(report.Tracks, tr)
+	}
+
+	for _, layer := range timeline.Layers {
+		lr := layerReport{Priority: layer.Priority, Clips: []clipReport{}, Transitions: []clipReport{}}
+		for _, clip := range layer.Clips {
+			cr := clipReport{
+				ID:         clip.ID,
+				Name:       clipName(&clip),
+				TypeName:   clip.TypeName,
+				Asset:      clip.AssetID,
+				TrackTypes: trackTypeName(clip.TrackTypes),
+				Start:      newTimeReport(clip.Start, rate),
+				Duration:   newTimeReport(clip.Duration, rate),
+				Inpoint:    newTimeReport(clip.Inpoint, rate),
+			}
+			if clip.TypeName == xges.ClipTypeTransition {
+				lr.Transitions = append(lr.Transitions, cr)
+			} else {
+				lr.Clips = append(lr.Clips, cr)
+			}
+		}
+		report.Layers = append(report.Layers, lr)
+	}
+
+	for _, group := range timeline.AllGroups() {
+		gr := groupReport{ID: group.ID, Children: []int{}}
+		if props, err := xges.ParseStructure(group.Properties); err == nil {
+			gr.Name, _ = props.GetString("name")
+		}
+		for _, child := range group.Children {
+			gr.Children = append(gr.Children, child.ID)
+		}
+		report.Groups = append(report.Groups, gr)
+	}
+
+	for _, asset := range ges.Project.Assets() {
+		ar := assetReport{ID: asset.ID, Type: asset.ExtractableTypeName, Proxy: asset.ProxyID}
+		if duration, ok := asset.Duration(); ok {
+			tr := newTimeReport(duration, rate)
+			ar.Duration = &tr
+		}
+		report.Assets = append(report.Assets, ar)
+	}
+
+	return report
+}
+
+func printInspectReport(w io.Writer, report *inspectReport) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "Project:\t%s\n", report.Name)
+	fmt.Fprintf(tw, "Format version:\t%s\n", report.Version)
+	fmt.Fprintf(tw, "Frame rate:\t%g\n", report.FrameRate)
+	fmt.Fprintf(tw, "Duration:\t%s\n", report.Duration)
+	for _, f := range report.Metadata {
+		fmt.Fprintf(tw, "  %s\t(%s) %s\n", f.Name, f.Type, f.Value)
+	}
+
+	fmt.Fprintf(tw, "\nTracks\n")
+	fmt.Fprintf(tw, "ID\tTYPE\tCAPS\tRESTRICTION CAPS\n")
+	for _, t := range report.Tracks {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", t.ID, t.Type, t.Caps, t.RestrictionCaps)
+	}
+
+	for _, layer := range report.Layers {
+		fmt.Fprintf(tw, "\nLayer %d: %d clips, %d transitions\n", layer.Priority, len(layer.Clips), len(layer.Transitions))
+		if len(layer.Clips) > 0 {
+			fmt.Fprintf(tw, "ID\tNAME\tTYPE\tTRACKS\tASSET\tSTART\tDURATION\tINPOINT\n")
+			for _, c := range layer.Clips {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.TypeName, c.TrackTypes, c.Asset, c.Start, c.Duration, c.Inpoint)
+			}
+		}
+		if len(layer.Transitions) > 0 {
+			fmt.Fprintf(tw, "TRANSITION\tNAME\tTYPE\tTRACKS\tASSET\tSTART\tDURATION\n")
+			for _, c := range layer.Transitions {
+				fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", c.ID, c.Name, c.TypeName, c.TrackTypes, c.Asset, c.Start, c.Duration)
+			}
+		}
+	}
+
+	if len(report.Groups) > 0 {
+		fmt.Fprintf(tw, "\nGroups\n")
+		fmt.Fprintf(tw, "ID\tNAME\tCHILDREN\n")
+		for _, g := range report.Groups {
+			fmt.Fprintf(tw, "%d\t%s\t%s\n", g.ID, g.Name, strings.Trim(fmt.Sprint(g.Children), "[]"))
+		}
+	}
+
+	if len(report.Assets) > 0 {
+		fmt.Fprintf(tw, "\nAssets\n")
+		fmt.Fprintf(tw, "TYPE\tDURATION\tID\tPROXY\n")
+		for _, a := range report.Assets {
+			duration := ""
+			if a.Duration != nil {
+				duration = a.Duration.String()
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", a.Type, duration, a.ID, a.Proxy)
+		}
+	}
+
+	return tw.Flush()
+}
+
+// clipName returns the name property of a clip
+func clipName(clip *xges.Clip) string {
+	props, err := xges.ParseStructure(clip.Properties)
+	if err != nil {
+		return ""
+	}
+	name, _ := props.GetString("name")
+	return name
+}
+
+// trackTypeName describes a track type bitmask, e.g. "audio+video"
+func trackTypeName(trackTypes int) string {
+	var names []string
+	for _, tt := range []struct {
+		bit  int
+		name string
+	}{
+		{xges.TrackTypeUnknown, "unknown"},
+		{xges.TrackTypeAudio, "audio"},
+		{xges.TrackTypeVideo, "video"},
+		{xges.TrackTypeText, "text"},
+		{xges.TrackTypeCustom, "custom"},
+	} {
+		if trackTypes&tt.bit != 0 {
+			names = append(names, tt.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "+")
+}
+
+// newTimeReport converts nanoseconds to frames and timecode at rate
+func newTimeReport(ns uint64, rate float64) timeReport {
+	frames := int64(math.Round(float64(ns) * rate / float64(xges.GSTSecond)))
+	return timeReport{Nanoseconds: ns, Frames: frames, Timecode: timecode(frames, rate)}
+}
+
+// timecode formats a frame count as non-drop-frame HH:MM:SS:FF
+func timecode(frames int64, rate float64) string {
+	fps := int64(math.Round(rate))
+	if fps <= 0 {
+		fps = 1
+	}
+	ff := frames % fps
+	seconds := frames / fps
+	return fmt.Sprintf("%02d:%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60, ff)
+}
@@ -0,0 +1,76 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestInspect_Table(t *testing.T) {
+	code, stdout, stderr := runCommand(testXGES, "inspect", "-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	for _, expected := range []string{
+		"(string) CLI",
+		"00:00:03:00 (75)",
+		"video/x-raw(ANY)",
+		"Layer 0: 2 clips, 0 transitions",
+		"00:00:02:00 (50)",
+		"file:///media/b.mov",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Output missing %q:\n%s", expected, stdout)
+		}
+	}
+}
+
+func TestInspect_JSON(t *testing.T) {
+	code, stdout, stderr := runCommand("", "inspect", "-json", "../../testdata/xges_example.xges")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	var report inspectReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+
+	if report.FrameRate != 25 {
+		t.Errorf("Expected frame rate 25, got %v", report.FrameRate)
+	}
+	if len(report.Assets) != 7 || len(report.Groups) != 1 {
+		t.Errorf("Expected 7 assets and 1 group, got %d and %d", len(report.Assets), len(report.Groups))
+	}
+
+	clips, transitions := 0, 0
+	for _, layer := range report.Layers {
+		clips += len(layer.Clips)
+		transitions += len(layer.Transitions)
+	}
+	if clips != 6 || transitions != 1 {
+		t.Errorf("Expected 6 clips and 1 transition, got %d and %d", clips, transitions)
+	}
+}
+
+func TestTimecode(t *testing.T) {
+	testCases := []struct {
+		frames   int64
+		rate     float64
+		expected string
+	}{
+		{0, 25, "00:00:00:00"},
+		{24, 24, "00:00:01:00"},
+		{90001, 25, "01:00:00:01"},
+		{1799, 29.97, "00:00:59:29"},
+	}
+	for _, tc := range testCases {
+		if got := timecode(tc.frames, tc.rate); got != tc.expected {
+			t.Errorf("timecode(%d, %v) = %s, expected %s", tc.frames, tc.rate, got, tc.expected)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	xges "github.com/Avalanche-io/otio-xges"
+)
+
+// listFlag collects repeated or comma separated flag values
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
+
+// severityFlag collects repeated -severity RULE=LEVEL flags
+type severityFlag map[string]xges.Severity
+
+func (f severityFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for rule, severity := range f {
+		pairs = append(pairs, rule+"="+string(severity))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f severityFlag) Set(value string) error {
+	rule, level, ok := strings.Cut(value, "=")
+	if !ok || rule == "" {
+		return fmt.Errorf("expected RULE=LEVEL, got %q", value)
+	}
+	severity, err := xges.ParseSeverity(level)
+	if err != nil {
+		return err
+	}
+	f[rule] = severity
+	return nil
+}
+
+// lintReport is the JSON output of lint
+type lintReport struct {
+	Files  []lintFileReport `json:"files"`
+	Failed bool             `json:"failed"`
+}
+
+type lintFileReport struct {
+	File   string       `json:"file"`
+	Issues []xges.Issue `json:"issues"`
+}
+
+func runLint(args []string, e *env) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	list := fs.Bool("list", false, "list the lint rules and exit")
+	rate := fs.Float64("rate", 0, "frame `rate` to measure frames at (default: from the project)")
+	minFrames := fs.Int("min-frames", 3, "clips shorter than this many `frames` are flash frames")
+	failOn := fs.String("fail-on", string(xges.SeverityError), "exit with code 4 for issues of this `level` or above")
+	var disable listFlag
+	fs.Var(&disable, "disable", "`rules` not to run, comma separated (repeatable)")
+	severities := severityFlag{}
+	fs.Var(severities, "severity", "change the severity of a rule as `RULE=LEVEL` (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: otio-xges lint [flags] FILE.xges...")
+		fmt.Fprintln(fs.Output(), "\nCheck XGES projects for edit problems. Use - for stdin.")
+		fmt.Fprintln(fs.Output(), "\nFlags:")
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *list {
+		tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+		for _, rule := range xges.LintRules() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", rule.Name, rule.Severity, rule.Description)
+		}
+		tw.Flush()
+		return exitOK
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+	threshold, err := xges.ParseSeverity(*failOn)
+	if err != nil {
+		e.errorf("%v", err)
+		return exitUsage
+	}
+
+	opts := xges.LintOptions{
+		Rate:          *rate,
+		MinClipFrames: *minFrames,
+		Disable:       disable,
+		Severities:    severities,
+	}
+
+	report := lintReport{Files: []lintFileReport{}}
+	for _, path := range fs.Args() {
+		ges, err := readProject(path, e.stdin)
+		if err != nil {
+			e.errorf("%v", err)
+			return exitFailure
+		}
+		issues, err := xges.Lint(ges, opts)
+		if err != nil {
+			e.errorf("%v", err)
+			return exitUsage
+		}
+		if issues == nil {
+			issues = []xges.Issue{}
+		}
+
+		for _, issue := range issues {
+			if issue.Severity.Level() >= threshold.Level() {
+				report.Failed = true
+			}
+			if !*asJSON {
+				fmt.Fprintf(e.stdout, "%s: %s\n", path, formatIssue(issue, projectRate(ges, *rate)))
+			}
+		}
+		report.Files = append(report.Files, lintFileReport{File: path, Issues: issues})
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(e.stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			e.errorf("%v", err)
+			return exitFailure
+		}
+	}
+	if report.Failed {
+		return exitIssues
+	}
+	return exitOK
+}
+
+// formatIssue describes an issue on one line
+func formatIssue(issue xges.Issue, rate float64) string {
+	where := "project"
+	if issue.Layer >= 0 {
+		where = fmt.Sprintf("layer %d at %s", issue.Layer, newTimeReport(issue.Start, rate).Timecode)
+	}
+	return fmt.Sprintf("%s: %s: %s [%s]", where, issue.Severity, issue.Message, issue.Rule)
+}
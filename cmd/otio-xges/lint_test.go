@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLint_Command(t *testing.T) {
+	// Clip b overlaps clip a by a second with no transition
+	input := strings.Replace(testXGES, "start='2000000000'", "start='1000000000'", 1)
+
+	code, stdout, stderr := runCommand(input, "lint", "-")
+	if code != exitIssues {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitIssues, code, stderr)
+	}
+	if !strings.Contains(stdout, "-: layer 0 at 00:00:01:00: error: clips 0 and 1 overlap") {
+		t.Errorf("Unexpected output:\n%s", stdout)
+	}
+
+	code, stdout, _ = runCommand(input, "lint", "-json", "-severity", "overlap=warning", "-")
+	if code != exitOK {
+		t.Errorf("Expected exit code %d with overlaps as warnings, got %d", exitOK, code)
+	}
+	var report lintReport
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+		t.Fatalf("Invalid JSON output: %v", err)
+	}
+	if report.Failed || len(report.Files) != 1 || len(report.Files[0].Issues) != 1 {
+		t.Errorf("Unexpected report %+v", report)
+	}
+
+	if code, _, _ := runCommand(input, "lint", "-fail-on", "warning", "-severity", "overlap=warning", "-"); code != exitIssues {
+		t.Errorf("Expected exit code %d failing on warnings, got %d", exitIssues, code)
+	}
+	if code, _, _ := runCommand(input, "lint", "-disable", "overlap,unused-asset", "-"); code != exitOK {
+		t.Errorf("Expected exit code %d with overlap disabled, got %d", exitOK, code)
+	}
+}
+
+func TestLint_CommandErrors(t *testing.T) {
+	testCases := [][]string{
+		{"lint"},
+		{"lint", "-fail-on", "fatal", "-"},
+		{"lint", "-severity", "overlap", "-"},
+		{"lint", "-disable", "nope", "-"},
+	}
+	for _, args := range testCases {
+		if code, _, _ := runCommand(testXGES, args...); code != exitUsage {
+			t.Errorf("Expected exit code %d for %v, got %d", exitUsage, args, code)
+		}
+	}
+
+	code, stdout, _ := runCommand("", "lint", "-list")
+	if code != exitOK || !strings.Contains(stdout, "flash-frame") {
+		t.Errorf("Unexpected rule list (%d):\n%s", code, stdout)
+	}
+}
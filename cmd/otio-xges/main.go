@@ -0,0 +1,95 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+// Command otio-xges converts GStreamer Editing Services projects to and from
+// OpenTimelineIO.
+//
+// Usage:
+//
+//	otio-xges <command> [flags] [arguments]
+//
+// Exit codes are 0 on success, 1 on failure, 2 for invalid usage, 3 when
+// a conversion succeeded but dropped information and 4 when lint found
+// problems or diff -exit-code found changes.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+	exitLossy   = 3
+	exitIssues  = 4
+)
+
+// env holds the standard streams of a command invocation
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// errorf reports a failure on stderr
+func (e *env) errorf(format string, args ...interface{}) {
+	fmt.Fprintf(e.stderr, "otio-xges: "+format+"\n", args...)
+}
+
+// command is a subcommand of otio-xges
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, e *env) int
+}
+
+var commands = []command{
+	{"convert", "convert between .xges and .otio files", runConvert},
+	{"inspect", "print the contents of an XGES project", runInspect},
+	{"lint", "check XGES projects for edit problems", runLint},
+	{"diff", "list the edits between two XGES projects", runDiff},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], &env{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}))
+}
+
+// run executes the command line and returns the exit code
+func run(args []string, e *env) int {
+	if len(args) == 0 {
+		usage(e.stderr)
+		return exitUsage
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		usage(e.stdout)
+		return exitOK
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], e)
+		}
+	}
+
+	e.errorf("unknown command %q", args[0])
+	usage(e.stderr)
+	return exitUsage
+}
+
+// usage prints the list of commands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: otio-xges <command> [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'otio-xges <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testXGES = `<?xml version="1.0" ?>
+<ges version='0.3'>
+  <project properties='properties;' metadatas='metadatas, name=(string)"CLI";'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0' properties='properties, restriction-caps=(string)"video/x-raw\,\ framerate\=\(fraction\)25/1";'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///media/a.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties, name=(string)"a";' />
+        <clip id='1' asset-id='file:///media/b.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='2000000000' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)"b";' />
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+// runCommand runs the command line with the given stdin
+func runCommand(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &env{stdin: strings.NewReader(stdin), stdout: &stdout, stderr: &stderr})
+	return code, stdout.String(), stderr.String()
+}
+
+func TestConvert_Stdio(t *testing.T) {
+	code, stdout, stderr := runCommand(testXGES, "convert", "-to", "xges", "-remap", "file:///media/=file:///srv/", "-", "-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "file:///srv/a.mov") {
+		t.Errorf("Output missing remapped media:\n%s", stdout)
+	}
+}
+
+func TestConvert_Files(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "in.xges")
+	output := filepath.Join(dir, "out.xges")
+	if err := os.WriteFile(input, []byte(testXGES), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	code, _, stderr := runCommand("", "convert", "-rate", "50", input, output)
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Output not written: %v", err)
+	}
+	if !strings.Contains(string(data), `framerate\=\(fraction\)50/1`) {
+		t.Errorf("Output missing requested rate:\n%s", data)
+	}
+}
+
+func TestConvert_Lossy(t *testing.T) {
+	input := strings.Replace(testXGES, "GESUriClip", "GESOverlayClip", 1)
+
+	code, stdout, stderr := runCommand(input, "convert", "-to", "xges", "-", "-")
+	if code != exitLossy {
+		t.Errorf("Expected exit code %d, got %d", exitLossy, code)
+	}
+	if !strings.Contains(stderr, "warning") || stdout == "" {
+		t.Errorf("Expected output and a warning, got stderr %q", stderr)
+	}
+
+	dir := t.TempDir()
+	output := filepath.Join(dir, "out.xges")
+	code, _, _ = runCommand(input, "convert", "-strict", "-", output)
+	if code != exitFailure {
+		t.Errorf("Expected exit code %d in strict mode, got %d", exitFailure, code)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Error("Expected no output file after a failed conversion")
+	}
+
+	// An existing output is kept when the conversion fails
+	if err := os.WriteFile(output, []byte("previous"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runCommand(input, "convert", "-strict", "-", output)
+	if data, _ := os.ReadFile(output); string(data) != "previous" {
+		t.Errorf("Expected the previous output kept, got %q", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("Expected no temporary file left, got %v", entries)
+	}
+}
+
+func TestUsageErrors(t *testing.T) {
+	testCases := [][]string{
+		{},
+		{"frobnicate"},
+		{"convert", "only-one-arg"},
+		{"convert", "-remap", "nope", "-", "-"},
+		{"convert", "-from", "edl", "-", "-"},
+	}
+	for _, args := range testCases {
+		if code, _, _ := runCommand(testXGES, args...); code != exitUsage {
+			t.Errorf("Expected exit code %d for %v, got %d", exitUsage, args, code)
+		}
+	}
+
+	if code, _, _ := runCommand("", "convert", "missing.xges", "out.otio"); code != exitFailure {
+		t.Errorf("Expected exit code %d for a missing input, got %d", exitFailure, code)
+	}
+}
+
+func TestConvert_Launch(t *testing.T) {
+	code, stdout, stderr := runCommand(testXGES, "convert", "-to", "launch", "-", "-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.HasPrefix(stdout, "ges-launch-1.0 +track video") || !strings.Contains(stdout, "+clip file:///media/b.mov name=b duration=1.0") {
+		t.Errorf("Unexpected command line:\n%s", stdout)
+	}
+
+	// The command line is detected and converted back to XGES
+	code, project, stderr := runCommand(stdout, "convert", "-", "-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(project, `asset-id='file:///media/b.mov'`) || !strings.Contains(project, `start='2000000000'`) {
+		t.Errorf("Unexpected project:\n%s", project)
+	}
+
+	code, _, _ = runCommand(testXGES, "convert", "-to", "launch", "-from", "otio", "-", "-")
+	if code != exitFailure {
+		t.Errorf("Expected exit code %d reading XGES as OTIO, got %d", exitFailure, code)
+	}
+}
+
+func TestConvert_LaunchFlags(t *testing.T) {
+	code, stdout, stderr := runCommand(testXGES, "convert", "-to", "launch", "-remap", "file:///media/=file:///footage/", "-", "-")
+	if code != exitOK {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitOK, code, stderr)
+	}
+	if !strings.Contains(stdout, "+clip file:///footage/b.mov") {
+		t.Errorf("Expected remapped media, got:\n%s", stdout)
+	}
+
+	for _, flag := range [][]string{{"-rate", "30"}, {"-proxies"}, {"-format-version", "0.4"}, {"-auto-transitions"}} {
+		args := append([]string{"convert", "-to", "launch"}, flag...)
+		if code, _, _ := runCommand(testXGES, append(args, "-", "-")...); code != exitUsage {
+			t.Errorf("Expected exit code %d for %v, got %d", exitUsage, flag, code)
+		}
+	}
+
+	// Proxies are only listed in XGES input; the flags are checked before reading
+	if code, _, _ := runCommand(testXGES, "convert", "-proxies", "-from", "otio", "-to", "xges", "-", "-"); code != exitUsage {
+		t.Errorf("Expected exit code %d for -proxies with OTIO input, got %d", exitUsage, code)
+	}
+
+	// Content the requested format version can't hold is dropped
+	launch := "ges-launch-1.0 +clip /media/a.mov duration=2.0 +effect 'videorate rate=2.0'"
+	code, project, stderr := runCommand(launch, "convert", "-format-version", "0.4", "-", "-")
+	if code != exitLossy {
+		t.Fatalf("Expected exit code %d, got %d: %s", exitLossy, code, stderr)
+	}
+	if !strings.Contains(stderr, "time effects dropped") || !strings.Contains(project, "<ges version='0.4'>") || strings.Contains(project, "videorate") {
+		t.Errorf("Expected the time effect dropped from a 0.4 project, got %s\n%s", stderr, project)
+	}
+	if code, _, _ = runCommand(launch, "convert", "-strict", "-format-version", "0.4", "-", "-"); code != exitFailure {
+		t.Errorf("Expected exit code %d in strict mode, got %d", exitFailure, code)
+	}
+}
@@ -4,10 +4,10 @@
 package xges
 
 import (
-	"encoding/xml"
 	"fmt"
 	"io"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -17,8 +17,20 @@ import (
 
 // Decoder reads and decodes XGES data
 type Decoder struct {
-	r    io.Reader
-	rate float64
+	r        io.Reader
+	rate     float64
+	opts     DecodeOptions
+	warnings []string
+
+	// Proxy relations from the project resources
+	proxies   map[string]string // asset id -> proxy asset id
+	originals map[string]string // proxy asset id -> original asset id
+
+	// Assets that are still images
+	stillImages map[string]bool
+
+	// Track type of each XGES track id
+	trackTypes map[int]int
 }
 
 // NewDecoder creates a new XGES decoder
@@ -29,16 +41,50 @@ func NewDecoder(r io.Reader) *Decoder {
 	}
 }
 
+// SetOptions configures how the following Decode calls convert the project
+func (d *Decoder) SetOptions(opts DecodeOptions) {
+	d.opts = opts
+}
+
+// Warnings returns the lossy conversions made by the last Decode call
+func (d *Decoder) Warnings() []string {
+	return d.warnings
+}
+
 // Decode reads XGES XML and converts it to an OTIO Timeline
 func (d *Decoder) Decode() (*gotio.Timeline, error) {
-	var ges GES
-	decoder := xml.NewDecoder(d.r)
-	if err := decoder.Decode(&ges); err != nil {
-		return nil, fmt.Errorf("failed to decode XGES XML: %w", err)
+	ges, err := ParseDocument(d.r)
+	if err != nil {
+		return nil, err
 	}
+	return d.DecodeDocument(ges)
+}
 
-	// Extract frame rate from video track
-	d.extractFrameRate(&ges.Project.Timeline)
+// DecodeDocument converts a parsed XGES document to an OTIO Timeline. The
+// decoder's reader is not used. Documents older than format version 0.2 are
+// upgraded to the current model in place.
+func (d *Decoder) DecodeDocument(ges *GES) (*gotio.Timeline, error) {
+	d.warnings = nil
+
+	// Upgrade documents of older format versions to the current model
+	version, err := normalizeDocument(ges)
+	if err != nil {
+		return nil, err
+	}
+	if !version.Supported() {
+		if err := d.warnf("XGES format version %s is newer than %s, unknown content may be dropped", version, LatestFormatVersion); err != nil {
+			return nil, err
+		}
+	}
+
+	d.indexAssets(ges.Project.Assets())
+
+	// Use the requested frame rate, or extract it from the video track
+	if d.opts.Rate > 0 {
+		d.rate = d.opts.Rate
+	} else {
+		d.extractFrameRate(&ges.Project.Timeline)
+	}
 
 	// Convert to OTIO timeline
 	timeline, err := d.convertTimeline(&ges.Project.Timeline)
@@ -46,14 +92,88 @@ func (d *Decoder) Decode() (*gotio.Timeline, error) {
 		return nil, err
 	}
 
+	// Only time effects have an OTIO equivalent
+	effects := 0
+	for _, layer := range ges.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			for i := range clip.Effects {
+				if !IsTimeEffect(&clip.Effects[i]) {
+					effects++
+				}
+			}
+		}
+	}
+	if effects > 0 {
+		if err := d.warnf("%d clip effects dropped", effects); err != nil {
+			return nil, err
+		}
+	}
+
+	// OTIO has no equivalent of GES groups
+	if n := len(ges.Project.Timeline.AllGroups()); n > 0 {
+		if err := d.warnf("%d clip groups dropped", n); err != nil {
+			return nil, err
+		}
+	}
+
 	// Extract timeline name from project metadata
 	if name := d.extractName(ges.Project.Metadatas); name != "" {
 		timeline.SetName(name)
 	}
 
+	// Keep every project metadata field, such as the author, typed. The raw
+	// structure keeps their order and GstStructure types.
+	if metadatas, err := ParseStructure(ges.Project.Metadatas); err == nil {
+		xgesMetadata := setXgesMetadata(timeline)
+		xgesMetadata["project-metadatas"] = structureMetadata(metadatas)
+		xgesMetadata["raw-project-metadatas"] = ges.Project.Metadatas
+	}
+	setXgesMetadata(timeline)["schema-version"] = MetadataSchemaVersion
+
 	return timeline, nil
 }
 
+// warnf records a lossy conversion. In strict mode it returns an error instead
+func (d *Decoder) warnf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if d.opts.Strict {
+		return fmt.Errorf("lossy conversion: %s", msg)
+	}
+	d.warnings = append(d.warnings, msg)
+	return nil
+}
+
+// indexAssets records which assets are proxies of which originals, and
+// which are still images
+func (d *Decoder) indexAssets(assets []Asset) {
+	d.proxies = make(map[string]string)
+	d.originals = make(map[string]string)
+	d.stillImages = make(map[string]bool)
+	for _, asset := range assets {
+		if asset.ProxyID != "" {
+			d.proxies[asset.ID] = asset.ProxyID
+			d.originals[asset.ProxyID] = asset.ID
+		}
+		if asset.IsStillImage() {
+			d.stillImages[asset.ID] = true
+		}
+	}
+}
+
+// mediaURI returns the media location for a clip asset, choosing between
+// the original and its proxy and applying path remapping
+func (d *Decoder) mediaURI(assetID string) string {
+	uri := assetID
+	if d.opts.UseProxies {
+		if proxy, ok := d.proxies[uri]; ok {
+			uri = proxy
+		}
+	} else if original, ok := d.originals[uri]; ok {
+		uri = original
+	}
+	return remapPath(uri, d.opts.PathMap)
+}
+
 // extractFrameRate extracts the frame rate from the video track
 func (d *Decoder) extractFrameRate(timeline *Timeline) {
 	for _, track := range timeline.Tracks {
@@ -99,6 +219,11 @@ func (d *Decoder) extractFrameRateFromProperties(props string) float64 {
 
 // extractName extracts the name from a GstStructure metadata string
 func (d *Decoder) extractName(metadatas string) string {
+	if structure, err := ParseStructure(metadatas); err == nil {
+		name, _ := structure.GetString("name")
+		return name
+	}
+
 	// Look for name=(string)"value" or name=(string)value
 	re := regexp.MustCompile(`name=\(string\)(?:"([^"]+)"|(\S+))`)
 	matches := re.FindStringSubmatch(metadatas)
@@ -121,74 +246,215 @@ func (d *Decoder) unescapeGstString(s string) string {
 	return s
 }
 
-// convertTimeline converts an XGES Timeline to an OTIO Timeline
+// convertTimeline converts an XGES Timeline to an OTIO Timeline. Each layer
+// becomes one OTIO track per track type it has clips for, video tracks
+// first, then audio, text and custom tracks, in layer priority order.
 func (d *Decoder) convertTimeline(xgesTimeline *Timeline) (*gotio.Timeline, error) {
 	// Create the timeline
 	timeline := gotio.NewTimeline("", nil, nil)
 
-	// Create tracks based on the track types
-	tracksByType := make(map[int]*gotio.Track)
+	// Find the XGES tracks of each supported type
+	tracksByType := make(map[int][]Track)
+	d.trackTypes = make(map[int]int)
 	for _, track := range xgesTimeline.Tracks {
-		otioTrack := d.createTrack(&track)
-		tracksByType[track.TrackType] = otioTrack
+		d.trackTypes[track.TrackID] = track.TrackType
+		if _, ok := trackKind(track.TrackType); !ok {
+			if err := d.warnf("track %d of type %d dropped", track.TrackID, track.TrackType); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		tracksByType[track.TrackType] = append(tracksByType[track.TrackType], track)
 	}
 
-	// Process layers in order
-	for _, layer := range xgesTimeline.Layers {
-		if err := d.processLayer(&layer, tracksByType); err != nil {
-			return nil, err
+	layers := make([]*Layer, len(xgesTimeline.Layers))
+	for i := range xgesTimeline.Layers {
+		layers[i] = &xgesTimeline.Layers[i]
+	}
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Priority < layers[j].Priority })
+
+	for _, layer := range layers {
+		for _, clip := range layer.Clips {
+			placed := false
+			for trackType := range tracksByType {
+				if clip.TrackTypes&trackType != 0 {
+					placed = true
+				}
+			}
+			if !placed {
+				if err := d.warnf("clip %d on layer %d has no matching track", clip.ID, layer.Priority); err != nil {
+					return nil, err
+				}
+			}
+			if clip.Duration == gstClockTimeNone {
+				if err := d.warnf("clip %d on layer %d dropped, its duration is left to GES", clip.ID, layer.Priority); err != nil {
+					return nil, err
+				}
+			}
 		}
 	}
 
-	// Add tracks to timeline
+	// Add a track for each layer and XGES track
 	tracks := timeline.Tracks()
-	for _, trackType := range []int{TrackTypeVideo, TrackTypeAudio} {
-		if track, ok := tracksByType[trackType]; ok {
-			if err := tracks.AppendChild(track); err != nil {
-				return nil, err
+	for _, k := range trackKinds {
+		for i := range tracksByType[k.trackType] {
+			xgesTrack := &tracksByType[k.trackType][i]
+			added := false
+			for _, layer := range layers {
+				var clips []Clip
+				for _, clip := range layer.Clips {
+					if d.inTrack(&clip, xgesTrack) && clip.Duration != gstClockTimeNone {
+						clips = append(clips, clip)
+					}
+				}
+				if len(clips) == 0 {
+					continue
+				}
+
+				track := d.createTrack(layer, xgesTrack)
+				if err := d.addClipsToTrack(track, clips, xgesTrack); err != nil {
+					return nil, err
+				}
+				if err := tracks.AppendChild(track); err != nil {
+					return nil, err
+				}
+				added = true
+			}
+
+			// Keep an empty track so the XGES track survives a round trip
+			if !added {
+				if err := tracks.AppendChild(d.createTrack(nil, xgesTrack)); err != nil {
+					return nil, err
+				}
 			}
 		}
 	}
 
+	// Keep the XGES tracks so the encoder can recreate them
+	xgesMetadata := make(map[string]interface{})
+	var trackList []interface{}
+	for _, track := range xgesTimeline.Tracks {
+		if _, ok := trackKind(track.TrackType); ok {
+			trackList = append(trackList, trackMetadata(&track))
+		}
+	}
+	if len(trackList) > 0 {
+		xgesMetadata["tracks"] = trackList
+	}
+	d.addTimelineStructures(xgesMetadata, xgesTimeline)
+	if len(xgesMetadata) > 0 {
+		timeline.SetMetadata(map[string]interface{}{"xges": xgesMetadata})
+	}
+
 	return timeline, nil
 }
 
-// createTrack creates an OTIO track from an XGES track
-func (d *Decoder) createTrack(xgesTrack *Track) *gotio.Track {
-	kind := gotio.TrackKindVideo
-	if xgesTrack.TrackType == TrackTypeAudio {
-		kind = gotio.TrackKindAudio
+// addTimelineStructures adds the timeline properties, such as
+// auto-transition and snapping-distance, and metadatas, such as duration,
+// framerate and user data, to the timeline's xges metadata as typed values.
+// The raw structures are kept so the fields can be written back in their
+// order and with their types.
+func (d *Decoder) addTimelineStructures(xgesMetadata map[string]interface{}, xgesTimeline *Timeline) {
+	if properties, err := ParseStructure(xgesTimeline.Properties); err == nil {
+		xgesMetadata["properties"] = structureMetadata(properties)
+		xgesMetadata["raw-properties"] = xgesTimeline.Properties
 	}
 
-	return gotio.NewTrack("", nil, kind, nil, nil)
+	metadatas, err := ParseStructure(xgesTimeline.Metadatas)
+	if err != nil {
+		return
+	}
+	xgesMetadata["metadatas"] = structureMetadata(metadatas)
+	xgesMetadata["raw-metadatas"] = xgesTimeline.Metadatas
+
+	// The declared duration goes stale when files are edited by hand. It is
+	// recomputed when encoding, so this isn't lossy even in strict mode.
+	if declared, ok := metadatas.GetUint64("duration"); ok {
+		if computed := xgesTimeline.Duration(); declared != computed {
+			d.warnings = append(d.warnings, fmt.Sprintf("timeline duration %d differs from the end of its last clip at %d", declared, computed))
+		}
+	}
 }
 
-// processLayer processes an XGES layer and adds clips to tracks
-func (d *Decoder) processLayer(layer *Layer, tracksByType map[int]*gotio.Track) error {
-	// Group clips by track type and sort by start time
-	clipsByTrack := make(map[int][]Clip)
-	for _, clip := range layer.Clips {
-		// Determine which tracks this clip belongs to (based on track-types bitmask)
-		for trackType := range tracksByType {
-			if clip.TrackTypes&trackType != 0 {
-				clipsByTrack[trackType] = append(clipsByTrack[trackType], clip)
-			}
+// inTrack reports whether a clip plays in an XGES track: clips play in
+// every track of their types, unless their sources name some of them
+func (d *Decoder) inTrack(clip *Clip, xgesTrack *Track) bool {
+	if clip.TrackTypes&xgesTrack.TrackType == 0 {
+		return false
+	}
+	routed := false
+	for _, source := range clip.Sources {
+		if source.TrackID == xgesTrack.TrackID {
+			return true
+		}
+		if d.trackTypes[source.TrackID] == xgesTrack.TrackType {
+			routed = true
 		}
 	}
+	return !routed
+}
 
-	// Process clips for each track
-	for trackType, clips := range clipsByTrack {
-		track := tracksByType[trackType]
-		if err := d.addClipsToTrack(track, clips); err != nil {
-			return err
+// trackMetadata describes an XGES track in OTIO metadata
+func trackMetadata(track *Track) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"track-id":   track.TrackID,
+		"track-type": track.TrackType,
+		"caps":       track.Caps,
+	}
+	if track.Properties != "" {
+		metadata["properties"] = track.Properties
+	}
+	if track.Metadatas != "" {
+		metadata["metadatas"] = track.Metadatas
+	}
+	return metadata
+}
+
+// createTrack creates the OTIO track holding the clips of a layer in one
+// XGES track. The layer name becomes the track name, its priority, volume
+// and auto-transition and the XGES track id go in the track metadata, and
+// the track is disabled when the layer is deactivated in the XGES track.
+// Without a layer, the track is an empty placeholder for an XGES track no
+// clip uses: it joins the first layer but carries none of its settings.
+func (d *Decoder) createTrack(layer *Layer, xgesTrack *Track) *gotio.Track {
+	kind, _ := trackKind(xgesTrack.TrackType)
+	xgesMetadata := map[string]interface{}{
+		"layer-priority": 0,
+		"track-id":       xgesTrack.TrackID,
+	}
+	if kind == TrackKindText || kind == TrackKindCustom {
+		// OTIO doesn't know these kinds, so keep what the track carries
+		xgesMetadata["track-type"] = xgesTrack.TrackType
+		xgesMetadata["caps"] = xgesTrack.Caps
+	}
+	if layer == nil {
+		return gotio.NewTrack("", nil, kind, nil, map[string]interface{}{"xges": xgesMetadata})
+	}
+	xgesMetadata["layer-priority"] = layer.Priority
+	name := ""
+	if metadatas, err := ParseStructure(layer.Metadatas); err == nil {
+		var ok bool
+		if name, ok = metadatas.GetString(LayerNameField); !ok {
+			name, _ = metadatas.GetString("name")
 		}
+		if volume, ok := metadatas.GetFloat("volume"); ok {
+			xgesMetadata["volume"] = volume
+		}
+		xgesMetadata["layer-metadatas"] = layer.Metadatas
 	}
+	// A layer without the property has no auto-transition in GES, while
+	// the encoder turns it on by default
+	xgesMetadata["auto-transition"] = layer.AutoTransition()
 
-	return nil
+	track := gotio.NewTrack(name, nil, kind, nil, map[string]interface{}{"xges": xgesMetadata})
+	track.SetEnabled(!layer.DeactivatedTrackIDs()[xgesTrack.TrackID])
+
+	return track
 }
 
-// addClipsToTrack adds clips to an OTIO track, filling gaps as needed
-func (d *Decoder) addClipsToTrack(track *gotio.Track, clips []Clip) error {
+// addClipsToTrack adds the clips of one XGES track to an OTIO track, filling
+// gaps as needed
+func (d *Decoder) addClipsToTrack(track *gotio.Track, clips []Clip, xgesTrack *Track) error {
 	if len(clips) == 0 {
 		return nil
 	}
@@ -209,7 +475,7 @@ func (d *Decoder) addClipsToTrack(track *gotio.Track, clips []Clip) error {
 		}
 
 		// Convert and add the clip
-		otioItem, err := d.convertClip(&xgesClip)
+		otioItem, err := d.convertClip(&xgesClip, xgesTrack)
 		if err != nil {
 			return err
 		}
@@ -226,33 +492,106 @@ func (d *Decoder) addClipsToTrack(track *gotio.Track, clips []Clip) error {
 	return nil
 }
 
-// convertClip converts an XGES clip to an OTIO composable
-func (d *Decoder) convertClip(xgesClip *Clip) (gotio.Composable, error) {
+// convertClip converts an XGES clip to an OTIO composable in an XGES track
+func (d *Decoder) convertClip(xgesClip *Clip, xgesTrack *Track) (gotio.Composable, error) {
+	trackType := xgesTrack.TrackType
+
 	// Handle transition clips
 	if xgesClip.TypeName == ClipTypeTransition {
 		return d.convertTransition(xgesClip), nil
 	}
 
-	// Handle URI clips
-	if xgesClip.TypeName == ClipTypeURI {
-		return d.convertURIClip(xgesClip), nil
+	var clip *gotio.Clip
+	switch xgesClip.TypeName {
+	case ClipTypeURI:
+		clip = d.convertURIClip(xgesClip)
+	case ClipTypeTest:
+		// Generators/test patterns
+		clip = d.convertTestClip(xgesClip)
+	case ClipTypeTitle:
+		// Text overlays
+		clip = d.convertTitleClip(xgesClip)
 	}
+	if clip != nil {
+		// The settings of the clip in this track
+		for i := range xgesClip.Sources {
+			if source := &xgesClip.Sources[i]; source.TrackID == xgesTrack.TrackID {
+				setXgesMetadata(clip)["source"] = sourceMetadata(source, trackType)
+				break
+			}
+		}
 
-	// Handle test clips (generators/test patterns)
-	if xgesClip.TypeName == ClipTypeTest {
-		return d.convertTestClip(xgesClip), nil
-	}
+		// Time effects become time warps
+		var trackEffects []Effect
+		for _, effect := range xgesClip.Effects {
+			// Effects on a track of another id apply to that track only
+			if effect.TrackType == trackType && (effect.TrackID == xgesTrack.TrackID || d.trackTypes[effect.TrackID] != trackType) {
+				trackEffects = append(trackEffects, effect)
+			}
+		}
+		if warps := timeWarps(trackEffects); len(warps) > 0 {
+			clip.SetEffects(append(clip.Effects(), warps...))
+		}
+
+		// Muted clips are disabled items
+		if props, err := ParseStructure(xgesClip.Properties); err == nil {
+			if mute, _ := props.GetBool("mute"); mute {
+				clip.SetEnabled(false)
+			}
+		}
 
-	// Handle title clips (text overlays)
-	if xgesClip.TypeName == ClipTypeTitle {
-		return d.convertTitleClip(xgesClip), nil
+		d.addClipInfo(clip, xgesClip)
+		d.addClipMetadatas(clip, xgesClip)
+		return clip, nil
 	}
 
 	// Unsupported clip type - return a gap
+	if err := d.warnf("clip %d of unsupported type %s replaced by a gap", xgesClip.ID, xgesClip.TypeName); err != nil {
+		return nil, err
+	}
 	duration := d.toRationalTime(xgesClip.Duration)
 	return gotio.NewGapWithDuration(duration), nil
 }
 
+// addClipInfo records the XGES clip an OTIO clip comes from in its xges
+// metadata
+func (d *Decoder) addClipInfo(clip *gotio.Clip, xgesClip *Clip) {
+	xgesMetadata := setXgesMetadata(clip)
+	xgesMetadata["id"] = xgesClip.ID
+	xgesMetadata["type-name"] = xgesClip.TypeName
+	xgesMetadata["layer-priority"] = xgesClip.LayerPriority
+	xgesMetadata["track-types"] = xgesClip.TrackTypes
+}
+
+// addClipMetadatas restores the OTIO metadata the encoder stored in the
+// clip metadatas, and adds the other fields, typed, to the "metadatas" map
+// of the clip's xges metadata
+func (d *Decoder) addClipMetadatas(clip *gotio.Clip, xgesClip *Clip) {
+	metadatas, err := ParseStructure(xgesClip.Metadatas)
+	if err != nil || len(metadatas.Fields) == 0 {
+		return
+	}
+
+	otio, fields := splitClipMetadatas(metadatas)
+	if len(otio) > 0 {
+		metadata := clip.Metadata()
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+			clip.SetMetadata(metadata)
+		}
+		for key, value := range otio {
+			if key != "xges" {
+				metadata[key] = value
+			}
+		}
+	}
+	if len(fields) > 0 {
+		xgesMetadata := setXgesMetadata(clip)
+		xgesMetadata["metadatas"] = fields
+		xgesMetadata["raw-metadatas"] = xgesClip.Metadatas
+	}
+}
+
 // convertTransition converts a transition clip to an OTIO Transition
 func (d *Decoder) convertTransition(xgesClip *Clip) gotio.Composable {
 	duration := d.toRationalTime(xgesClip.Duration)
@@ -310,6 +649,15 @@ func (d *Decoder) mapTransitionType(assetID string) string {
 func (d *Decoder) convertURIClip(xgesClip *Clip) *gotio.Clip {
 	name := d.extractName(xgesClip.Properties)
 
+	if IsImageSequenceURI(xgesClip.AssetID) {
+		if clip := d.convertImageSequenceClip(xgesClip, name); clip != nil {
+			return clip
+		}
+	}
+	if d.isStillImage(xgesClip) {
+		return d.convertImageClip(xgesClip, name)
+	}
+
 	// Create source range
 	start := d.toRationalTime(xgesClip.Inpoint)
 	duration := d.toRationalTime(xgesClip.Duration)
@@ -317,10 +665,10 @@ func (d *Decoder) convertURIClip(xgesClip *Clip) *gotio.Clip {
 
 	// Create media reference
 	mediaRef := gotio.NewExternalReference(
-		"",              // name
-		xgesClip.AssetID, // target URL
-		nil,             // available range - could be extracted from asset
-		nil,             // metadata
+		"",                           // name
+		d.mediaURI(xgesClip.AssetID), // target URL
+		nil,                          // available range - could be extracted from asset
+		nil,                          // metadata
 	)
 
 	// Create clip
@@ -341,6 +689,82 @@ func (d *Decoder) convertURIClip(xgesClip *Clip) *gotio.Clip {
 	return clip
 }
 
+// isStillImage reports whether a URI clip shows a still image, either from
+// its is-image property or from its asset
+func (d *Decoder) isStillImage(xgesClip *Clip) bool {
+	if props, err := ParseStructure(xgesClip.Properties); err == nil {
+		if image, ok := props.GetBool("is-image"); ok && image {
+			return true
+		}
+	}
+	return d.stillImages[xgesClip.AssetID]
+}
+
+// convertImageClip converts a still image URI clip to an OTIO Clip. The
+// reference is marked as a still image and its available range is the hold
+// duration, since an image has no media time to start from.
+func (d *Decoder) convertImageClip(xgesClip *Clip, name string) *gotio.Clip {
+	hold := opentime.NewTimeRange(d.toRationalTime(0), d.toRationalTime(xgesClip.Duration))
+
+	mediaRef := gotio.NewExternalReference(
+		"",
+		d.mediaURI(xgesClip.AssetID),
+		&hold,
+		map[string]interface{}{
+			"xges": map[string]interface{}{"still-image": true},
+		},
+	)
+
+	clip := gotio.NewClip(name, mediaRef, &hold, nil, nil, nil, "", nil)
+	d.addChildrenPropertiesToMetadata(clip, xgesClip)
+
+	return clip
+}
+
+// convertImageSequenceClip converts a URI clip playing an imagesequence://
+// URI to an OTIO Clip with an ImageSequenceReference. The GES inpoint counts
+// from the first frame of the sequence. It returns nil if the URI can't be
+// parsed, so the clip keeps an ExternalReference.
+func (d *Decoder) convertImageSequenceClip(xgesClip *Clip, name string) *gotio.Clip {
+	seq, err := ParseImageSequenceURI(xgesClip.AssetID)
+	if err != nil {
+		return nil
+	}
+
+	rate := seq.Rate
+	if rate <= 0 {
+		rate = d.rate
+	}
+	var availableRange *opentime.TimeRange
+	first := opentime.NewRationalTime(float64(seq.StartFrame), rate)
+	if seq.EndFrame >= 0 {
+		r := opentime.NewTimeRange(first, opentime.NewRationalTime(float64(seq.EndFrame-seq.StartFrame+1), rate))
+		availableRange = &r
+	}
+
+	mediaRef := gotio.NewImageSequenceReference(
+		"",
+		remapPath(seq.URLBase, d.opts.PathMap),
+		seq.Prefix,
+		seq.Suffix,
+		seq.StartFrame,
+		1,
+		rate,
+		seq.Padding,
+		gotio.MissingFramePolicyError,
+		availableRange,
+		nil,
+	)
+
+	start := first.Add(d.toRationalTime(xgesClip.Inpoint).RescaledTo(rate))
+	sourceRange := opentime.NewTimeRange(start, d.toRationalTime(xgesClip.Duration))
+
+	clip := gotio.NewClip(name, mediaRef, &sourceRange, nil, nil, nil, "", nil)
+	d.addChildrenPropertiesToMetadata(clip, xgesClip)
+
+	return clip
+}
+
 // convertTestClip converts a GESTestClip to an OTIO Clip with GeneratorReference
 func (d *Decoder) convertTestClip(xgesClip *Clip) *gotio.Clip {
 	name := d.extractName(xgesClip.Properties)
@@ -414,51 +838,27 @@ func (d *Decoder) convertTitleClip(xgesClip *Clip) *gotio.Clip {
 	)
 
 	// Store title text and properties in metadata
-	metadata := make(map[string]interface{})
-	xgesMetadata := make(map[string]interface{})
-
-	// Extract text from children-properties
-	if xgesClip.ChildrenProperties != "" {
-		xgesMetadata["children-properties"] = xgesClip.ChildrenProperties
-		// Try to extract text property
-		textMatch := regexp.MustCompile(`text=\(string\)(?:"([^"]+)"|(\S+))`).FindStringSubmatch(xgesClip.ChildrenProperties)
-		if len(textMatch) > 1 {
-			text := textMatch[1]
-			if text == "" && len(textMatch) > 2 {
-				text = textMatch[2]
-			}
-			xgesMetadata["text"] = d.unescapeGstString(text)
+	d.addChildrenPropertiesToMetadata(clip, xgesClip)
+	xgesMetadata := setXgesMetadata(clip)
+	if children, err := ParseStructure(xgesClip.ChildrenProperties); err == nil {
+		name := "text"
+		if existing, ok := children.ChildPropertyName(name); ok {
+			name = existing
+		}
+		if text, ok := children.GetString(name); ok {
+			xgesMetadata["text"] = text
 		}
 	}
-
-	// Store clip type
 	xgesMetadata["clip-type"] = "title"
 
-	metadata["xges"] = xgesMetadata
-	clip.SetMetadata(metadata)
-
 	return clip
 }
 
 // addChildrenPropertiesToMetadata adds children-properties to clip metadata
 func (d *Decoder) addChildrenPropertiesToMetadata(clip *gotio.Clip, xgesClip *Clip) {
-	if xgesClip.ChildrenProperties == "" {
-		return
-	}
-
-	metadata := clip.Metadata()
-	if metadata == nil {
-		metadata = make(map[string]interface{})
-		clip.SetMetadata(metadata)
-	}
-
-	xgesMetadata, ok := metadata["xges"].(map[string]interface{})
-	if !ok {
-		xgesMetadata = make(map[string]interface{})
-		metadata["xges"] = xgesMetadata
+	if xgesClip.ChildrenProperties != "" {
+		setXgesMetadata(clip)["children-properties"] = xgesClip.ChildrenProperties
 	}
-
-	xgesMetadata["children-properties"] = xgesClip.ChildrenProperties
 }
 
 // toRationalTime converts nanoseconds to RationalTime
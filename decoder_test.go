@@ -245,7 +245,7 @@ func TestEncoder_Encode(t *testing.T) {
 		t.Error("Output missing <timeline> element")
 	}
 
-	if !strings.Contains(output, "track-type=\"4\"") {
+	if !strings.Contains(output, "track-type='4'") {
 		t.Error("Output missing video track")
 	}
 
@@ -650,15 +650,15 @@ func TestEncoder_GeneratorReference(t *testing.T) {
 	output := buf.String()
 
 	// Verify test clip
-	if !strings.Contains(output, `type-name="GESTestClip"`) {
+	if !strings.Contains(output, `type-name='GESTestClip'`) {
 		t.Error("Output missing GESTestClip type")
 	}
-	if !strings.Contains(output, `asset-id="bars"`) {
+	if !strings.Contains(output, `asset-id='bars'`) {
 		t.Error("Output missing bars asset-id")
 	}
 
 	// Verify title clip
-	if !strings.Contains(output, `type-name="GESTitleClip"`) {
+	if !strings.Contains(output, `type-name='GESTitleClip'`) {
 		t.Error("Output missing GESTitleClip type")
 	}
 	if !strings.Contains(output, `Test\ Title`) {
@@ -719,3 +719,640 @@ func TestRoundTrip_NewFeatures(t *testing.T) {
 		}
 	}
 }
+
+const layersXGES = `<?xml version="1.0" ?>
+<ges version='0.3'>
+  <project properties='properties;'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <track caps='audio/x-raw(ANY)' track-type='2' track-id='1'/>
+      <layer priority='0' properties='properties, auto-transition=(boolean)false;' metadatas='metadatas, volume=(float)0.5, video::name=(string)Interview;'>
+        <clip id='0' asset-id='file:///a.mov' type-name='GESUriClip' layer-priority='0' track-types='6' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties;' />
+      </layer>
+      <layer priority='1' properties='properties, auto-transition=(boolean)true;' metadatas='metadatas, name=(string)Overlay;' deactivated-tracks='0'>
+        <clip id='1' asset-id='file:///b.mov' type-name='GESUriClip' layer-priority='1' track-types='4' start='0' duration='1000000000' inpoint='0' rate='0' properties='properties;' />
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_Layers(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(layersXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	videoTracks := timeline.VideoTracks()
+	audioTracks := timeline.AudioTracks()
+	if len(videoTracks) != 2 || len(audioTracks) != 1 {
+		t.Fatalf("Expected 2 video tracks and 1 audio track, got %d and %d", len(videoTracks), len(audioTracks))
+	}
+
+	interview := videoTracks[0]
+	if interview.Name() != "Interview" || !interview.Enabled() {
+		t.Errorf("Expected enabled track 'Interview', got %q enabled=%v", interview.Name(), interview.Enabled())
+	}
+	metadata := xgesMetadata(interview.Metadata())
+	if metadata["volume"] != 0.5 || metadata["auto-transition"] != false || metadata["layer-priority"] != 0 {
+		t.Errorf("Unexpected track metadata %v", metadata)
+	}
+	if audioTracks[0].Name() != "Interview" {
+		t.Errorf("Expected the audio track to share the layer name, got %q", audioTracks[0].Name())
+	}
+
+	overlay := videoTracks[1]
+	if overlay.Name() != "Overlay" || overlay.Enabled() {
+		t.Errorf("Expected disabled track 'Overlay', got %q enabled=%v", overlay.Name(), overlay.Enabled())
+	}
+}
+
+func TestEncoder_Layers(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(layersXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	// A new track without layer metadata goes below the decoded layers
+	extra := gotio.NewTrack("Music", nil, gotio.TrackKindAudio, nil, nil)
+	extra.SetEnabled(false)
+	timeline.Tracks().AppendChild(extra)
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	layers := ges.Project.Timeline.Layers
+	if len(layers) != 3 {
+		t.Fatalf("Expected 3 layers, got %d", len(layers))
+	}
+
+	if n := len(layers[0].Clips); n != 1 || layers[0].Clips[0].TrackTypes != TrackTypeVideo|TrackTypeAudio {
+		t.Errorf("Expected one audio and video clip on layer 0, got %+v", layers[0].Clips)
+	}
+	if layers[0].Properties != "properties, auto-transition=(boolean)false;" {
+		t.Errorf("Unexpected layer properties %s", layers[0].Properties)
+	}
+	if layers[0].Metadatas != `metadatas, volume=(float)0.5, video::name=(string)Interview;` {
+		t.Errorf("Unexpected layer metadatas %s", layers[0].Metadatas)
+	}
+	if layers[0].DeactivatedTracks != "" {
+		t.Errorf("Expected layer 0 to be active, got %q", layers[0].DeactivatedTracks)
+	}
+
+	if layers[1].DeactivatedTracks != "0" {
+		t.Errorf("Expected layer 1 deactivated in the video track, got %q", layers[1].DeactivatedTracks)
+	}
+	if layers[2].DeactivatedTracks != "1" || layers[2].Metadatas != `metadatas, volume=(float)1, video::name=(string)Music;` {
+		t.Errorf("Unexpected new layer %+v", layers[2])
+	}
+}
+
+const imageXGES = `<?xml version="1.0" ?>
+<ges version='0.3'>
+  <project properties='properties;'>
+    <ressources>
+      <asset id='file:///media/logo.png' extractable-type-name='GESUriClip' properties='properties, supported-formats=(int)4, duration=(guint64)18446744073709551615;'/>
+    </ressources>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///media/logo.png' type-name='GESUriClip' layer-priority='0' track-types='4' start='0' duration='3000000000' inpoint='0' rate='0' properties='properties, name=(string)logo;' />
+        <clip id='1' asset-id='file:///media/still' type-name='GESUriClip' layer-priority='0' track-types='4' start='3000000000' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)still, is-image=(boolean)true;' />
+        <clip id='2' asset-id='file:///media/a.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='4000000000' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)muted, mute=(boolean)true;' />
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_ImageAndMutedClips(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(imageXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	children := timeline.VideoTracks()[0].Children()
+	if len(children) != 3 {
+		t.Fatalf("Expected 3 clips, got %d", len(children))
+	}
+
+	for _, child := range children[:2] {
+		clip := child.(*gotio.Clip)
+		ref, ok := clip.MediaReference().(*gotio.ExternalReference)
+		if !ok {
+			t.Fatalf("Expected an external reference for %s, got %T", clip.Name(), clip.MediaReference())
+		}
+		if still, _ := xgesMetadata(ref.Metadata())["still-image"].(bool); !still {
+			t.Errorf("Expected %s to be a still image", clip.Name())
+		}
+		duration, _ := clip.Duration()
+		if ref.AvailableRange() == nil || ref.AvailableRange().Duration() != duration {
+			t.Errorf("Expected %s to hold for %v, got %v", clip.Name(), duration, ref.AvailableRange())
+		}
+		if !clip.Enabled() {
+			t.Errorf("Expected %s to be enabled", clip.Name())
+		}
+	}
+
+	if muted := children[2].(*gotio.Clip); muted.Enabled() {
+		t.Error("Expected the muted clip to be disabled")
+	}
+}
+
+func TestEncoder_ImageAndMutedClips(t *testing.T) {
+	timeline := gotio.NewTimeline("", nil, nil)
+	track := gotio.NewTrack("", nil, gotio.TrackKindVideo, nil, nil)
+	sourceRange := opentime.NewTimeRange(opentime.NewRationalTime(10, 25), opentime.NewRationalTime(50, 25))
+	image := gotio.NewClip("image", gotio.NewExternalReference("", "file:///media/Title.PNG", nil, nil), &sourceRange, nil, nil, nil, "", nil)
+	muted := gotio.NewClip("muted", gotio.NewExternalReference("", "file:///media/a.mov", nil, nil), &sourceRange, nil, nil, nil, "", nil)
+	muted.SetEnabled(false)
+	track.AppendChild(image)
+	track.AppendChild(muted)
+	timeline.Tracks().AppendChild(track)
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	clips := ges.Project.Timeline.Layers[0].Clips
+	if clips[0].Properties != `properties, name=(string)"image", mute=(boolean)false, is-image=(boolean)true;` || clips[0].Inpoint != 0 {
+		t.Errorf("Unexpected image clip %+v", clips[0])
+	}
+	if clips[1].Properties != `properties, name=(string)"muted", mute=(boolean)true, is-image=(boolean)false;` {
+		t.Errorf("Unexpected muted clip %+v", clips[1])
+	}
+}
+
+const multiTrackXGES = `<?xml version="1.0" ?>
+<ges version='0.4'>
+  <project properties='properties;'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0' properties='properties, restriction-caps=(string)"video/x-raw\,\ width\=\(int\)1920\,\ height\=\(int\)1080\,\ framerate\=\(fraction\)25/1";'/>
+      <track caps='audio/x-raw(ANY)' track-type='2' track-id='1'/>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='2' properties='properties, restriction-caps=(string)"video/x-raw\,\ width\=\(int\)1280\,\ height\=\(int\)720\,\ framerate\=\(fraction\)25/1";'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///logo.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='0' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)logo;'>
+          <source track-id='2'/>
+        </clip>
+        <clip id='1' asset-id='file:///a.mov' type-name='GESUriClip' layer-priority='0' track-types='6' start='1000000000' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)a;'/>
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_MultipleTracks(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(multiTrackXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	videoTracks := timeline.VideoTracks()
+	if len(videoTracks) != 2 || len(timeline.AudioTracks()) != 1 {
+		t.Fatalf("Expected 2 video tracks and 1 audio track, got %d and %d", len(videoTracks), len(timeline.AudioTracks()))
+	}
+
+	// The logo is routed to the second video track only
+	for i, expected := range []struct {
+		trackID int
+		clips   int
+	}{{0, 1}, {2, 2}} {
+		track := videoTracks[i]
+		if id := xgesMetadata(track.Metadata())["track-id"]; id != expected.trackID {
+			t.Errorf("Expected video track %d to be XGES track %d, got %v", i, expected.trackID, id)
+		}
+		clips := 0
+		for _, child := range track.Children() {
+			if _, ok := child.(*gotio.Clip); ok {
+				clips++
+			}
+		}
+		if clips != expected.clips {
+			t.Errorf("Expected %d clips in XGES track %d, got %d", expected.clips, expected.trackID, clips)
+		}
+	}
+
+	tracks, _ := xgesMetadata(timeline.Metadata())["tracks"].([]interface{})
+	if len(tracks) != 3 {
+		t.Errorf("Expected the 3 XGES tracks in the timeline metadata, got %v", tracks)
+	}
+}
+
+func TestEncoder_MultipleTracks(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(multiTrackXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	// Request an extra audio track through the timeline metadata and
+	// another video track through a track
+	tracks := xgesMetadata(timeline.Metadata())["tracks"].([]interface{})
+	xgesMetadata(timeline.Metadata())["tracks"] = append(tracks, map[string]interface{}{"track-id": 5, "track-type": TrackTypeAudio})
+	extra := gotio.NewTrack("", nil, gotio.TrackKindVideo, nil, map[string]interface{}{
+		"xges": map[string]interface{}{"track-id": 7},
+	})
+	timeline.Tracks().AppendChild(extra)
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	xgesTracks := ges.Project.Timeline.Tracks
+	var ids []int
+	for _, track := range xgesTracks {
+		ids = append(ids, track.TrackID)
+	}
+	if len(ids) != 5 || ids[0] != 0 || ids[1] != 1 || ids[2] != 2 || ids[3] != 5 || ids[4] != 7 {
+		t.Fatalf("Unexpected track ids %v", ids)
+	}
+	if !strings.Contains(xgesTracks[2].Properties, `width\=\(int\)1280`) {
+		t.Errorf("Expected the second video track to keep its restriction caps, got %s", xgesTracks[2].Properties)
+	}
+
+	clips := ges.Project.Timeline.Layers[0].Clips
+	if len(clips) != 2 {
+		t.Fatalf("Expected 2 clips, got %+v", clips)
+	}
+	if logo := clips[0]; logo.TrackTypes != TrackTypeVideo || len(logo.Sources) != 1 || logo.Sources[0].TrackID != 2 {
+		t.Errorf("Expected the logo routed to track 2, got %+v", logo)
+	}
+	if a := clips[1]; a.ID != 1 || a.TrackTypes != TrackTypeVideo|TrackTypeAudio || len(a.Sources) != 3 {
+		t.Errorf("Expected one clip routed to both video tracks and the first audio track, got %+v", a)
+	}
+
+	// The routing survives another round trip
+	decoded, err := NewDecoder(nil).DecodeDocument(ges)
+	if err != nil {
+		t.Fatalf("DecodeDocument failed: %v", err)
+	}
+	if n := len(decoded.VideoTracks()[0].Children()); n != 2 {
+		t.Errorf("Expected a gap and a clip in the first video track, got %d items", n)
+	}
+}
+
+const textTrackXGES = `<?xml version="1.0" ?>
+<ges version='0.4'>
+  <project properties='properties;'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <track caps='text/x-raw(ANY)' track-type='8' track-id='1'/>
+      <track caps='application/x-subtitle' track-type='16' track-id='2'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///a.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties, name=(string)a;'/>
+        <clip id='1' asset-id='file:///a.srt' type-name='GESUriClip' layer-priority='0' track-types='8' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties, name=(string)subtitles;'/>
+        <clip id='2' asset-id='file:///a.data' type-name='GESUriClip' layer-priority='0' track-types='16' start='0' duration='1000000000' inpoint='0' rate='0' properties='properties, name=(string)data;'/>
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_TextAndCustomTracks(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(textTrackXGES))
+	timeline, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(decoder.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", decoder.Warnings())
+	}
+
+	children := timeline.Tracks().Children()
+	if len(children) != 3 {
+		t.Fatalf("Expected 3 tracks, got %d", len(children))
+	}
+	for i, expected := range []struct {
+		kind string
+		caps interface{}
+	}{
+		{gotio.TrackKindVideo, nil},
+		{TrackKindText, "text/x-raw(ANY)"},
+		{TrackKindCustom, "application/x-subtitle"},
+	} {
+		track := children[i].(*gotio.Track)
+		if track.Kind() != expected.kind {
+			t.Errorf("Expected track %d to be %s, got %s", i, expected.kind, track.Kind())
+		}
+		if caps := xgesMetadata(track.Metadata())["caps"]; caps != expected.caps {
+			t.Errorf("Expected track %d caps %v, got %v", i, expected.caps, caps)
+		}
+		if len(track.Children()) != 1 {
+			t.Errorf("Expected 1 clip in track %d, got %d", i, len(track.Children()))
+		}
+	}
+}
+
+func TestEncoder_TextAndCustomTracks(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(textTrackXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	// A text track with no XGES track metadata gets the default text caps
+	timeline.SetMetadata(nil)
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	tracks := ges.Project.Timeline.Tracks
+	if len(tracks) != 3 {
+		t.Fatalf("Expected 3 XGES tracks, got %+v", tracks)
+	}
+	for i, expected := range []struct {
+		trackType int
+		caps      string
+	}{
+		{TrackTypeVideo, "video/x-raw(ANY)"},
+		{TrackTypeText, "text/x-raw(ANY)"},
+		{TrackTypeCustom, "application/x-subtitle"},
+	} {
+		if tracks[i].TrackType != expected.trackType || tracks[i].Caps != expected.caps {
+			t.Errorf("Expected track %d of type %d with caps %s, got %+v", i, expected.trackType, expected.caps, tracks[i])
+		}
+	}
+
+	clips := ges.Project.Timeline.Layers[0].Clips
+	if len(clips) != 3 {
+		t.Fatalf("Expected 3 clips on one layer, got %+v", ges.Project.Timeline.Layers)
+	}
+	if clips[1].TrackTypes != TrackTypeText || clips[2].TrackTypes != TrackTypeCustom {
+		t.Errorf("Expected a text and a custom clip, got %+v", clips)
+	}
+}
+
+const timelineSettingsXGES = `<?xml version="1.0" ?>
+<ges version='0.4'>
+  <project properties='properties;'>
+    <timeline properties='properties, auto-transition=(boolean)false, snapping-distance=(guint64)40000000;' metadatas='metadatas, duration=(guint64)2000000000, framerate=(fraction)25/1, reviewer=(string)&quot;Ann\ Lee&quot;, take=(int)3;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///a.mov' type-name='GESUriClip' layer-priority='0' track-types='4' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties, name=(string)a;'/>
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_TimelineSettings(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(timelineSettingsXGES))
+	timeline, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(decoder.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", decoder.Warnings())
+	}
+
+	metadata := xgesMetadata(timeline.Metadata())
+	properties := metadataMap(metadata["properties"])
+	if properties["auto-transition"] != false || properties["snapping-distance"] != int64(40000000) {
+		t.Errorf("Unexpected timeline properties %v", properties)
+	}
+	metadatas := metadataMap(metadata["metadatas"])
+	if metadatas["duration"] != int64(2000000000) || metadatas["framerate"] != "25/1" ||
+		metadatas["reviewer"] != "Ann Lee" || metadatas["take"] != int64(3) {
+		t.Errorf("Unexpected timeline metadatas %v", metadatas)
+	}
+
+	// A stale duration is reported
+	stale := strings.Replace(timelineSettingsXGES, "duration=(guint64)2000000000", "duration=(guint64)5000000000", 1)
+	decoder = NewDecoder(strings.NewReader(stale))
+	decoder.SetOptions(DecodeOptions{Strict: true})
+	if _, err := decoder.Decode(); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(decoder.Warnings()) != 1 || !strings.Contains(decoder.Warnings()[0], "timeline duration 5000000000") {
+		t.Errorf("Expected a warning about the duration, got %v", decoder.Warnings())
+	}
+}
+
+func TestEncoder_TimelineSettings(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(timelineSettingsXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	expected := `properties, auto-transition=(boolean)false, snapping-distance=(guint64)40000000;`
+	if ges.Project.Timeline.Properties != expected {
+		t.Errorf("Expected properties %s, got %s", expected, ges.Project.Timeline.Properties)
+	}
+	expected = `metadatas, duration=(guint64)2000000000, framerate=(fraction)25/1, reviewer=(string)"Ann\ Lee", take=(int)3;`
+	if ges.Project.Timeline.Metadatas != expected {
+		t.Errorf("Expected metadatas %s, got %s", expected, ges.Project.Timeline.Metadatas)
+	}
+
+	// Edited settings are written back, new keys after the existing ones
+	metadata := xgesMetadata(timeline.Metadata())
+	metadataMap(metadata["properties"])["snapping-distance"] = 80000000
+	metadatas := metadataMap(metadata["metadatas"])
+	delete(metadatas, "take")
+	metadatas["approved"] = true
+	if ges, err = NewEncoder(nil).EncodeDocument(timeline); err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if !strings.Contains(ges.Project.Timeline.Properties, "snapping-distance=(guint64)80000000") {
+		t.Errorf("Expected the new snapping distance, got %s", ges.Project.Timeline.Properties)
+	}
+	expected = `metadatas, duration=(guint64)2000000000, framerate=(fraction)25/1, reviewer=(string)"Ann\ Lee", approved=(boolean)true;`
+	if ges.Project.Timeline.Metadatas != expected {
+		t.Errorf("Expected metadatas %s, got %s", expected, ges.Project.Timeline.Metadatas)
+	}
+}
+
+func TestProjectMetadatas(t *testing.T) {
+	projectMetadatas := `metadatas, author=(string)"Thibault\ saunier", render-scale=(double)100, format-version=(string)0.3, name=(string)Edit, pitivi::scaled_proxies=(boolean)false, shot=(int)42;`
+	data := strings.Replace(timelineSettingsXGES, "<project properties='properties;'>",
+		"<project properties='properties;' metadatas='"+strings.ReplaceAll(projectMetadatas, `"`, "&quot;")+"'>", 1)
+
+	timeline, err := NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if timeline.Name() != "Edit" {
+		t.Errorf("Expected timeline name 'Edit', got %q", timeline.Name())
+	}
+	metadatas := metadataMap(xgesMetadata(timeline.Metadata())["project-metadatas"])
+	if metadatas["author"] != "Thibault saunier" || metadatas["render-scale"] != 100.0 ||
+		metadatas["format-version"] != "0.3" || metadatas["pitivi::scaled_proxies"] != false || metadatas["shot"] != int64(42) {
+		t.Errorf("Unexpected project metadatas %v", metadatas)
+	}
+
+	// Every field is written back unchanged, with the new timeline name
+	timeline.SetName("Final Edit")
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	expected := strings.Replace(projectMetadatas, "name=(string)Edit", `name=(string)"Final\ Edit"`, 1)
+	if ges.Project.Metadatas != expected {
+		t.Errorf("Expected project metadatas %s, got %s", expected, ges.Project.Metadatas)
+	}
+
+	// format-version follows the version of the document
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{Version: "0.5"})
+	if ges, err = encoder.EncodeDocument(timeline); err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if !strings.Contains(ges.Project.Metadatas, "format-version=(string)0.5") {
+		t.Errorf("Expected format-version 0.5, got %s", ges.Project.Metadatas)
+	}
+}
+
+func TestClipMetadatas(t *testing.T) {
+	clipMetadatas := `metadatas, pitivi::shot=(int)7, otio::status=(string)approved, otio::json::review=(string)"{\"notes\":[\"fix\ grade\"]\,\"round\":2}";`
+	data := strings.Replace(timelineSettingsXGES, "properties='properties, name=(string)a;'",
+		"properties='properties, name=(string)a;' metadatas='"+strings.ReplaceAll(clipMetadatas, `"`, "&quot;")+"'", 1)
+
+	timeline, err := NewDecoder(strings.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	clip, ok := timeline.Tracks().Children()[0].(*gotio.Track).Children()[0].(*gotio.Clip)
+	if !ok {
+		t.Fatalf("Expected a clip")
+	}
+	metadata := clip.Metadata()
+	if metadata["status"] != "approved" {
+		t.Errorf("Expected status 'approved', got %v", metadata["status"])
+	}
+	review, _ := metadata["review"].(map[string]interface{})
+	notes, _ := review["notes"].([]interface{})
+	if review["round"] != int64(2) || len(notes) != 1 || notes[0] != "fix grade" {
+		t.Errorf("Unexpected review %v", metadata["review"])
+	}
+	if fields := metadataMap(xgesMetadata(metadata)["metadatas"]); len(fields) != 1 || fields["pitivi::shot"] != int64(7) {
+		t.Errorf("Unexpected xges metadatas %v", fields)
+	}
+
+	// OTIO metadata is written back in the reserved namespace, typed
+	metadata["status"] = "final"
+	metadata["score"] = 4.5
+	metadata["locked"] = true
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	expected := `metadatas, pitivi::shot=(int)7, otio::locked=(boolean)true, otio::json::review=(string)"\{\"notes\":\[\"fix\ grade\"\]\,\"round\":2\}", otio::score=(double)4.5, otio::status=(string)final;`
+	if got := ges.Project.Timeline.Layers[0].Clips[0].Metadatas; got != expected {
+		t.Errorf("Expected clip metadatas %s, got %s", expected, got)
+	}
+}
+
+func TestMarkersRoundTrip(t *testing.T) {
+	// A project written by hand in the format GES 1.18 saves, with marker
+	// lists and a date
+	original := loadTestProject(t, "markers.xges")
+	timeline, err := ToOTIO(loadTestProject(t, "markers.xges"), DecodeOptions{})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{Version: original.Version})
+	ges, err := encoder.EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	// Fields nothing changed are written back exactly as read
+	pairs := [][2]string{
+		{original.Project.Metadatas, ges.Project.Metadatas},
+		{original.Project.Timeline.Metadatas, ges.Project.Timeline.Metadatas},
+		{original.Project.Timeline.Layers[0].Metadatas, ges.Project.Timeline.Layers[0].Metadatas},
+		{original.Project.Timeline.Layers[0].Clips[0].Metadatas, ges.Project.Timeline.Layers[0].Clips[0].Metadatas},
+	}
+	for _, p := range pairs {
+		if p[0] != p[1] {
+			t.Errorf("Expected metadatas\n%s\ngot\n%s", p[0], p[1])
+		}
+	}
+
+	// The markers need format version 0.5
+	if version := RequiredFormatVersion(ges); version != versionMarkers {
+		t.Errorf("Expected required version %s, got %s", versionMarkers, version)
+	}
+}
+
+func TestRoundTrip_UnusedTrackKeepsLayerSettings(t *testing.T) {
+	// The video track has no clips: the placeholder decoded for it must not
+	// replace the settings of the audio-only layer
+	const project = `<ges version='0.3'>
+  <project properties='properties;' metadatas='metadatas;'>
+    <timeline properties='properties, auto-transition=(boolean)true;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0' properties='properties;' metadatas='metadatas;'/>
+      <track caps='audio/x-raw(ANY)' track-type='2' track-id='1' properties='properties;' metadatas='metadatas;'/>
+      <layer priority='0' properties='properties, auto-transition=(boolean)false;' metadatas='metadatas, volume=(float)0.5, video::name=(string)Music;'>
+        <clip id='0' asset-id='file:///media/music.wav' type-name='GESUriClip' layer-priority='0' track-types='2' start='0' duration='4000000000' inpoint='0' rate='0' properties='properties;' />
+      </layer>
+    </timeline>
+  </project>
+</ges>`
+	original, err := ParseDocument(strings.NewReader(project))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	timeline, err := ToOTIO(original, DecodeOptions{})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	if layers := ges.Project.Timeline.Layers; len(layers) != 1 {
+		t.Fatalf("Expected 1 layer, got %d", len(layers))
+	}
+	layer := &ges.Project.Timeline.Layers[0]
+	if layer.AutoTransition() {
+		t.Error("Expected the layer to keep auto-transition false")
+	}
+	metadatas := structureOf(layer.Metadatas)
+	if volume, _ := metadatas.GetFloat("volume"); volume != 0.5 {
+		t.Errorf("Expected volume 0.5, got %s", layer.Metadatas)
+	}
+	if name, _ := metadatas.GetString(LayerNameField); name != "Music" {
+		t.Errorf("Expected the layer name Music, got %s", layer.Metadatas)
+	}
+	if len(ges.Project.Timeline.Tracks) != 2 {
+		t.Errorf("Expected both tracks kept, got %+v", ges.Project.Timeline.Tracks)
+	}
+}
+
+func TestRoundTrip_MissingAutoTransition(t *testing.T) {
+	// GES reads a missing auto-transition as false, so the encoder must not
+	// turn it on for projects decoded from XGES
+	input := strings.Replace(simpleXGES, "auto-transition=(boolean)true", "snapping-distance=(guint64)0", -1)
+	original, err := ParseDocument(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	if original.Project.Timeline.AutoTransition() || original.Project.Timeline.Layers[0].AutoTransition() {
+		t.Fatal("Expected no auto-transition in the input")
+	}
+	timeline, err := ToOTIO(original, DecodeOptions{})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if ges.Project.Timeline.AutoTransition() {
+		t.Errorf("Expected the timeline without auto-transition, got %s", ges.Project.Timeline.Properties)
+	}
+	for _, layer := range ges.Project.Timeline.Layers {
+		if layer.AutoTransition() {
+			t.Errorf("Expected layer %d without auto-transition, got %s", layer.Priority, layer.Properties)
+		}
+	}
+}
@@ -0,0 +1,502 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind is the kind of edit found by Diff
+type ChangeKind string
+
+// Edits reported by Diff
+const (
+	ChangeClipAdded         ChangeKind = "clip-added"
+	ChangeClipRemoved       ChangeKind = "clip-removed"
+	ChangeClipMoved         ChangeKind = "clip-moved"
+	ChangeTrimmedHead       ChangeKind = "trimmed-head"
+	ChangeTrimmedTail       ChangeKind = "trimmed-tail"
+	ChangeRelinked          ChangeKind = "relinked"
+	ChangeTransitionChanged ChangeKind = "transition-changed"
+	ChangeLayerReordered    ChangeKind = "layer-reordered"
+	ChangePropertyChanged   ChangeKind = "property-changed"
+)
+
+// Change is an edit between two versions of a project
+type Change struct {
+	Kind    ChangeKind `json:"kind"`
+	Message string     `json:"message"`
+	// Before and After are the clip in each version, nil when it doesn't
+	// exist there or the change isn't about a clip
+	Before *ClipRef `json:"before,omitempty"`
+	After  *ClipRef `json:"after,omitempty"`
+	// Property names the changed property, prefixed with the structure it
+	// belongs to, e.g. "children-properties.alpha"
+	Property string `json:"property,omitempty"`
+	// OldValue and NewValue are the changed property, asset or layer
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+	// Delta is how far a clip moved or an edge was trimmed, in nanoseconds
+	Delta int64 `json:"delta,omitempty"`
+}
+
+// ClipRef identifies a clip in one version of a project
+type ClipRef struct {
+	ID       int    `json:"id"`
+	Layer    int    `json:"layer"`
+	TypeName string `json:"type_name"`
+	Asset    string `json:"asset"`
+	Start    uint64 `json:"start"`
+	Duration uint64 `json:"duration"`
+	Inpoint  uint64 `json:"inpoint"`
+}
+
+// diffClip is a clip and the priority of its layer
+type diffClip struct {
+	clip    *Clip
+	layer   int
+	matched bool
+}
+
+func (c *diffClip) ref() *ClipRef {
+	return &ClipRef{
+		ID:       c.clip.ID,
+		Layer:    c.layer,
+		TypeName: c.clip.TypeName,
+		Asset:    c.clip.AssetID,
+		Start:    c.clip.Start,
+		Duration: c.clip.Duration,
+		Inpoint:  c.clip.Inpoint,
+	}
+}
+
+func (c *diffClip) end() uint64 {
+	return c.clip.Start + c.clip.Duration
+}
+
+// label names a clip in change messages
+func (c *diffClip) label() string {
+	if c.clip.AssetID == "" || c.clip.TypeName == ClipTypeTransition {
+		return fmt.Sprintf("clip %d", c.clip.ID)
+	}
+	return fmt.Sprintf("clip %d (%s)", c.clip.ID, path.Base(c.clip.AssetID))
+}
+
+// diffPair is a clip matched across the two versions
+type diffPair struct {
+	before, after *diffClip
+}
+
+// differ holds the state of one Diff
+type differ struct {
+	rate     float64
+	layerMap map[int]int
+	changes  []Change
+}
+
+// Diff compares two versions of a project in editing terms. Clip ids are
+// ignored: clips are matched by asset and position, so renumbered or
+// reordered elements don't show up as changes.
+func Diff(before, after *GES) []Change {
+	d := &differ{rate: after.Project.Timeline.FrameRate()}
+	if d.rate <= 0 {
+		d.rate = 25.0
+	}
+
+	oldClips, oldTransitions := diffClips(&before.Project.Timeline)
+	newClips, newTransitions := diffClips(&after.Project.Timeline)
+
+	// Same asset in the same place, then same asset anywhere, then
+	// another asset in the same place
+	pairs := matchClips(oldClips, newClips, func(c *diffClip, _ bool) diffKey {
+		return diffKey{c.clip.TypeName, c.clip.AssetID, c.layer, c.clip.Start}
+	}, func(a, b *diffClip) (uint64, bool) {
+		return 0, a.clip.Duration == b.clip.Duration && a.clip.Inpoint == b.clip.Inpoint
+	})
+	pairs = append(pairs, matchClips(oldClips, newClips, func(c *diffClip, _ bool) diffKey {
+		return diffKey{typeName: c.clip.TypeName, asset: c.clip.AssetID}
+	}, func(a, b *diffClip) (uint64, bool) {
+		return absDiff(a.clip.Start, b.clip.Start) + absDiff(a.clip.Inpoint, b.clip.Inpoint) +
+			absDiff(a.end(), b.end()) + uint64(absDiff(uint64(a.layer), uint64(b.layer)))*GSTSecond, true
+	})...)
+	d.mapLayers(pairs)
+
+	relinks := matchClips(oldClips, newClips, func(c *diffClip, before bool) diffKey {
+		return diffKey{typeName: c.clip.TypeName, layer: d.layerAfter(c, before)}
+	}, func(a, b *diffClip) (uint64, bool) {
+		if a.clip.Start >= b.end() || b.clip.Start >= a.end() {
+			return 0, false
+		}
+		return absDiff(a.clip.Start, b.clip.Start) + absDiff(a.end(), b.end()), true
+	})
+
+	d.diffLayers()
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].after.clip.Start < pairs[j].after.clip.Start })
+	for _, p := range pairs {
+		d.diffClip(p, false)
+	}
+	for _, p := range relinks {
+		d.diffClip(p, true)
+	}
+	for _, c := range oldClips {
+		if !c.matched {
+			d.add(Change{Kind: ChangeClipRemoved, Before: c.ref(),
+				Message: fmt.Sprintf("%s removed from layer %d at %s", c.label(), c.layer, d.time(c.clip.Start))})
+		}
+	}
+	for _, c := range newClips {
+		if !c.matched {
+			d.add(Change{Kind: ChangeClipAdded, After: c.ref(),
+				Message: fmt.Sprintf("%s added to layer %d at %s", c.label(), c.layer, d.time(c.clip.Start))})
+		}
+	}
+
+	d.diffTransitions(oldTransitions, newTransitions)
+	return d.changes
+}
+
+func (d *differ) add(c Change) {
+	d.changes = append(d.changes, c)
+}
+
+// time describes a timeline position in frames
+func (d *differ) time(ns uint64) string {
+	return fmt.Sprintf("frame %d", int64(float64(ns)*d.rate/GSTSecond+0.5))
+}
+
+// frames describes a signed duration in frames
+func (d *differ) frames(delta int64) string {
+	frames := float64(delta) * d.rate / GSTSecond
+	if frames < 0 {
+		frames = -frames
+	}
+	return strconv.FormatFloat(frames, 'g', 4, 64) + " frames"
+}
+
+// mapLayers finds where each layer went from the layers its clips are on
+func (d *differ) mapLayers(pairs []diffPair) {
+	counts := make(map[[2]int]int)
+	for _, p := range pairs {
+		counts[[2]int{p.before.layer, p.after.layer}]++
+	}
+
+	keys := make([][2]int, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	// Most clips first, staying on the same priority on ties
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+		if (keys[i][0] == keys[i][1]) != (keys[j][0] == keys[j][1]) {
+			return keys[i][0] == keys[i][1]
+		}
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+
+	d.layerMap = make(map[int]int)
+	taken := make(map[int]bool)
+	for _, k := range keys {
+		if _, ok := d.layerMap[k[0]]; ok || taken[k[1]] {
+			continue
+		}
+		d.layerMap[k[0]] = k[1]
+		taken[k[1]] = true
+	}
+}
+
+// mappedLayer returns the new priority of an old layer
+func (d *differ) mappedLayer(priority int) int {
+	if mapped, ok := d.layerMap[priority]; ok {
+		return mapped
+	}
+	return priority
+}
+
+func (d *differ) diffLayers() {
+	priorities := make([]int, 0, len(d.layerMap))
+	for priority := range d.layerMap {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+
+	for _, priority := range priorities {
+		if mapped := d.layerMap[priority]; mapped != priority {
+			d.add(Change{
+				Kind:     ChangeLayerReordered,
+				Message:  fmt.Sprintf("layer %d moved to priority %d", priority, mapped),
+				OldValue: strconv.Itoa(priority),
+				NewValue: strconv.Itoa(mapped),
+			})
+		}
+	}
+}
+
+// diffClip reports the edits between two matched clips
+func (d *differ) diffClip(p diffPair, relinked bool) {
+	a, b := p.before, p.after
+	change := func(kind ChangeKind, message string) Change {
+		return Change{Kind: kind, Message: message, Before: a.ref(), After: b.ref()}
+	}
+
+	if relinked {
+		c := change(ChangeRelinked, fmt.Sprintf("clip %d relinked from %s to %s", b.clip.ID, a.clip.AssetID, b.clip.AssetID))
+		c.OldValue, c.NewValue = a.clip.AssetID, b.clip.AssetID
+		d.add(c)
+	}
+
+	if d.mappedLayer(a.layer) != b.layer {
+		c := change(ChangeClipMoved, fmt.Sprintf("%s moved from layer %d to layer %d", b.label(), a.layer, b.layer))
+		c.OldValue, c.NewValue = strconv.Itoa(a.layer), strconv.Itoa(b.layer)
+		d.add(c)
+	}
+
+	// A head trim moves the start along with the in-point, so a move is a
+	// change of the timeline position of the media
+	offset := (int64(b.clip.Start) - int64(b.clip.Inpoint)) - (int64(a.clip.Start) - int64(a.clip.Inpoint))
+	if relinked {
+		offset = int64(b.clip.Start) - int64(a.clip.Start)
+	}
+	if offset != 0 {
+		direction := "later"
+		if offset < 0 {
+			direction = "earlier"
+		}
+		c := change(ChangeClipMoved, fmt.Sprintf("%s moved %s %s", b.label(), d.frames(offset), direction))
+		c.Delta = offset
+		d.add(c)
+	}
+
+	if !relinked {
+		if delta := int64(b.clip.Inpoint) - int64(a.clip.Inpoint); delta != 0 {
+			c := change(ChangeTrimmedHead, fmt.Sprintf("%s head %s by %s", b.label(), trimVerb(delta), d.frames(delta)))
+			c.Delta = delta
+			d.add(c)
+		}
+		if delta := int64(b.clip.Inpoint+b.clip.Duration) - int64(a.clip.Inpoint+a.clip.Duration); delta != 0 {
+			c := change(ChangeTrimmedTail, fmt.Sprintf("%s tail %s by %s", b.label(), trimVerb(-delta), d.frames(delta)))
+			c.Delta = delta
+			d.add(c)
+		}
+	} else if delta := int64(b.clip.Duration) - int64(a.clip.Duration); delta != 0 {
+		c := change(ChangeTrimmedTail, fmt.Sprintf("%s duration changed by %s", b.label(), d.frames(delta)))
+		c.Delta = delta
+		d.add(c)
+	}
+
+	for _, section := range []struct {
+		name          string
+		before, after string
+	}{
+		{"properties", a.clip.Properties, b.clip.Properties},
+		{"metadatas", a.clip.Metadatas, b.clip.Metadatas},
+		{"children-properties", a.clip.ChildrenProperties, b.clip.ChildrenProperties},
+	} {
+		for _, f := range diffFields(section.before, section.after) {
+			property := section.name
+			if f.name != "" {
+				property += "." + f.name
+			}
+			c := change(ChangePropertyChanged, fmt.Sprintf("%s %s changed from %s to %s", b.label(), property, orNone(f.before), orNone(f.after)))
+			c.Property = property
+			c.OldValue, c.NewValue = f.before, f.after
+			d.add(c)
+		}
+	}
+}
+
+// diffTransitions matches transitions by layer and time and reports the
+// ones added, removed or changed
+func (d *differ) diffTransitions(oldTransitions, newTransitions []*diffClip) {
+	pairs := matchClips(oldTransitions, newTransitions, func(c *diffClip, before bool) diffKey {
+		return diffKey{layer: d.layerAfter(c, before)}
+	}, func(a, b *diffClip) (uint64, bool) {
+		if a.clip.TrackTypes&b.clip.TrackTypes == 0 ||
+			a.clip.Start > b.end() || b.clip.Start > a.end() {
+			return 0, false
+		}
+		return absDiff(a.clip.Start, b.clip.Start) + absDiff(a.end(), b.end()), true
+	})
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].after.clip.Start < pairs[j].after.clip.Start })
+
+	for _, p := range pairs {
+		a, b := p.before, p.after
+		if a.clip.AssetID != b.clip.AssetID {
+			d.add(Change{
+				Kind:     ChangeTransitionChanged,
+				Message:  fmt.Sprintf("transition %d on layer %d changed from %s to %s", b.clip.ID, b.layer, a.clip.AssetID, b.clip.AssetID),
+				Before:   a.ref(),
+				After:    b.ref(),
+				OldValue: a.clip.AssetID,
+				NewValue: b.clip.AssetID,
+			})
+		}
+		if a.clip.Start != b.clip.Start || a.clip.Duration != b.clip.Duration {
+			d.add(Change{
+				Kind: ChangeTransitionChanged,
+				Message: fmt.Sprintf("transition %d on layer %d changed from %s at %s to %s at %s", b.clip.ID, b.layer,
+					d.frames(int64(a.clip.Duration)), d.time(a.clip.Start), d.frames(int64(b.clip.Duration)), d.time(b.clip.Start)),
+				Before: a.ref(),
+				After:  b.ref(),
+				Delta:  int64(b.clip.Duration) - int64(a.clip.Duration),
+			})
+		}
+	}
+	for _, c := range oldTransitions {
+		if !c.matched {
+			d.add(Change{Kind: ChangeTransitionChanged, Before: c.ref(),
+				Message: fmt.Sprintf("%s transition removed from layer %d at %s", c.clip.AssetID, c.layer, d.time(c.clip.Start))})
+		}
+	}
+	for _, c := range newTransitions {
+		if !c.matched {
+			d.add(Change{Kind: ChangeTransitionChanged, After: c.ref(),
+				Message: fmt.Sprintf("%s transition added to layer %d at %s", c.clip.AssetID, c.layer, d.time(c.clip.Start))})
+		}
+	}
+}
+
+// diffClips splits the clips of a timeline into clips and transitions
+func diffClips(timeline *Timeline) (clips, transitions []*diffClip) {
+	for l := range timeline.Layers {
+		layer := &timeline.Layers[l]
+		for i := range layer.Clips {
+			c := &diffClip{clip: &layer.Clips[i], layer: layer.Priority}
+			if c.clip.TypeName == ClipTypeTransition {
+				transitions = append(transitions, c)
+			} else {
+				clips = append(clips, c)
+			}
+		}
+	}
+	return clips, transitions
+}
+
+// diffKey groups clips for matchClips. Fields a pass doesn't compare are
+// left zero.
+type diffKey struct {
+	typeName, asset string
+	layer           int
+	start           uint64
+}
+
+// layerAfter returns the layer of a clip in the new version of the timeline
+func (d *differ) layerAfter(c *diffClip, before bool) int {
+	if before {
+		return d.mappedLayer(c.layer)
+	}
+	return c.layer
+}
+
+// matchClips pairs unmatched clips accepted by match, lowest cost first.
+// Only clips with the same key are compared, so matching stays fast on
+// large timelines.
+func matchClips(before, after []*diffClip, key func(c *diffClip, before bool) diffKey, match func(a, b *diffClip) (uint64, bool)) []diffPair {
+	groups := make(map[diffKey][]*diffClip)
+	for _, b := range after {
+		if !b.matched {
+			k := key(b, false)
+			groups[k] = append(groups[k], b)
+		}
+	}
+
+	type candidate struct {
+		pair diffPair
+		cost uint64
+	}
+	var candidates []candidate
+	for _, a := range before {
+		if a.matched {
+			continue
+		}
+		for _, b := range groups[key(a, true)] {
+			if cost, ok := match(a, b); ok {
+				candidates = append(candidates, candidate{diffPair{a, b}, cost})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].cost < candidates[j].cost })
+
+	var pairs []diffPair
+	for _, c := range candidates {
+		if c.pair.before.matched || c.pair.after.matched {
+			continue
+		}
+		c.pair.before.matched, c.pair.after.matched = true, true
+		pairs = append(pairs, c.pair)
+	}
+	return pairs
+}
+
+// fieldChange is a structure field that differs between two versions
+type fieldChange struct {
+	name          string
+	before, after string
+}
+
+// diffFields compares two serialized structures field by field
+func diffFields(before, after string) []fieldChange {
+	if before == after {
+		return nil
+	}
+	a, errA := ParseStructure(before)
+	b, errB := ParseStructure(after)
+	if errA != nil || errB != nil {
+		return []fieldChange{{"", before, after}}
+	}
+
+	var changes []fieldChange
+	for _, f := range a.Fields {
+		if g, ok := b.Get(f.Name); !ok {
+			changes = append(changes, fieldChange{f.Name, f.Value, ""})
+		} else if f.Name == "name" && isGeneratedName(f.Value) && isGeneratedName(g.Value) {
+			// GES renames clips after their id, e.g. uriclip12
+			continue
+		} else if f.Value != g.Value || f.Type != g.Type {
+			changes = append(changes, fieldChange{f.Name, f.Value, g.Value})
+		}
+	}
+	for _, g := range b.Fields {
+		if !a.Has(g.Name) {
+			changes = append(changes, fieldChange{g.Name, "", g.Value})
+		}
+	}
+	return changes
+}
+
+// isGeneratedName reports whether a clip name looks like one GES made up
+// from the clip type and a counter
+func isGeneratedName(name string) bool {
+	letters := strings.TrimRight(name, "0123456789")
+	if letters == "" || letters == name {
+		return false
+	}
+	return strings.IndexFunc(letters, func(r rune) bool { return r < 'a' || r > 'z' }) < 0
+}
+
+func absDiff(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+func trimVerb(delta int64) string {
+	if delta > 0 {
+		return "trimmed"
+	}
+	return "extended"
+}
+
+func orNone(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
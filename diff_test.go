@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiff_Identical(t *testing.T) {
+	before := loadTestProject(t, "xges_example.xges")
+	after := loadTestProject(t, "xges_example.xges")
+
+	// Renumbering clips is not an edit
+	for l := range after.Project.Timeline.Layers {
+		for i := range after.Project.Timeline.Layers[l].Clips {
+			after.Project.Timeline.Layers[l].Clips[i].ID += 100
+		}
+	}
+
+	if changes := Diff(before, after); len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+}
+
+func TestDiff_Edits(t *testing.T) {
+	const s = GSTSecond
+
+	second := lintClip(2, "file:///a.mov", 8*s, 2*s)
+	second.Inpoint = 5 * s
+	second.Properties = "properties, name=(string)uriclip2;"
+	second.ChildrenProperties = "properties, alpha=(double)1;"
+
+	before := lintProject(
+		Layer{Priority: 0, Clips: []Clip{
+			lintClip(0, "file:///a.mov", 0, 4*s),
+			{ID: 6, AssetID: "crossfade", TypeName: ClipTypeTransition, TrackTypes: TrackTypeVideo, Start: 3 * s, Duration: s},
+			lintClip(1, "file:///b.mov", 4*s, 4*s),
+			second,
+			lintClip(5, "file:///x.mov", 10*s, 2*s),
+		}},
+		Layer{Priority: 1, Clips: []Clip{lintClip(3, "file:///b.mov", 0, 2*s)}},
+		Layer{Priority: 2, Clips: []Clip{lintClip(4, "file:///c.mov", 0, s)}},
+	)
+
+	trimmed := lintClip(12, "file:///a.mov", 8*s+s/2, 2*s-s/2)
+	trimmed.Inpoint = 5*s + s/2
+	trimmed.Properties = "properties, name=(string)uriclip12;"
+	trimmed.ChildrenProperties = "properties, alpha=(double)0.5;"
+
+	after := lintProject(
+		Layer{Priority: 0, Clips: []Clip{
+			lintClip(10, "file:///a.mov", 0, 3*s),
+			{ID: 16, AssetID: "crossfade", TypeName: ClipTypeTransition, TrackTypes: TrackTypeVideo, Start: 3 * s, Duration: s / 2},
+			lintClip(11, "file:///b.mov", 5*s, 4*s),
+			trimmed,
+			lintClip(15, "file:///y.mov", 10*s, 2*s),
+			lintClip(17, "file:///z.mov", 20*s, s),
+		}},
+		Layer{Priority: 2, Clips: []Clip{lintClip(13, "file:///b.mov", 0, 2*s)}},
+	)
+
+	changes := Diff(before, after)
+
+	expected := []struct {
+		kind  ChangeKind
+		delta int64
+	}{
+		{ChangeLayerReordered, 0},
+		{ChangeTrimmedTail, -s},
+		{ChangeClipMoved, s},
+		{ChangeTrimmedHead, s / 2},
+		{ChangePropertyChanged, 0},
+		{ChangeRelinked, 0},
+		{ChangeClipRemoved, 0},
+		{ChangeClipAdded, 0},
+		{ChangeTransitionChanged, -s / 2},
+	}
+	if len(changes) != len(expected) {
+		for _, c := range changes {
+			t.Log(c.Message)
+		}
+		t.Fatalf("Expected %d changes, got %d", len(expected), len(changes))
+	}
+	for i, e := range expected {
+		if changes[i].Kind != e.kind || changes[i].Delta != e.delta {
+			t.Errorf("Change %d: expected %s %d, got %s %d (%s)", i, e.kind, e.delta, changes[i].Kind, changes[i].Delta, changes[i].Message)
+		}
+	}
+
+	if c := changes[0]; c.OldValue != "1" || c.NewValue != "2" {
+		t.Errorf("Expected layer 1 to move to 2, got %s to %s", c.OldValue, c.NewValue)
+	}
+	if c := changes[4]; c.Property != "children-properties.alpha" || c.OldValue != "1" || c.NewValue != "0.5" {
+		t.Errorf("Unexpected property change %+v", c)
+	}
+	if c := changes[5]; c.OldValue != "file:///x.mov" || c.NewValue != "file:///y.mov" || c.Before.ID != 5 || c.After.ID != 15 {
+		t.Errorf("Unexpected relink %+v", c)
+	}
+	if c := changes[6]; c.Before == nil || c.Before.Asset != "file:///c.mov" || c.After != nil {
+		t.Errorf("Unexpected removal %+v", c)
+	}
+}
+
+func TestIsGeneratedName(t *testing.T) {
+	testCases := map[string]bool{
+		"uriclip12":     true,
+		"transition3":   true,
+		"uriclip":       false,
+		"12":            false,
+		"Interview 2":   false,
+		"titleclip-two": false,
+	}
+	for name, expected := range testCases {
+		if got := isGeneratedName(name); got != expected {
+			t.Errorf("isGeneratedName(%q) = %v, expected %v", name, got, expected)
+		}
+	}
+}
+
+func BenchmarkDiff10kClips(b *testing.B) {
+	const n = 10000
+	var before, after []Clip
+	for i := 0; i < n; i++ {
+		clip := lintClip(i, fmt.Sprintf("file:///media/%03d.mov", i%100), uint64(i)*4*GSTSecond, 2*GSTSecond)
+		before = append(before, clip)
+		switch i % 10 {
+		case 0:
+			clip.Start += GSTSecond
+		case 5:
+			clip.AssetID = "file:///media/relinked.mov"
+		}
+		clip.ID += n
+		after = append(after, clip)
+	}
+	old := lintProject(Layer{Priority: 0, Clips: before})
+	edited := lintProject(Layer{Priority: 0, Clips: after})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if changes := Diff(old, edited); len(changes) == 0 {
+			b.Fatal("Expected changes")
+		}
+	}
+}
+
+// changesOfKind returns the changes of one kind
+func changesOfKind(changes []Change, kind ChangeKind) []Change {
+	var found []Change
+	for _, c := range changes {
+		if c.Kind == kind {
+			found = append(found, c)
+		}
+	}
+	return found
+}
@@ -0,0 +1,328 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// NewDocument creates an empty GES document of the default format version,
+// ready for tracks, layers and clips to be added
+func NewDocument() *GES {
+	return &GES{
+		Version: DefaultFormatVersion.String(),
+		Project: Project{
+			Properties: "properties;",
+			Metadatas:  "metadatas;",
+			Ressources: &Ressources{},
+			Timeline: Timeline{
+				Properties: "properties, auto-transition=(boolean)true;",
+			},
+		},
+	}
+}
+
+// ParseDocument reads a GES document without converting it
+func ParseDocument(r io.Reader) (*GES, error) {
+	var ges GES
+	if err := xml.NewDecoder(r).Decode(&ges); err != nil {
+		return nil, fmt.Errorf("failed to decode XGES XML: %w", err)
+	}
+	return &ges, nil
+}
+
+// WriteDocument writes a GES document formatted as GES saves it
+func WriteDocument(w io.Writer, ges *GES) error {
+	return NewWriter(w).WriteDocument(ges)
+}
+
+// ToOTIO converts a parsed GES document to an OTIO Timeline. Documents older
+// than format version 0.2 are upgraded to the current model in place. Use a
+// Decoder's DecodeDocument to get the lossy conversions made.
+func ToOTIO(ges *GES, opts DecodeOptions) (*gotio.Timeline, error) {
+	d := NewDecoder(nil)
+	d.SetOptions(opts)
+	return d.DecodeDocument(ges)
+}
+
+// FromOTIO converts an OTIO Timeline to a GES document, which can be edited
+// before it is written with WriteDocument. Use an Encoder's EncodeDocument
+// to get the lossy conversions made.
+func FromOTIO(timeline *gotio.Timeline, opts EncodeOptions) (*GES, error) {
+	e := NewEncoder(nil)
+	e.SetOptions(opts)
+	return e.EncodeDocument(timeline)
+}
+
+// AddTrack adds a track of a type with the default caps of the type and
+// the next free track id. The track is valid until the next AddTrack.
+func (t *Timeline) AddTrack(trackType int) *Track {
+	id := 0
+	for _, track := range t.Tracks {
+		if track.TrackID >= id {
+			id = track.TrackID + 1
+		}
+	}
+	t.Tracks = append(t.Tracks, Track{
+		Caps:       defaultCaps(trackType),
+		TrackType:  trackType,
+		TrackID:    id,
+		Properties: "properties;",
+	})
+	return &t.Tracks[len(t.Tracks)-1]
+}
+
+// AddLayer adds a layer below the others, with the next priority. The layer
+// is valid until the next AddLayer.
+func (t *Timeline) AddLayer() *Layer {
+	priority := 0
+	for _, layer := range t.Layers {
+		if layer.Priority >= priority {
+			priority = layer.Priority + 1
+		}
+	}
+	t.Layers = append(t.Layers, Layer{
+		Priority:   priority,
+		Properties: "properties, auto-transition=(boolean)true;",
+	})
+	return &t.Layers[len(t.Layers)-1]
+}
+
+// Layer returns the layer of a priority
+func (t *Timeline) Layer(priority int) (*Layer, bool) {
+	for i := range t.Layers {
+		if t.Layers[i].Priority == priority {
+			return &t.Layers[i], true
+		}
+	}
+	return nil, false
+}
+
+// NextClipID returns the id following the highest clip or group id of the
+// timeline, as clips and groups share their ids
+func (t *Timeline) NextClipID() int {
+	id := 0
+	for i := range t.Layers {
+		for _, clip := range t.Layers[i].Clips {
+			if clip.ID >= id {
+				id = clip.ID + 1
+			}
+		}
+	}
+	for _, group := range t.AllGroups() {
+		if group.ID >= id {
+			id = group.ID + 1
+		}
+	}
+	return id
+}
+
+// AddClip adds a copy of clip to the layer of a priority, with the next
+// free id and the priority of the layer. The ids of its effects follow.
+// The clip is valid until the next clip is added to the layer.
+func (t *Timeline) AddClip(priority int, clip Clip) (*Clip, error) {
+	layer, ok := t.Layer(priority)
+	if !ok {
+		return nil, fmt.Errorf("no layer of priority %d", priority)
+	}
+	clip.ID = t.NextClipID()
+	clip.LayerPriority = layer.Priority
+	clip.Effects = append([]Effect(nil), clip.Effects...)
+	for i := range clip.Effects {
+		clip.Effects[i].ClipID = clip.ID
+	}
+	layer.Clips = append(layer.Clips, clip)
+	return &layer.Clips[len(layer.Clips)-1], nil
+}
+
+// FindClip returns the clip of an id and its layer
+func (t *Timeline) FindClip(id int) (*Clip, *Layer, bool) {
+	for i := range t.Layers {
+		layer := &t.Layers[i]
+		for j := range layer.Clips {
+			if layer.Clips[j].ID == id {
+				return &layer.Clips[j], layer, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+// SyncLayerPriorities sets the layer-priority of every clip to the
+// priority of the layer holding it, after layers were reordered
+func (t *Timeline) SyncLayerPriorities() {
+	for i := range t.Layers {
+		layer := &t.Layers[i]
+		for j := range layer.Clips {
+			layer.Clips[j].LayerPriority = layer.Priority
+		}
+	}
+}
+
+// AssetFor returns the asset a clip is extracted from
+func (p *Project) AssetFor(clip *Clip) (*Asset, bool) {
+	if p.Ressources == nil {
+		return nil, false
+	}
+	for i := range p.Ressources.Assets {
+		asset := &p.Ressources.Assets[i]
+		if asset.ID == clip.AssetID && asset.ExtractableTypeName == clip.TypeName {
+			return asset, true
+		}
+	}
+	return nil, false
+}
+
+// AddAsset returns the asset of an id and extractable type, adding it to
+// the project resources if needed. The asset is valid until the next asset
+// is added.
+func (p *Project) AddAsset(id, typeName string) *Asset {
+	if asset, ok := p.AssetFor(&Clip{AssetID: id, TypeName: typeName}); ok {
+		return asset
+	}
+	if p.Ressources == nil {
+		p.Ressources = &Ressources{}
+	}
+	p.Ressources.Assets = append(p.Ressources.Assets, Asset{
+		ID:                  id,
+		ExtractableTypeName: typeName,
+		Properties:          "properties;",
+		Metadatas:           "metadatas;",
+	})
+	return &p.Ressources.Assets[len(p.Ressources.Assets)-1]
+}
+
+// editStructure parses the structure held in attribute, or creates an
+// empty one of a name, applies edit and stores the result back
+func editStructure(attribute *string, name string, edit func(s *Structure)) {
+	s, err := ParseStructure(*attribute)
+	if err != nil || *attribute == "" {
+		s = NewStructure(name)
+	}
+	edit(s)
+	*attribute = s.String()
+}
+
+// structureOf parses the structure held in an attribute, ignoring errors
+func structureOf(attribute string) *Structure {
+	s, err := ParseStructure(attribute)
+	if err != nil {
+		return NewStructure("properties")
+	}
+	return s
+}
+
+// Name returns the name property of the clip
+func (c *Clip) Name() string {
+	name, _ := structureOf(c.Properties).GetString("name")
+	return name
+}
+
+// SetName sets the name property of the clip
+func (c *Clip) SetName(name string) {
+	editStructure(&c.Properties, "properties", func(s *Structure) { s.SetString("name", name) })
+}
+
+// Mute reports whether the clip is muted
+func (c *Clip) Mute() bool {
+	mute, _ := structureOf(c.Properties).GetBool("mute")
+	return mute
+}
+
+// SetMute mutes or unmutes the clip
+func (c *Clip) SetMute(mute bool) {
+	editStructure(&c.Properties, "properties", func(s *Structure) { s.SetBool("mute", mute) })
+}
+
+// ChildProperty returns a child property of the clip, such as "volume" or
+// "GstVolume::volume"
+func (c *Clip) ChildProperty(property string) (Field, bool) {
+	children := structureOf(c.ChildrenProperties)
+	name, ok := children.ChildPropertyName(property)
+	if !ok {
+		return Field{}, false
+	}
+	return children.Get(name)
+}
+
+// SetChildProperty sets a child property of the clip, keeping the name it
+// is qualified with if the clip already has it
+func (c *Clip) SetChildProperty(property, typ, value string) {
+	editStructure(&c.ChildrenProperties, "properties", func(s *Structure) {
+		if name, ok := s.ChildPropertyName(property); ok {
+			property = name
+		}
+		s.Set(property, typ, value)
+	})
+}
+
+// End returns the end of the clip in nanoseconds. A clip whose duration is
+// left to GES ends at GST_CLOCK_TIME_NONE.
+func (c *Clip) End() uint64 {
+	if c.Duration == gstClockTimeNone {
+		return gstClockTimeNone
+	}
+	return c.Start + c.Duration
+}
+
+// Name returns the name of the layer
+func (l *Layer) Name() string {
+	metadatas := structureOf(l.Metadatas)
+	if name, ok := metadatas.GetString(LayerNameField); ok {
+		return name
+	}
+	name, _ := metadatas.GetString("name")
+	return name
+}
+
+// SetName sets the name of the layer as Pitivi stores it
+func (l *Layer) SetName(name string) {
+	editStructure(&l.Metadatas, "metadatas", func(s *Structure) { s.SetString(LayerNameField, name) })
+}
+
+// Volume returns the volume of the layer, 1 by default
+func (l *Layer) Volume() float64 {
+	if volume, ok := structureOf(l.Metadatas).GetFloat("volume"); ok {
+		return volume
+	}
+	return 1
+}
+
+// SetVolume sets the volume of the layer
+func (l *Layer) SetVolume(volume float64) {
+	editStructure(&l.Metadatas, "metadatas", func(s *Structure) {
+		s.Set("volume", "float", strconv.FormatFloat(volume, 'g', -1, 64))
+	})
+}
+
+// AutoTransition reports whether GES creates transitions between the
+// overlapping clips of the layer, false by default as in GES
+func (l *Layer) AutoTransition() bool {
+	autoTransition, _ := structureOf(l.Properties).GetBool("auto-transition")
+	return autoTransition
+}
+
+// SetAutoTransition sets whether GES creates transitions between the
+// overlapping clips of the layer
+func (l *Layer) SetAutoTransition(autoTransition bool) {
+	editStructure(&l.Properties, "properties", func(s *Structure) { s.SetBool("auto-transition", autoTransition) })
+}
+
+// AutoTransition reports whether the timeline creates transitions between
+// overlapping clips, false by default as in GES
+func (t *Timeline) AutoTransition() bool {
+	autoTransition, _ := structureOf(t.Properties).GetBool("auto-transition")
+	return autoTransition
+}
+
+// SetAutoTransition sets whether the timeline creates transitions between
+// overlapping clips
+func (t *Timeline) SetAutoTransition(autoTransition bool) {
+	editStructure(&t.Properties, "properties", func(s *Structure) { s.SetBool("auto-transition", autoTransition) })
+}
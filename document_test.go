@@ -0,0 +1,159 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDocument_Build(t *testing.T) {
+	ges := NewDocument()
+	timeline := &ges.Project.Timeline
+	timeline.AddTrack(TrackTypeVideo)
+	if track := timeline.AddTrack(TrackTypeAudio); track.TrackID != 1 || track.Caps != "audio/x-raw(ANY)" {
+		t.Errorf("Expected audio track 1, got %+v", track)
+	}
+
+	timeline.AddLayer().SetName("Main")
+	if layer := timeline.AddLayer(); layer.Priority != 1 {
+		t.Errorf("Expected layer priority 1, got %d", layer.Priority)
+	}
+
+	asset := ges.Project.AddAsset("file:///a.mov", ClipTypeURI)
+	clip := Clip{AssetID: asset.ID, TypeName: ClipTypeURI, TrackTypes: TrackTypeVideo | TrackTypeAudio, Duration: 2 * GSTSecond, LayerPriority: 7}
+	clip.SetName("a")
+	first, err := timeline.AddClip(0, clip)
+	if err != nil {
+		t.Fatalf("AddClip failed: %v", err)
+	}
+	if first.ID != 0 || first.LayerPriority != 0 {
+		t.Errorf("Expected clip 0 in layer 0, got id %d in layer %d", first.ID, first.LayerPriority)
+	}
+	clip.Start = clip.Duration
+	second, err := timeline.AddClip(1, clip)
+	if err != nil {
+		t.Fatalf("AddClip failed: %v", err)
+	}
+	if second.ID != 1 || second.LayerPriority != 1 {
+		t.Errorf("Expected clip 1 in layer 1, got id %d in layer %d", second.ID, second.LayerPriority)
+	}
+	if _, err := timeline.AddClip(5, clip); err == nil {
+		t.Error("Expected an error for a missing layer")
+	}
+
+	found, layer, ok := timeline.FindClip(1)
+	if !ok || found.Name() != "a" || layer.Priority != 1 {
+		t.Errorf("Expected clip 1 named a in layer 1, got %+v", found)
+	}
+	if asset, ok := ges.Project.AssetFor(found); !ok || asset.ID != "file:///a.mov" {
+		t.Errorf("Expected the asset of clip 1, got %+v", asset)
+	}
+	if len(ges.Project.Assets()) != 1 || ges.Project.AddAsset("file:///a.mov", ClipTypeURI) != &ges.Project.Ressources.Assets[0] {
+		t.Error("Expected AddAsset to return the existing asset")
+	}
+
+	// The document reads back the same
+	var sb strings.Builder
+	if err := WriteDocument(&sb, ges); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+	parsed, err := ParseDocument(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+	if parsed.Project.Timeline.Layers[0].Name() != "Main" || parsed.Project.Timeline.NextClipID() != 2 {
+		t.Errorf("Unexpected parsed document:\n%s", sb.String())
+	}
+	decoded, err := ReadString(sb.String())
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if n := len(decoded.VideoTracks()); n != 2 {
+		t.Errorf("Expected 2 video tracks, got %d", n)
+	}
+}
+
+func TestDocument_Properties(t *testing.T) {
+	ges := loadTestProject(t, "xges_example.xges")
+	timeline := &ges.Project.Timeline
+	if len(timeline.Layers) == 0 || len(timeline.Layers[0].Clips) == 0 {
+		t.Fatal("Expected clips in the example project")
+	}
+
+	layer := &timeline.Layers[0]
+	layer.SetVolume(0.5)
+	layer.SetAutoTransition(false)
+	if layer.Volume() != 0.5 || layer.AutoTransition() {
+		t.Errorf("Expected volume 0.5 without auto-transition, got %s %s", layer.Metadatas, layer.Properties)
+	}
+
+	clip := &layer.Clips[0]
+	clip.SetMute(true)
+	if !clip.Mute() {
+		t.Errorf("Expected a muted clip, got %s", clip.Properties)
+	}
+	clip.SetChildProperty("alpha", "double", "0.5")
+	if f, ok := clip.ChildProperty("alpha"); !ok || f.Value != "0.5" {
+		t.Errorf("Expected alpha 0.5, got %s", clip.ChildrenProperties)
+	}
+	if clip.End() != clip.Start+clip.Duration {
+		t.Errorf("Expected end %d, got %d", clip.Start+clip.Duration, clip.End())
+	}
+
+	// Renumbered layers carry their clips along
+	layer.Priority = 3
+	timeline.SyncLayerPriorities()
+	if clip.LayerPriority != 3 {
+		t.Errorf("Expected layer-priority 3, got %d", clip.LayerPriority)
+	}
+}
+
+func TestParseDocument(t *testing.T) {
+	f, err := os.Open("testdata/xges_example.xges")
+	if err != nil {
+		t.Fatalf("Failed to open test data: %v", err)
+	}
+	defer f.Close()
+	if _, err := ParseDocument(f); err != nil {
+		t.Errorf("ParseDocument failed: %v", err)
+	}
+	if _, err := ParseDocument(strings.NewReader("<ges><project>")); err == nil {
+		t.Error("Expected an error for truncated XML")
+	}
+}
+
+func TestToOTIOAndFromOTIO(t *testing.T) {
+	ges := NewDocument()
+	timeline := &ges.Project.Timeline
+	timeline.AddTrack(TrackTypeVideo)
+	timeline.AddLayer()
+	clip := Clip{AssetID: "file:///a.mov", TypeName: ClipTypeURI, TrackTypes: TrackTypeVideo, Duration: GSTSecond}
+	clip.SetName("a")
+	if _, err := timeline.AddClip(0, clip); err != nil {
+		t.Fatalf("AddClip failed: %v", err)
+	}
+
+	otioTimeline, err := ToOTIO(ges, DecodeOptions{Rate: 24})
+	if err != nil {
+		t.Fatalf("ToOTIO failed: %v", err)
+	}
+	tracks := otioTimeline.VideoTracks()
+	if len(tracks) != 1 || len(tracks[0].Children()) != 1 {
+		t.Fatalf("Expected one video track with one clip, got %d tracks", len(tracks))
+	}
+
+	encoded, err := FromOTIO(otioTimeline, EncodeOptions{Version: "0.4"})
+	if err != nil {
+		t.Fatalf("FromOTIO failed: %v", err)
+	}
+	if encoded.Version != "0.4" {
+		t.Errorf("Expected version 0.4, got %s", encoded.Version)
+	}
+	layers := encoded.Project.Timeline.Layers
+	if len(layers) != 1 || len(layers[0].Clips) != 1 || layers[0].Clips[0].Name() != "a" || layers[0].Clips[0].Duration != GSTSecond {
+		t.Errorf("Expected clip a of 1s, got %+v", layers)
+	}
+}
@@ -0,0 +1,700 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// EditMode is how an edit affects the clips around the edited clip, as in
+// the GES edit modes
+type EditMode int
+
+// Edit modes
+const (
+	// EditNormal moves the clip, or trims it when an edge is given
+	EditNormal EditMode = iota
+	// EditRipple moves or trims the clip and shifts every later clip of the
+	// timeline by as much
+	EditRipple
+	// EditRoll trims an edge of the clip and the opposite edge of the clips
+	// it touches, so nothing else moves
+	EditRoll
+	// EditTrim trims an edge of the clip
+	EditTrim
+	// EditSlide moves the clip and trims the clips it touches, so nothing
+	// else moves
+	EditSlide
+)
+
+// Edge is the edge of a clip an edit trims
+type Edge int
+
+// Clip edges
+const (
+	EdgeNone  Edge = iota // the edit moves the clip
+	EdgeStart             // the edit trims the start of the clip
+	EdgeEnd               // the edit trims the end of the clip
+)
+
+// Edit edits the clip of an id as ges_timeline_element_edit does. Without
+// an edge, the clip moves to start at position and, unless layerPriority is
+// -1, to the layer of that priority. With an edge, that edge is trimmed to
+// position and layerPriority is ignored.
+//
+// As in GES, clips grouped with the clip move with it, and those sharing
+// the trimmed edge, such as linked audio and video, are trimmed with it.
+// Edits that would leave a clip fully overlapping another, three clips
+// overlapping in a track or a clip reading past the end of its media fail
+// and leave the project unchanged. The transitions of layers with
+// auto-transition are updated afterwards.
+//
+// With ripple, trimming the start keeps the clip in place and moves the
+// later clips back by as much as the clip lost.
+func (g *GES) Edit(id, layerPriority int, mode EditMode, edge Edge, position uint64) error {
+	return g.edit(id, func(e *editor, clip *Clip) error {
+		switch edge {
+		case EdgeNone:
+			return e.move(clip, layerPriority, mode, position)
+		case EdgeStart, EdgeEnd:
+			return e.trim(clip, mode, edge, position)
+		}
+		return fmt.Errorf("invalid edge %d", edge)
+	})
+}
+
+// MoveToLayer moves the clip of an id, with the clips grouped with it, to
+// the layer of a priority
+func (g *GES) MoveToLayer(id, priority int) error {
+	return g.edit(id, func(e *editor, clip *Clip) error {
+		return e.move(clip, priority, EditNormal, clip.Start)
+	})
+}
+
+// Slip changes the part of its media the clip of an id and its linked
+// clips play without moving them, by adding delta to their inpoint
+func (g *GES) Slip(id int, delta int64) error {
+	return g.edit(id, func(e *editor, clip *Clip) error {
+		for _, c := range e.toplevel(clip) {
+			if c.Start != clip.Start || c.End() != clip.End() {
+				continue
+			}
+			inpoint := int64(c.Inpoint) + delta
+			if inpoint < 0 {
+				return fmt.Errorf("clip %d can't start before the start of its media", c.ID)
+			}
+			c.Inpoint = uint64(inpoint)
+			e.touch(c)
+		}
+		return nil
+	})
+}
+
+// Split cuts the clip of an id, and the clips grouped with it that span
+// position, at position. The clips keep the part before position and new
+// clips play the rest; the new clips are grouped together when several
+// clips were cut. It returns the id of the new clip cut from the clip.
+func (g *GES) Split(id int, position uint64) (int, error) {
+	newID := -1
+	err := g.edit(id, func(e *editor, clip *Clip) error {
+		if position <= clip.Start || position >= clip.End() {
+			return fmt.Errorf("position %d is not inside clip %d", position, id)
+		}
+		var children []GroupChild
+		for _, c := range e.toplevel(clip) {
+			if c.Start >= position || c.End() <= position {
+				continue
+			}
+			right := e.split(c, position)
+			if c == clip {
+				newID = right.ID
+			}
+			children = append(children, GroupChild{ID: right.ID, Name: right.Name()})
+		}
+		if len(children) > 1 {
+			e.groups = append(e.groups, Group{ID: e.nextID, Properties: "properties;", Children: children})
+			e.nextID++
+		}
+		return nil
+	})
+	if err != nil {
+		return -1, err
+	}
+	return newID, nil
+}
+
+// Delete removes the clip of an id with the clips grouped with it. With
+// ripple, the later clips of the timeline move back to close the gap.
+func (g *GES) Delete(id int, ripple bool) error {
+	return g.edit(id, func(e *editor, clip *Clip) error {
+		group := e.toplevel(clip)
+		removed := make(map[int]bool)
+		start, end := group[0].Start, group[0].End()
+		for _, c := range group {
+			removed[c.ID] = true
+			start, end = min(start, c.Start), max(end, c.End())
+			e.touched[c.LayerPriority] = true
+		}
+
+		clips := e.clips[:0]
+		for _, c := range e.clips {
+			if !removed[c.ID] {
+				clips = append(clips, c)
+			}
+		}
+		e.clips = clips
+		e.removeFromGroups(removed)
+
+		if ripple {
+			for _, c := range e.clips {
+				if c.Start >= end {
+					if err := e.shift(c, -int64(end-start)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// editor applies an edit to copies of the clips and groups of a project,
+// which replace the originals once the edit is checked
+type editor struct {
+	project  *Project
+	timeline *Timeline
+	clips    []*Clip // layer by layer, in document order
+	groups   []Group
+
+	layers      map[int]bool // priorities of the layers
+	touched     map[int]bool // priorities of the layers the edit changed
+	changed     map[int]bool // ids of the clips trimmed or slipped
+	transitions map[transitionKey]Clip
+	nextID      int
+}
+
+func newEditor(g *GES) *editor {
+	timeline := &g.Project.Timeline
+	e := &editor{
+		project:     &g.Project,
+		timeline:    timeline,
+		layers:      make(map[int]bool),
+		touched:     make(map[int]bool),
+		changed:     make(map[int]bool),
+		transitions: make(map[transitionKey]Clip),
+		nextID:      timeline.NextClipID(),
+	}
+	for i := range timeline.Layers {
+		layer := &timeline.Layers[i]
+		e.layers[layer.Priority] = true
+		layerTransitions(layer, e.transitions)
+		for _, clip := range layer.Clips {
+			clip.LayerPriority = layer.Priority
+			e.clips = append(e.clips, &clip)
+		}
+	}
+	for _, group := range timeline.AllGroups() {
+		group.Children = append([]GroupChild(nil), group.Children...)
+		e.groups = append(e.groups, group)
+	}
+	return e
+}
+
+// edit applies an edit to the clip of an id, and replaces the clips and
+// groups of the project with the result if it follows the GES rules
+func (g *GES) edit(id int, apply func(e *editor, clip *Clip) error) error {
+	e := newEditor(g)
+	var clip *Clip
+	for _, c := range e.clips {
+		if c.ID == id {
+			clip = c
+			break
+		}
+	}
+	if clip == nil {
+		return fmt.Errorf("no clip of id %d", id)
+	}
+	if clip.TypeName == ClipTypeTransition {
+		return fmt.Errorf("clip %d is a transition, which follows the clips it joins", id)
+	}
+
+	if err := apply(e, clip); err != nil {
+		return err
+	}
+	if err := e.check(); err != nil {
+		return err
+	}
+	e.commit()
+	return nil
+}
+
+// toplevel returns the clips moving with a clip: those of its outermost
+// group, or the clip alone
+func (e *editor) toplevel(clip *Clip) []*Clip {
+	id := clip.ID
+	seen := map[int]bool{id: true}
+	for {
+		parent, ok := e.parent(id)
+		if !ok || seen[parent] {
+			break
+		}
+		id = parent
+		seen[id] = true
+	}
+	if id == clip.ID {
+		return []*Clip{clip}
+	}
+
+	ids := make(map[int]bool)
+	e.groupMembers(id, ids)
+	var clips []*Clip
+	for _, c := range e.clips {
+		if ids[c.ID] && c.TypeName != ClipTypeTransition {
+			clips = append(clips, c)
+		}
+	}
+	return clips
+}
+
+// parent returns the id of the group holding a clip or group
+func (e *editor) parent(id int) (int, bool) {
+	for _, group := range e.groups {
+		for _, child := range group.Children {
+			if child.ID == id {
+				return group.ID, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// groupMembers adds the ids of a group and of everything it holds to ids
+func (e *editor) groupMembers(id int, ids map[int]bool) {
+	if ids[id] {
+		return
+	}
+	ids[id] = true
+	for _, group := range e.groups {
+		if group.ID == id {
+			for _, child := range group.Children {
+				e.groupMembers(child.ID, ids)
+			}
+		}
+	}
+}
+
+// removeFromGroups removes deleted clips from their groups, and the groups
+// left empty
+func (e *editor) removeFromGroups(removed map[int]bool) {
+	for changed := true; changed; {
+		changed = false
+		groups := e.groups[:0]
+		for _, group := range e.groups {
+			children := group.Children[:0]
+			for _, child := range group.Children {
+				if !removed[child.ID] {
+					children = append(children, child)
+				}
+			}
+			group.Children = children
+			if len(children) == 0 {
+				removed[group.ID] = true
+				changed = true
+				continue
+			}
+			groups = append(groups, group)
+		}
+		e.groups = groups
+	}
+}
+
+// move moves a clip and its group to start at position, shifting the later
+// clips with ripple and trimming the clips touching them with slide
+func (e *editor) move(clip *Clip, layerPriority int, mode EditMode, position uint64) error {
+	group := e.toplevel(clip)
+	inGroup := clipSet(group)
+	delta := int64(position) - int64(clip.Start)
+	layerOffset := 0
+	if layerPriority >= 0 {
+		layerOffset = layerPriority - clip.LayerPriority
+	}
+
+	switch mode {
+	case EditNormal, EditTrim:
+	case EditRipple:
+		from := group[0].Start
+		for _, c := range group {
+			from = min(from, c.Start)
+		}
+		for _, c := range e.clips {
+			if !inGroup[c] && c.Start >= from {
+				if err := e.shift(c, delta); err != nil {
+					return err
+				}
+			}
+		}
+	case EditSlide:
+		if layerOffset != 0 {
+			return fmt.Errorf("clip %d can't slide to another layer", clip.ID)
+		}
+		before, after := e.neighbors(group, inGroup, EdgeStart), e.neighbors(group, inGroup, EdgeEnd)
+		for _, n := range before {
+			if err := e.trimEdge(n, EdgeEnd, addTime(n.End(), delta)); err != nil {
+				return err
+			}
+		}
+		for _, n := range after {
+			if err := e.trimEdge(n, EdgeStart, addTime(n.Start, delta)); err != nil {
+				return err
+			}
+		}
+	case EditRoll:
+		return fmt.Errorf("rolling clip %d needs an edge", clip.ID)
+	default:
+		return fmt.Errorf("invalid edit mode %d", mode)
+	}
+
+	for _, c := range group {
+		if err := e.shift(c, delta); err != nil {
+			return err
+		}
+		if layerOffset != 0 {
+			c.LayerPriority += layerOffset
+			e.touched[c.LayerPriority] = true
+		}
+	}
+	return nil
+}
+
+// trim trims an edge of a clip and of the clips of its group sharing that
+// edge, shifting the later clips with ripple and trimming the clips
+// touching them with roll
+func (e *editor) trim(clip *Clip, mode EditMode, edge Edge, position uint64) error {
+	group := e.toplevel(clip)
+	inGroup := clipSet(group)
+	old := edgeTime(clip, edge)
+	var linked []*Clip
+	for _, c := range group {
+		if edgeTime(c, edge) == old {
+			linked = append(linked, c)
+		}
+	}
+
+	switch mode {
+	case EditNormal, EditTrim:
+		for _, c := range linked {
+			if err := e.trimEdge(c, edge, position); err != nil {
+				return err
+			}
+		}
+	case EditRipple:
+		delta := int64(position) - int64(old)
+		from := clip.End()
+		var later []*Clip
+		for _, c := range e.clips {
+			if !inGroup[c] && c.Start >= from {
+				later = append(later, c)
+			}
+		}
+		for _, c := range linked {
+			if err := e.trimEdge(c, edge, position); err != nil {
+				return err
+			}
+			if edge == EdgeStart {
+				// The clip stays in place, starting later in its media
+				if err := e.shift(c, -delta); err != nil {
+					return err
+				}
+			}
+		}
+		if edge == EdgeStart {
+			delta = -delta
+		}
+		for _, c := range later {
+			if err := e.shift(c, delta); err != nil {
+				return err
+			}
+		}
+	case EditRoll:
+		opposite := EdgeStart
+		if edge == EdgeStart {
+			opposite = EdgeEnd
+		}
+		for _, n := range e.neighbors(linked, inGroup, edge) {
+			if err := e.trimEdge(n, opposite, position); err != nil {
+				return err
+			}
+		}
+		for _, c := range linked {
+			if err := e.trimEdge(c, edge, position); err != nil {
+				return err
+			}
+		}
+	case EditSlide:
+		return fmt.Errorf("sliding clip %d moves it, it takes no edge", clip.ID)
+	default:
+		return fmt.Errorf("invalid edit mode %d", mode)
+	}
+	return nil
+}
+
+// neighbors returns the clips outside a group touching an edge of its clips
+// in the same layer and tracks
+func (e *editor) neighbors(clips []*Clip, inGroup map[*Clip]bool, edge Edge) []*Clip {
+	var found []*Clip
+	seen := make(map[*Clip]bool)
+	for _, clip := range clips {
+		for _, c := range e.clips {
+			if inGroup[c] || seen[c] || c.TypeName == ClipTypeTransition ||
+				c.LayerPriority != clip.LayerPriority || c.TrackTypes&clip.TrackTypes == 0 {
+				continue
+			}
+			if (edge == EdgeStart && c.End() == clip.Start) || (edge == EdgeEnd && c.Start == clip.End()) {
+				seen[c] = true
+				found = append(found, c)
+			}
+		}
+	}
+	return found
+}
+
+// shift moves a clip in time
+func (e *editor) shift(clip *Clip, delta int64) error {
+	start := int64(clip.Start) + delta
+	if start < 0 {
+		return fmt.Errorf("clip %d would start before the timeline", clip.ID)
+	}
+	clip.Start = uint64(start)
+	e.touched[clip.LayerPriority] = true
+	return nil
+}
+
+// trimEdge moves an edge of a clip to position. Trimming the start moves
+// the inpoint by as much media as the clip reads in that time.
+func (e *editor) trimEdge(clip *Clip, edge Edge, position uint64) error {
+	if edge == EdgeEnd {
+		if position <= clip.Start {
+			return fmt.Errorf("clip %d can't end before it starts", clip.ID)
+		}
+		clip.Duration = position - clip.Start
+		e.touch(clip)
+		return nil
+	}
+
+	if position >= clip.End() {
+		return fmt.Errorf("clip %d can't start after it ends", clip.ID)
+	}
+	inpoint := int64(clip.Inpoint) + sourceOffset(clip, int64(position)-int64(clip.Start))
+	if inpoint < 0 {
+		return fmt.Errorf("clip %d can't start before the start of its media", clip.ID)
+	}
+	clip.Duration = clip.End() - position
+	clip.Start = position
+	clip.Inpoint = uint64(inpoint)
+	e.touch(clip)
+	return nil
+}
+
+// touch records that a clip was trimmed or slipped
+func (e *editor) touch(clip *Clip) {
+	e.touched[clip.LayerPriority] = true
+	e.changed[clip.ID] = true
+}
+
+// split cuts a clip at position, adding a clip for the part after position
+// right after it
+func (e *editor) split(clip *Clip, position uint64) *Clip {
+	right := *clip
+	right.ID = e.nextID
+	e.nextID++
+	right.Start = position
+	right.Duration = clip.End() - position
+	right.Inpoint = clip.Inpoint + uint64(sourceOffset(clip, int64(position-clip.Start)))
+	right.Sources = append([]Source(nil), clip.Sources...)
+	right.Effects = append([]Effect(nil), clip.Effects...)
+	for i := range right.Effects {
+		right.Effects[i].ClipID = right.ID
+	}
+	if clip.Name() != "" {
+		right.SetName(e.uniqueName(&right))
+	}
+	clip.Duration = position - clip.Start
+	e.touch(clip)
+	e.touch(&right)
+
+	// Transitions into the next clips now follow the new clip
+	for key, tr := range e.transitions {
+		if key.prev == clip.ID {
+			delete(e.transitions, key)
+			e.transitions[transitionKey{right.ID, key.next, key.trackType}] = tr
+		}
+	}
+
+	for i, c := range e.clips {
+		if c == clip {
+			e.clips = append(e.clips[:i+1], append([]*Clip{&right}, e.clips[i+1:]...)...)
+			break
+		}
+	}
+	return &right
+}
+
+// uniqueName names a new clip as GES does, after its type and id, making
+// sure no other clip has the name
+func (e *editor) uniqueName(clip *Clip) string {
+	names := make(map[string]bool)
+	for _, c := range e.clips {
+		names[c.Name()] = true
+	}
+	prefix := strings.ToLower(strings.TrimPrefix(clip.TypeName, "GES"))
+	for n := clip.ID; ; n++ {
+		if name := prefix + strconv.Itoa(n); !names[name] {
+			return name
+		}
+	}
+}
+
+// check reports the first GES rule the edited clips break
+func (e *editor) check() error {
+	byLayer := make(map[int][]*Clip)
+	for _, c := range e.clips {
+		if !e.layers[c.LayerPriority] {
+			return fmt.Errorf("no layer of priority %d", c.LayerPriority)
+		}
+		if e.touched[c.LayerPriority] && c.TypeName != ClipTypeTransition {
+			byLayer[c.LayerPriority] = append(byLayer[c.LayerPriority], c)
+		}
+		if e.changed[c.ID] {
+			if err := e.checkMedia(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	priorities := make([]int, 0, len(byLayer))
+	for priority := range byLayer {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	for _, priority := range priorities {
+		if err := checkOverlaps(byLayer[priority], priority); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkMedia checks that a clip reads no further than the end of its media
+func (e *editor) checkMedia(clip *Clip) error {
+	if clip.TypeName != ClipTypeURI {
+		return nil
+	}
+	asset, ok := e.project.AssetFor(clip)
+	if !ok || asset.IsStillImage() {
+		return nil
+	}
+	duration, ok := asset.Duration()
+	if !ok || duration == gstClockTimeNone {
+		return nil
+	}
+	for _, trackType := range trackTypeFlags(clip.TrackTypes) {
+		if clip.Inpoint+clip.SourceDuration(trackType) > duration {
+			return fmt.Errorf("clip %d would play past the end of its media", clip.ID)
+		}
+	}
+	return nil
+}
+
+// checkOverlaps checks the clips of a layer against the GES overlap rules:
+// no clip may fully overlap another in a track, and no more than two clips
+// may overlap at once
+func checkOverlaps(clips []*Clip, priority int) error {
+	trackTypes := 0
+	for _, c := range clips {
+		trackTypes |= c.TrackTypes
+	}
+	for _, trackType := range trackTypeFlags(trackTypes) {
+		var inTrack []*Clip
+		for _, c := range clips {
+			if c.TrackTypes&trackType != 0 {
+				inTrack = append(inTrack, c)
+			}
+		}
+		sort.SliceStable(inTrack, func(i, j int) bool { return inTrack[i].Start < inTrack[j].Start })
+
+		for i := 1; i < len(inTrack); i++ {
+			prev, next := inTrack[i-1], inTrack[i]
+			if next.Start == prev.Start || next.End() <= prev.End() {
+				return fmt.Errorf("clips %d and %d would fully overlap in layer %d", prev.ID, next.ID, priority)
+			}
+			if i >= 2 && next.Start < inTrack[i-2].End() {
+				return fmt.Errorf("clips %d, %d and %d would overlap in layer %d", inTrack[i-2].ID, prev.ID, next.ID, priority)
+			}
+		}
+	}
+	return nil
+}
+
+// commit replaces the clips and groups of the project with the edited ones
+// and updates the transitions of the layers with auto-transition
+func (e *editor) commit() {
+	timeline := e.timeline
+	for i := range timeline.Layers {
+		layer := &timeline.Layers[i]
+		layer.Clips = layer.Clips[:0:0]
+		for _, c := range e.clips {
+			if c.LayerPriority == layer.Priority {
+				layer.Clips = append(layer.Clips, *c)
+			}
+		}
+		if e.touched[layer.Priority] && timeline.autoTransitions(layer) {
+			updateLayerTransitions(layer, e.transitions, &e.nextID)
+		}
+	}
+
+	if timeline.Groups != nil || len(e.groups) > 0 {
+		timeline.Groups = &Groups{Groups: e.groups}
+	}
+	addCrossfadeAsset(e.project)
+}
+
+// clipSet returns a set of clips
+func clipSet(clips []*Clip) map[*Clip]bool {
+	set := make(map[*Clip]bool, len(clips))
+	for _, c := range clips {
+		set[c] = true
+	}
+	return set
+}
+
+// edgeTime returns the time of an edge of a clip
+func edgeTime(clip *Clip, edge Edge) uint64 {
+	if edge == EdgeEnd {
+		return clip.End()
+	}
+	return clip.Start
+}
+
+// addTime adds a signed delta to a time, stopping at 0
+func addTime(t uint64, delta int64) uint64 {
+	if delta < 0 && uint64(-delta) > t {
+		return 0
+	}
+	return uint64(int64(t) + delta)
+}
+
+// sourceOffset converts a time in the timeline to the time of its media a
+// clip reads in it, following the time effects of its video, or of its
+// first track type
+func sourceOffset(clip *Clip, d int64) int64 {
+	trackType := TrackTypeVideo
+	if clip.TrackTypes&TrackTypeVideo == 0 {
+		trackType = clip.TrackTypes & -clip.TrackTypes
+	}
+	return int64(math.Round(float64(d) * clip.TimeScalar(trackType)))
+}
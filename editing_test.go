@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"testing"
+)
+
+// newEditDocument creates a project with two layers and clips of 10s media
+// at the given start and end times, in seconds, in the first layer
+func newEditDocument(t *testing.T, spans ...[2]uint64) *GES {
+	t.Helper()
+
+	ges := NewDocument()
+	timeline := &ges.Project.Timeline
+	timeline.AddTrack(TrackTypeVideo)
+	timeline.AddTrack(TrackTypeAudio)
+	timeline.AddLayer()
+	timeline.AddLayer()
+	asset := ges.Project.AddAsset("file:///a.mov", ClipTypeURI)
+	asset.Properties = "properties, supported-formats=(int)6, duration=(guint64)10000000000;"
+
+	for i, span := range spans {
+		clip := Clip{
+			AssetID:    asset.ID,
+			TypeName:   ClipTypeURI,
+			TrackTypes: TrackTypeVideo | TrackTypeAudio,
+			Start:      span[0] * GSTSecond,
+			Duration:   (span[1] - span[0]) * GSTSecond,
+		}
+		clip.SetName(string(rune('a' + i)))
+		if _, err := timeline.AddClip(0, clip); err != nil {
+			t.Fatalf("AddClip failed: %v", err)
+		}
+	}
+	return ges
+}
+
+// findClip returns the clip of an id, failing the test if it is missing
+func findClip(t *testing.T, ges *GES, id int) *Clip {
+	t.Helper()
+	clip, _, ok := ges.Project.Timeline.FindClip(id)
+	if !ok {
+		t.Fatalf("Expected clip %d", id)
+	}
+	return clip
+}
+
+// transitions returns the transition clips of a layer
+func transitions(layer *Layer) []Clip {
+	var found []Clip
+	for _, clip := range layer.Clips {
+		if clip.TypeName == ClipTypeTransition {
+			found = append(found, clip)
+		}
+	}
+	return found
+}
+
+func TestEdit_RippleAndRoll(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 8})
+	if err := ges.Edit(0, -1, EditRipple, EdgeEnd, 3*GSTSecond); err != nil {
+		t.Fatalf("Ripple failed: %v", err)
+	}
+	if a, b := findClip(t, ges, 0), findClip(t, ges, 1); a.Duration != 3*GSTSecond || b.Start != 3*GSTSecond {
+		t.Errorf("Expected b to follow the end of a at 3s, got a %d-%d, b %d", a.Start, a.End(), b.Start)
+	}
+
+	// Rippling the start keeps the clip in place
+	if err := ges.Edit(0, -1, EditRipple, EdgeStart, GSTSecond); err != nil {
+		t.Fatalf("Ripple failed: %v", err)
+	}
+	a, b := findClip(t, ges, 0), findClip(t, ges, 1)
+	if a.Start != 0 || a.Inpoint != GSTSecond || a.Duration != 2*GSTSecond || b.Start != 2*GSTSecond {
+		t.Errorf("Expected a 0-2s from 1s and b at 2s, got a %d-%d from %d, b %d", a.Start, a.End(), a.Inpoint, b.Start)
+	}
+
+	if err := ges.Edit(0, -1, EditRoll, EdgeEnd, 3*GSTSecond); err != nil {
+		t.Fatalf("Roll failed: %v", err)
+	}
+	a, b = findClip(t, ges, 0), findClip(t, ges, 1)
+	if a.End() != 3*GSTSecond || b.Start != 3*GSTSecond || b.Inpoint != GSTSecond || b.End() != 6*GSTSecond {
+		t.Errorf("Expected the cut at 3s, got a ending at %d, b %d-%d from %d", a.End(), b.Start, b.End(), b.Inpoint)
+	}
+}
+
+func TestEdit_SlideAndSlip(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 6}, [2]uint64{6, 10})
+	if err := ges.Edit(1, -1, EditSlide, EdgeNone, 5*GSTSecond); err != nil {
+		t.Fatalf("Slide failed: %v", err)
+	}
+	a, b, c := findClip(t, ges, 0), findClip(t, ges, 1), findClip(t, ges, 2)
+	if a.End() != 5*GSTSecond || b.Start != 5*GSTSecond || c.Start != 7*GSTSecond || c.End() != 10*GSTSecond || c.Inpoint != GSTSecond {
+		t.Errorf("Expected b to slide to 5s, got a ending at %d, b at %d, c %d-%d", a.End(), b.Start, c.Start, c.End())
+	}
+
+	if err := ges.Slip(1, 2*GSTSecond); err != nil {
+		t.Fatalf("Slip failed: %v", err)
+	}
+	if b := findClip(t, ges, 1); b.Start != 5*GSTSecond || b.Inpoint != 2*GSTSecond {
+		t.Errorf("Expected b to read from 2s at 5s, got %d at %d", b.Inpoint, b.Start)
+	}
+	if err := ges.Slip(1, -3*GSTSecond); err == nil {
+		t.Error("Expected an error slipping before the start of the media")
+	}
+	if err := ges.Slip(1, 7*GSTSecond); err == nil {
+		t.Error("Expected an error slipping past the end of the media")
+	}
+}
+
+func TestEdit_Rules(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 8})
+	if err := ges.Edit(1, -1, EditNormal, EdgeNone, 0); err == nil {
+		t.Error("Expected an error for a full overlap")
+	}
+	if err := ges.Edit(1, 5, EditNormal, EdgeNone, 0); err == nil {
+		t.Error("Expected an error for a missing layer")
+	}
+	if err := ges.Edit(1, -1, EditTrim, EdgeEnd, 15*GSTSecond); err == nil {
+		t.Error("Expected an error trimming past the end of the media")
+	}
+	if b := findClip(t, ges, 1); b.Start != 4*GSTSecond || b.End() != 8*GSTSecond {
+		t.Errorf("Expected failed edits to leave b at 4-8s, got %d-%d", b.Start, b.End())
+	}
+
+	if err := ges.MoveToLayer(1, 1); err != nil {
+		t.Fatalf("MoveToLayer failed: %v", err)
+	}
+	layers := ges.Project.Timeline.Layers
+	if len(layers[0].Clips) != 1 || len(layers[1].Clips) != 1 || layers[1].Clips[0].LayerPriority != 1 {
+		t.Errorf("Expected b alone in layer 1, got %+v", layers)
+	}
+}
+
+func TestEdit_AutoTransitions(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 8})
+	layer := &ges.Project.Timeline.Layers[0]
+	if err := ges.Edit(1, -1, EditNormal, EdgeNone, 3*GSTSecond); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	trs := transitions(layer)
+	if len(trs) != 2 || trs[0].TrackTypes != TrackTypeAudio || trs[1].TrackTypes != TrackTypeVideo {
+		t.Fatalf("Expected an audio and a video transition, got %+v", trs)
+	}
+	if trs[0].Start != 3*GSTSecond || trs[0].Duration != GSTSecond || trs[0].AssetID != "crossfade" {
+		t.Errorf("Expected a 1s crossfade at 3s, got %+v", trs[0])
+	}
+
+	// Transitions keep their settings when the overlap changes
+	layer.Clips[1].AssetID = "bar-wipe-lr"
+	if err := ges.Edit(1, -1, EditNormal, EdgeNone, 2*GSTSecond); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	resized := transitions(layer)
+	if len(resized) != 2 || resized[0].ID != trs[0].ID || resized[0].AssetID != "bar-wipe-lr" || resized[0].Duration != 2*GSTSecond {
+		t.Errorf("Expected the transitions resized to 2s, got %+v", resized)
+	}
+
+	// and go when it does
+	if err := ges.Edit(1, -1, EditNormal, EdgeNone, 5*GSTSecond); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions, got %+v", trs)
+	}
+}
+
+func TestEdit_Groups(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 8})
+	timeline := &ges.Project.Timeline
+
+	// Linked audio and video clips, as Pitivi saves them
+	video, audio := findClip(t, ges, 0), findClip(t, ges, 1)
+	video.TrackTypes = TrackTypeVideo
+	audio.TrackTypes = TrackTypeAudio
+	audio.Start = 0
+	audio.Duration = video.Duration
+	timeline.Groups = &Groups{Groups: []Group{{ID: 2, Children: []GroupChild{{ID: 0}, {ID: 1}}}}}
+
+	if err := ges.Edit(0, -1, EditNormal, EdgeNone, 2*GSTSecond); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if audio := findClip(t, ges, 1); audio.Start != 2*GSTSecond {
+		t.Errorf("Expected the audio to move with the video, got %d", audio.Start)
+	}
+	if err := ges.Edit(1, -1, EditTrim, EdgeEnd, 5*GSTSecond); err != nil {
+		t.Fatalf("Trim failed: %v", err)
+	}
+	if video := findClip(t, ges, 0); video.End() != 5*GSTSecond {
+		t.Errorf("Expected the video to be trimmed with the audio, got end %d", video.End())
+	}
+
+	id, err := ges.Split(0, 3*GSTSecond)
+	if err != nil {
+		t.Fatalf("Split failed: %v", err)
+	}
+	right := findClip(t, ges, id)
+	if id != 3 || right.Start != 3*GSTSecond || right.Inpoint != GSTSecond || right.Duration != 2*GSTSecond || right.Name() != "uriclip3" {
+		t.Errorf("Expected uriclip3 at 3-5s from 1s, got %+v", right)
+	}
+	groups := timeline.AllGroups()
+	if len(groups) != 2 || groups[1].ID != 5 || len(groups[1].Children) != 2 {
+		t.Errorf("Expected the new clips grouped as 5, got %+v", groups)
+	}
+
+	if err := ges.Delete(3, true); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, _, ok := timeline.FindClip(4); ok {
+		t.Error("Expected the linked audio to be deleted")
+	}
+	if groups := timeline.AllGroups(); len(groups) != 1 {
+		t.Errorf("Expected the empty group to be removed, got %+v", groups)
+	}
+}
+
+func TestEdit_DeleteRipple(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{4, 8})
+	if err := ges.Delete(0, true); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if b := findClip(t, ges, 1); b.Start != 0 {
+		t.Errorf("Expected b to move to 0, got %d", b.Start)
+	}
+	if err := ges.Delete(7, false); err == nil {
+		t.Error("Expected an error for a missing clip")
+	}
+}
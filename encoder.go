@@ -4,9 +4,12 @@
 package xges
 
 import (
-	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/Avalanche-io/gotio/opentime"
@@ -15,8 +18,17 @@ import (
 
 // Encoder writes OTIO timelines as XGES XML
 type Encoder struct {
-	w    io.Writer
-	rate float64
+	w        io.Writer
+	rate     float64
+	opts     EncodeOptions
+	warnings []string
+
+	// XGES tracks of the timeline being converted
+	tracks []Track
+
+	// Format version to write, and whether it is picked from the content
+	version     FormatVersion
+	autoVersion bool
 }
 
 // NewEncoder creates a new XGES encoder
@@ -27,96 +39,908 @@ func NewEncoder(w io.Writer) *Encoder {
 	}
 }
 
-// Encode converts an OTIO Timeline to XGES and writes it
+// SetOptions configures how the following Encode calls convert the timeline
+func (e *Encoder) SetOptions(opts EncodeOptions) {
+	e.opts = opts
+}
+
+// Warnings returns the lossy conversions made by the last Encode call
+func (e *Encoder) Warnings() []string {
+	return e.warnings
+}
+
+// Encode converts an OTIO Timeline to XGES and writes it formatted as GES
+// saves projects. Elements are written to the underlying writer as they are
+// converted, so memory use does not grow with the number of clips: the
+// tracks making up one layer, such as the video and audio of a decoded
+// layer, are converted side by side and their clips merged as they come.
+// Unless a format version is requested, the timeline is converted twice:
+// once to find the version its content needs, then to write it. With the
+// AutoTransitions option the whole document is built before it is written.
 func (e *Encoder) Encode(timeline *gotio.Timeline) error {
+	if e.opts.AutoTransitions {
+		ges, err := e.EncodeDocument(timeline)
+		if err != nil {
+			return err
+		}
+		return WriteDocument(e.w, ges)
+	}
+
+	if err := e.prepare(timeline); err != nil {
+		return err
+	}
+
+	ges, err := e.buildHeader(timeline)
+	if err != nil {
+		return err
+	}
+	if err := e.limitHeaderVersion(ges); err != nil {
+		return err
+	}
+	if e.autoVersion {
+		warnings := len(e.warnings)
+		check := newVersionSink(e, nil)
+		if err := e.convertLayers(timeline, ges.Project.Timeline.Tracks, check); err != nil {
+			return err
+		}
+		// The conversion is repeated below, with the same warnings
+		e.warnings = e.warnings[:warnings]
+		e.version = laterVersion(check.required, headerVersion(ges))
+	}
+	ges.Version = e.version.String()
+	setProjectFormatVersion(ges)
+
+	w := NewWriter(e.w)
+	// GES writes no XML declaration
+	w.startGES(ges)
+	w.startProject(&ges.Project)
+	w.startTimeline(&ges.Project.Timeline)
+	for i := range ges.Project.Timeline.Tracks {
+		w.writeTrack(&ges.Project.Timeline.Tracks[i])
+	}
+	if w.err != nil {
+		return fmt.Errorf("failed to write XGES: %w", w.err)
+	}
+
+	// Convert tracks to layers, writing each clip as soon as it is converted
+	if err := e.convertLayers(timeline, ges.Project.Timeline.Tracks, newVersionSink(e, &streamSink{w: w})); err != nil {
+		return err
+	}
+
+	w.close("timeline")
+	w.close("project")
+	w.close("ges")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write XGES: %w", err)
+	}
+
+	return nil
+}
+
+// EncodeDocument converts a whole OTIO Timeline into an in-memory GES
+// document. The encoder's writer is not used.
+func (e *Encoder) EncodeDocument(timeline *gotio.Timeline) (*GES, error) {
+	if err := e.prepare(timeline); err != nil {
+		return nil, err
+	}
+
+	ges, err := e.buildHeader(timeline)
+	if err != nil {
+		return nil, err
+	}
+	if err := e.limitHeaderVersion(ges); err != nil {
+		return nil, err
+	}
+	sink := newVersionSink(e, &documentSink{timeline: &ges.Project.Timeline})
+	if err := e.convertLayers(timeline, ges.Project.Timeline.Tracks, sink); err != nil {
+		return nil, err
+	}
+	if e.autoVersion {
+		e.version = laterVersion(sink.required, headerVersion(ges))
+	}
+	ges.Version = e.version.String()
+	setProjectFormatVersion(ges)
+	if e.opts.AutoTransitions {
+		if err := e.updateAutoTransitions(ges); err != nil {
+			return nil, err
+		}
+	}
+
+	return ges, nil
+}
+
+// EncodeProject writes a GES project formatted as GES saves projects,
+// applying the encoder's options as Encode does: media URIs are remapped,
+// content the format version can't express is dropped with a warning and
+// transitions are updated with AutoTransitions. The project is modified in
+// place. Rate is not used, as project times are already in nanoseconds.
+func (e *Encoder) EncodeProject(ges *GES) error {
+	if err := e.convertProject(ges); err != nil {
+		return err
+	}
+	return WriteDocument(e.w, ges)
+}
+
+// convertProject applies the encoder's options to a GES project
+func (e *Encoder) convertProject(ges *GES) error {
+	e.warnings = nil
+	if err := e.prepareVersion(); err != nil {
+		return err
+	}
+	remapProject(ges, e.opts.PathMap)
+
+	if err := e.limitHeaderVersion(ges); err != nil {
+		return err
+	}
+	sink := newVersionSink(e, nil)
+	for l := range ges.Project.Timeline.Layers {
+		layer := &ges.Project.Timeline.Layers[l]
+		if err := sink.startLayer(layer); err != nil {
+			return err
+		}
+		for i := range layer.Clips {
+			if err := sink.addClip(&layer.Clips[i]); err != nil {
+				return err
+			}
+		}
+	}
+	if e.autoVersion {
+		e.version = laterVersion(sink.required, headerVersion(ges))
+	}
+	ges.Version = e.version.String()
+	setProjectFormatVersion(ges)
+	if e.opts.AutoTransitions {
+		return e.updateAutoTransitions(ges)
+	}
+	return nil
+}
+
+// remapProject rewrites the media URIs of the assets and clips of a project
+func remapProject(ges *GES, pathMap map[string]string) {
+	if len(pathMap) == 0 {
+		return
+	}
+	for i := range ges.Project.Assets() {
+		asset := &ges.Project.Ressources.Assets[i]
+		if asset.ExtractableTypeName == ClipTypeURI {
+			asset.ID = remapPath(asset.ID, pathMap)
+			if asset.ProxyID != "" {
+				asset.ProxyID = remapPath(asset.ProxyID, pathMap)
+			}
+		}
+	}
+	for l := range ges.Project.Timeline.Layers {
+		layer := &ges.Project.Timeline.Layers[l]
+		for i := range layer.Clips {
+			if layer.Clips[i].TypeName == ClipTypeURI {
+				layer.Clips[i].AssetID = remapPath(layer.Clips[i].AssetID, pathMap)
+			}
+		}
+	}
+}
+
+// updateAutoTransitions adds the transitions GES creates between the
+// overlapping clips of a converted document. Converted transitions joining
+// no overlapping clips are removed, as GES would not keep them.
+func (e *Encoder) updateAutoTransitions(ges *GES) error {
+	var converted []Clip
+	for _, layer := range ges.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			if clip.TypeName == ClipTypeTransition {
+				converted = append(converted, clip)
+			}
+		}
+	}
+
+	ges.UpdateAutoTransitions()
+
+	kept := make(map[int]bool)
+	for _, layer := range ges.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			kept[clip.ID] = true
+		}
+	}
+	for _, clip := range converted {
+		if !kept[clip.ID] {
+			if err := e.warnf("transition %q dropped: it joins no overlapping clips", clip.Name()); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prepare resets the encoder state and determines the frame rate before
+// converting a timeline
+func (e *Encoder) prepare(timeline *gotio.Timeline) error {
+	e.warnings = nil
+	if err := e.prepareVersion(); err != nil {
+		return err
+	}
+
 	// Determine the frame rate from the timeline
-	e.extractFrameRate(timeline)
+	if e.opts.Rate > 0 {
+		e.rate = e.opts.Rate
+	} else {
+		e.extractFrameRate(timeline)
+	}
+
+	// Only video and audio tracks become layers
+	for _, child := range timeline.Tracks().Children() {
+		track, ok := child.(*gotio.Track)
+		if !ok {
+			if err := e.warnf("%T in the timeline stack dropped", child); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := kindTrackType(track.Kind()); !ok {
+			if err := e.warnf("%s track %q dropped", track.Kind(), track.Name()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// prepareVersion uses the requested format version, or the latest until
+// the content tells which one it needs
+func (e *Encoder) prepareVersion() error {
+	e.version, e.autoVersion = LatestFormatVersion, true
+	if e.opts.Version == "" {
+		return nil
+	}
+	version, err := ParseFormatVersion(e.opts.Version)
+	if err != nil {
+		return err
+	}
+	if !version.Supported() {
+		return fmt.Errorf("unsupported XGES format version %s", version)
+	}
+	e.version, e.autoVersion = version, false
+	return nil
+}
+
+// warnf records a lossy conversion. In strict mode it returns an error instead
+func (e *Encoder) warnf(format string, args ...interface{}) error {
+	msg := fmt.Sprintf(format, args...)
+	if e.opts.Strict {
+		return fmt.Errorf("lossy conversion: %s", msg)
+	}
+	e.warnings = append(e.warnings, msg)
+	return nil
+}
 
-	// Create GES structure
+// buildHeader creates the GES structure with its project, timeline and
+// tracks, but no layers
+func (e *Encoder) buildHeader(timeline *gotio.Timeline) (*GES, error) {
 	ges := &GES{
-		Version: "0.3",
 		Project: Project{
 			Properties: "properties;",
-			Metadatas:  e.buildProjectMetadatas(timeline),
-			Timeline: Timeline{
-				Properties: e.buildTimelineProperties(),
-				Metadatas:  e.buildTimelineMetadatas(timeline),
-			},
 		},
 	}
 
-	// Add tracks
-	trackID := 0
-	videoTracks := timeline.VideoTracks()
-	audioTracks := timeline.AudioTracks()
+	var err error
+	if ges.Project.Metadatas, err = e.buildProjectMetadatas(timeline); err != nil {
+		return nil, err
+	}
+	if ges.Project.Timeline.Properties, err = e.buildTimelineProperties(timeline); err != nil {
+		return nil, err
+	}
+	if ges.Project.Timeline.Metadatas, err = e.buildTimelineMetadatas(timeline); err != nil {
+		return nil, err
+	}
+	ges.Project.Timeline.Tracks = e.buildTracks(timeline)
 
-	if len(videoTracks) > 0 {
-		ges.Project.Timeline.Tracks = append(ges.Project.Timeline.Tracks, Track{
-			Caps:       "video/x-raw(ANY)",
-			TrackType:  TrackTypeVideo,
-			TrackID:    trackID,
-			Properties: e.buildVideoTrackProperties(),
-			Metadatas:  "metadatas;",
-		})
-		trackID++
+	return ges, nil
+}
+
+// buildTracks creates the XGES tracks: those listed in the timeline's xges
+// metadata, those OTIO tracks name by id, and a default track for each
+// other type in use. Tracks are sorted by id.
+func (e *Encoder) buildTracks(timeline *gotio.Timeline) []Track {
+	var tracks []Track
+	byID := make(map[int]int)
+	add := func(track Track) {
+		byID[track.TrackID] = len(tracks)
+		tracks = append(tracks, track)
 	}
 
-	if len(audioTracks) > 0 {
-		ges.Project.Timeline.Tracks = append(ges.Project.Timeline.Tracks, Track{
-			Caps:       "audio/x-raw(ANY)",
-			TrackType:  TrackTypeAudio,
-			TrackID:    trackID,
-			Properties: e.buildAudioTrackProperties(),
-			Metadatas:  "metadatas;",
-		})
-		trackID++
+	if list, ok := xgesMetadata(timeline.Metadata())["tracks"].([]interface{}); ok {
+		for _, item := range list {
+			var entry map[string]interface{}
+			switch m := item.(type) {
+			case map[string]interface{}:
+				entry = m
+			case gotio.AnyDictionary:
+				entry = m
+			}
+			id, okID := metadataInt(entry["track-id"])
+			trackType, okType := metadataInt(entry["track-type"])
+			if _, known := trackKind(int(trackType)); !okID || !okType || !known {
+				continue
+			}
+			if _, exists := byID[int(id)]; exists {
+				continue
+			}
+			track := e.defaultTrack(int(trackType), int(id))
+			if caps, ok := entry["caps"].(string); ok && caps != "" {
+				track.Caps = caps
+			}
+			if properties, ok := entry["properties"].(string); ok {
+				track.Properties = e.trackProperties(properties, track.TrackType)
+			}
+			if metadatas, ok := entry["metadatas"].(string); ok {
+				track.Metadatas = metadatas
+			}
+			add(track)
+		}
+	}
+
+	// Tracks named by OTIO tracks, then a default track for each type in use
+	for _, k := range trackKinds {
+		for _, track := range tracksOfKind(timeline, k.kind) {
+			if id, ok := metadataInt(xgesMetadata(track.Metadata())["track-id"]); ok && id >= 0 {
+				if _, exists := byID[int(id)]; !exists {
+					add(e.trackFromOTIO(track, k.trackType, int(id)))
+				}
+			}
+		}
+	}
+	for _, k := range trackKinds {
+		otioTracks := tracksOfKind(timeline, k.kind)
+		if len(otioTracks) == 0 {
+			continue
+		}
+		found := false
+		for _, track := range tracks {
+			found = found || track.TrackType == k.trackType
+		}
+		if !found {
+			id := 0
+			for _, exists := byID[id]; exists; _, exists = byID[id] {
+				id++
+			}
+			add(e.trackFromOTIO(otioTracks[0], k.trackType, id))
+		}
+	}
+
+	sort.SliceStable(tracks, func(i, j int) bool { return tracks[i].TrackID < tracks[j].TrackID })
+	return tracks
+}
+
+// defaultTrack creates an XGES track of one type with the default caps and
+// restrictions
+func (e *Encoder) defaultTrack(trackType, id int) Track {
+	track := Track{
+		Caps:       defaultCaps(trackType),
+		TrackType:  trackType,
+		TrackID:    id,
+		Properties: "properties;",
+		Metadatas:  "metadatas;",
 	}
+	switch trackType {
+	case TrackTypeVideo:
+		track.Properties = e.buildVideoTrackProperties()
+	case TrackTypeAudio:
+		track.Properties = e.buildAudioTrackProperties()
+	}
+	return track
+}
+
+// trackFromOTIO creates the XGES track for an OTIO track that isn't listed in
+// the timeline metadata, keeping the caps of text and custom tracks
+func (e *Encoder) trackFromOTIO(otioTrack *gotio.Track, trackType, id int) Track {
+	track := e.defaultTrack(trackType, id)
+	if trackType == TrackTypeText || trackType == TrackTypeCustom {
+		if caps, ok := xgesMetadata(otioTrack.Metadata())["caps"].(string); ok && caps != "" {
+			track.Caps = caps
+		}
+	}
+	return track
+}
 
-	// Convert tracks to layers
+// trackProperties returns the properties of a track decoded from XGES, with
+// the frame rate of video restriction caps set to the encoding rate
+func (e *Encoder) trackProperties(properties string, trackType int) string {
+	props, err := ParseStructure(properties)
+	if err != nil || trackType != TrackTypeVideo {
+		return properties
+	}
+	caps, ok := props.GetStructure("restriction-caps")
+	if !ok || !caps.Has("framerate") {
+		return properties
+	}
+	num, den := rateFraction(e.rate)
+	caps.SetFraction("framerate", num, den)
+	props.SetString("restriction-caps", strings.TrimSuffix(caps.String(), ";"))
+	return props.String()
+}
+
+// tracksOfType returns the number of XGES tracks of one type
+func (e *Encoder) tracksOfType(trackType int) int {
+	n := 0
+	for _, track := range e.tracks {
+		if track.TrackType == trackType {
+			n++
+		}
+	}
+	return n
+}
+
+// trackFor returns the XGES track an OTIO track of one type goes to: the
+// one named by its xges metadata, else the first track of the type
+func trackFor(track *gotio.Track, trackType int, xgesTracks []Track) *Track {
+	id, named := metadataInt(xgesMetadata(track.Metadata())["track-id"])
+	var first *Track
+	for i := range xgesTracks {
+		if xgesTracks[i].TrackType != trackType {
+			continue
+		}
+		if named && int64(xgesTracks[i].TrackID) == id {
+			return &xgesTracks[i]
+		}
+		if first == nil {
+			first = &xgesTracks[i]
+		}
+	}
+	return first
+}
+
+// convertLayers converts the OTIO tracks to XGES layers, handing layers
+// and clips to the sink in document order. Tracks decoded from the same
+// layer share it again; the others get the lowest free priority, video
+// tracks first.
+func (e *Encoder) convertLayers(timeline *gotio.Timeline, xgesTracks []Track, sink layerSink) error {
 	clipID := 0
-	layerPriority := 0
+	e.tracks = xgesTracks
 
-	// Process video tracks
-	for _, track := range videoTracks {
-		layer, err := e.convertTrackToLayer(track, layerPriority, &clipID, TrackTypeVideo)
-		if err != nil {
+	for _, plan := range e.planLayers(timeline, xgesTracks) {
+		layer := e.buildLayer(plan)
+		if err := sink.startLayer(layer); err != nil {
+			return err
+		}
+
+		// A single track streams its clips; clips of several tracks are
+		// merged so the same clip in each of them is written once
+		if len(plan.tracks) == 1 {
+			if err := e.convertTrackToLayer(plan.tracks[0], layer.Priority, &clipID, plan.xgesTracks[0], sink); err != nil {
+				return err
+			}
+		} else if err := e.convertMergedTracks(plan, layer.Priority, &clipID, sink); err != nil {
+			return err
+		}
+
+		if err := sink.endLayer(layer); err != nil {
 			return err
 		}
-		ges.Project.Timeline.Layers = append(ges.Project.Timeline.Layers, *layer)
-		layerPriority++
 	}
 
-	// Process audio tracks
-	for _, track := range audioTracks {
-		layer, err := e.convertTrackToLayer(track, layerPriority, &clipID, TrackTypeAudio)
-		if err != nil {
+	return nil
+}
+
+// layerPlan lists the OTIO tracks that make up one XGES layer, with the
+// XGES track each of them goes to
+type layerPlan struct {
+	priority   int
+	tracks     []*gotio.Track
+	xgesTracks []*Track
+}
+
+// planLayers groups the tracks of the timeline into layers and numbers the
+// layers contiguously from 0
+func (e *Encoder) planLayers(timeline *gotio.Timeline, xgesTracks []Track) []*layerPlan {
+	type entry struct {
+		track     *gotio.Track
+		xgesTrack *Track
+	}
+	var entries []entry
+	for _, k := range trackKinds {
+		for _, track := range tracksOfKind(timeline, k.kind) {
+			entries = append(entries, entry{track, trackFor(track, k.trackType, xgesTracks)})
+		}
+	}
+
+	// Tracks keep the layer they were decoded from, unless another track
+	// already claimed that layer in the same XGES track
+	plans := make(map[int]*layerPlan)
+	claimed := make(map[[2]int]bool)
+	var unplaced []entry
+	for _, en := range entries {
+		priority, ok := metadataInt(xgesMetadata(en.track.Metadata())["layer-priority"])
+		key := [2]int{int(priority), en.xgesTrack.TrackID}
+		if !ok || priority < 0 || claimed[key] {
+			unplaced = append(unplaced, en)
+			continue
+		}
+		claimed[key] = true
+		plan := plans[int(priority)]
+		if plan == nil {
+			plan = &layerPlan{priority: int(priority)}
+			plans[int(priority)] = plan
+		}
+		plan.tracks = append(plan.tracks, en.track)
+		plan.xgesTracks = append(plan.xgesTracks, en.xgesTrack)
+	}
+
+	next := 0
+	for _, en := range unplaced {
+		for plans[next] != nil {
+			next++
+		}
+		plans[next] = &layerPlan{priority: next, tracks: []*gotio.Track{en.track}, xgesTracks: []*Track{en.xgesTrack}}
+	}
+
+	ordered := make([]*layerPlan, 0, len(plans))
+	for _, plan := range plans {
+		ordered = append(ordered, plan)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].priority < ordered[j].priority })
+	for i, plan := range ordered {
+		plan.priority = i
+	}
+	return ordered
+}
+
+// hasLayerSettings reports whether the xges metadata of a track holds the
+// settings of its layer, which placeholder tracks decoded from unused XGES
+// tracks don't
+func hasLayerSettings(track *gotio.Track) bool {
+	metadata := xgesMetadata(track.Metadata())
+	for _, key := range []string{"auto-transition", "volume", "layer-metadatas"} {
+		if _, ok := metadata[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// buildLayer creates the layer element for a plan from the name, enabled
+// state and xges metadata of its tracks
+func (e *Encoder) buildLayer(plan *layerPlan) *Layer {
+	name := ""
+	autoTransition := true
+	volume := 1.0
+	metadatas := NewStructure("metadatas")
+	deactivated := make(map[int]bool)
+	settingsFound := false
+	for i, track := range plan.tracks {
+		if name == "" {
+			name = track.Name()
+		}
+		if info, ok := TrackInfo(track); ok && !settingsFound && hasLayerSettings(track) {
+			settingsFound = true
+			autoTransition = info.AutoTransition
+			volume = info.Volume
+			// Other fields, such as marker lists, are kept as decoded
+			raw, _ := xgesMetadata(track.Metadata())["layer-metadatas"].(string)
+			if decoded, err := ParseStructure(raw); err == nil {
+				metadatas = decoded
+			}
+		}
+		if !track.Enabled() {
+			deactivated[plan.xgesTracks[i].TrackID] = true
+		}
+	}
+
+	properties := NewStructure("properties")
+	properties.SetBool("auto-transition", autoTransition)
+
+	metadatas.Set("volume", "float", strconv.FormatFloat(volume, 'g', -1, 64))
+	if name != "" {
+		metadatas.SetString(LayerNameField, name)
+	}
+
+	var ids []string
+	for _, xgesTrack := range e.tracks {
+		if deactivated[xgesTrack.TrackID] {
+			ids = append(ids, strconv.Itoa(xgesTrack.TrackID))
+		}
+	}
+
+	return &Layer{
+		Priority:          plan.priority,
+		Properties:        properties.String(),
+		Metadatas:         metadatas.String(),
+		DeactivatedTracks: strings.Join(ids, " "),
+	}
+}
+
+// layerSink receives layers and their clips as the encoder converts them
+type layerSink interface {
+	startLayer(layer *Layer) error
+	addClip(clip *Clip) error
+	endLayer(layer *Layer) error
+}
+
+// streamSink writes layers and clips straight to a Writer
+type streamSink struct {
+	w *Writer
+}
+
+func (s *streamSink) startLayer(layer *Layer) error {
+	s.w.startLayer(layer)
+	if s.w.err != nil {
+		return fmt.Errorf("failed to write XGES layer: %w", s.w.err)
+	}
+	return nil
+}
+
+func (s *streamSink) addClip(clip *Clip) error {
+	s.w.writeClip(clip)
+	if s.w.err != nil {
+		return fmt.Errorf("failed to write XGES clip: %w", s.w.err)
+	}
+	return nil
+}
+
+func (s *streamSink) endLayer(layer *Layer) error {
+	s.w.close("layer")
+	if s.w.err != nil {
+		return fmt.Errorf("failed to write XGES layer: %w", s.w.err)
+	}
+	return nil
+}
+
+// versionSink passes layers and clips on, recording the format version they
+// need and removing what the version being written can't express. Without
+// a next sink it only records the version.
+type versionSink struct {
+	e        *Encoder
+	next     layerSink
+	required FormatVersion
+}
+
+func newVersionSink(e *Encoder, next layerSink) *versionSink {
+	return &versionSink{e: e, next: next, required: DefaultFormatVersion}
+}
+
+func (s *versionSink) startLayer(layer *Layer) error {
+	s.required = laterVersion(s.required, layerVersion(layer))
+	if layer.DeactivatedTracks != "" && s.e.version.Before(versionDeactivatedTracks) {
+		if err := s.e.warnf("layer %d: deactivated tracks dropped, they need format version %s", layer.Priority, versionDeactivatedTracks); err != nil {
 			return err
 		}
-		ges.Project.Timeline.Layers = append(ges.Project.Timeline.Layers, *layer)
-		layerPriority++
+		layer.DeactivatedTracks = ""
 	}
+	if hasMarkers(layer.Metadatas) && s.e.version.Before(versionMarkers) {
+		if err := s.e.warnf("layer %d: markers dropped, they need format version %s", layer.Priority, versionMarkers); err != nil {
+			return err
+		}
+		layer.Metadatas = removeMarkers(layer.Metadatas)
+	}
+	if s.next == nil {
+		return nil
+	}
+	return s.next.startLayer(layer)
+}
 
-	// Write XML with proper formatting
-	output, err := xml.MarshalIndent(ges, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal XGES: %w", err)
+func (s *versionSink) addClip(clip *Clip) error {
+	s.required = laterVersion(s.required, clipVersion(clip))
+	if len(clip.Sources) > 0 && s.e.version.Before(versionSources) {
+		if err := s.e.warnf("clip %d: per-track sources dropped, they need format version %s", clip.ID, versionSources); err != nil {
+			return err
+		}
+		clip.Sources = nil
 	}
+	if hasMarkers(clip.Metadatas) && s.e.version.Before(versionMarkers) {
+		if err := s.e.warnf("clip %d: markers dropped, they need format version %s", clip.ID, versionMarkers); err != nil {
+			return err
+		}
+		clip.Metadatas = removeMarkers(clip.Metadatas)
+	}
+	if s.e.version.Before(versionTimeEffects) {
+		effects := clip.Effects[:0]
+		for _, effect := range clip.Effects {
+			if !IsTimeEffect(&effect) {
+				effects = append(effects, effect)
+			}
+		}
+		if dropped := len(clip.Effects) - len(effects); dropped > 0 {
+			if err := s.e.warnf("clip %d: %d time effects dropped, they need format version %s", clip.ID, dropped, versionTimeEffects); err != nil {
+				return err
+			}
+			clip.Effects = effects
+		}
+	}
+	if s.next == nil {
+		return nil
+	}
+	return s.next.addClip(clip)
+}
 
-	// Write XML declaration
-	if _, err := e.w.Write([]byte(xml.Header)); err != nil {
-		return err
+func (s *versionSink) endLayer(layer *Layer) error {
+	if s.next == nil {
+		return nil
 	}
+	return s.next.endLayer(layer)
+}
 
-	// Write the XML content
-	if _, err := e.w.Write(output); err != nil {
-		return err
+// convertMergedTracks converts the tracks of a layer side by side. The
+// clips starting first in any track are merged and handed to the sink once
+// every track has moved past their start, so the layer is never held whole.
+func (e *Encoder) convertMergedTracks(plan *layerPlan, priority int, clipID *int, sink layerSink) error {
+	// Conversion ids only name clips until they are renumbered in order
+	converted := *clipID
+	type head struct {
+		next func() (*Clip, error, bool)
+		clip *Clip
+	}
+	heads := make([]*head, len(plan.tracks))
+	for i, track := range plan.tracks {
+		next, stop := iter.Pull2(func(yield func(*Clip, error) bool) {
+			err := e.convertTrackToLayer(track, priority, &converted, plan.xgesTracks[i], &pullSink{yield: yield})
+			if err != nil && !errors.Is(err, errPullStopped) {
+				yield(nil, err)
+			}
+		})
+		defer stop()
+		heads[i] = &head{next: next}
+	}
+	advance := func(h *head) error {
+		clip, err, ok := h.next()
+		if ok && err != nil {
+			return err
+		}
+		h.clip = clip
+		return nil
+	}
+	for _, h := range heads {
+		if err := advance(h); err != nil {
+			return err
+		}
 	}
 
-	// Write final newline
-	if _, err := e.w.Write([]byte("\n")); err != nil {
-		return err
+	for {
+		found := false
+		var start uint64
+		for _, h := range heads {
+			if h.clip != nil && (!found || h.clip.Start < start) {
+				start, found = h.clip.Start, true
+			}
+		}
+		if !found {
+			return nil
+		}
+
+		// Tracks come in order, as mergeTrackClips expects
+		var group []*Clip
+		for _, h := range heads {
+			for h.clip != nil && h.clip.Start == start {
+				group = append(group, h.clip)
+				if err := advance(h); err != nil {
+					return err
+				}
+			}
+		}
+		for _, clip := range mergeTrackClips(group) {
+			e.renumberClip(clip, *clipID)
+			*clipID++
+			if err := sink.addClip(clip); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// errPullStopped ends the conversion of a track nobody pulls clips from
+var errPullStopped = errors.New("track conversion stopped")
+
+// pullSink hands the clips of one track to the loop pulling them
+type pullSink struct {
+	yield func(*Clip, error) bool
+}
+
+func (s *pullSink) startLayer(layer *Layer) error { return nil }
+
+func (s *pullSink) addClip(clip *Clip) error {
+	if !s.yield(clip, nil) {
+		return errPullStopped
+	}
+	return nil
+}
+
+func (s *pullSink) endLayer(layer *Layer) error { return nil }
+
+// mergeTrackClips merges the clips converted from the tracks of one layer.
+// A clip found in several tracks, such as the video and audio of a file,
+// becomes one clip in all of them. Clips are sorted by start.
+func mergeTrackClips(clips []*Clip) []*Clip {
+	sort.SliceStable(clips, func(i, j int) bool { return clips[i].Start < clips[j].Start })
+
+	var merged []*Clip
+	for _, clip := range clips {
+		found := false
+		for i := len(merged) - 1; i >= 0 && merged[i].Start == clip.Start; i-- {
+			if canMergeClips(merged[i], clip) {
+				merged[i].TrackTypes |= clip.TrackTypes
+				merged[i].Sources = append(merged[i].Sources, clip.Sources...)
+				merged[i].Effects = append(merged[i].Effects, clip.Effects...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, clip)
+		}
+	}
+	return merged
+}
+
+// canMergeClips reports whether two clips are the same clip in different
+// tracks
+func canMergeClips(a, b *Clip) bool {
+	if a.AssetID != b.AssetID || a.TypeName != b.TypeName || a.Start != b.Start || a.Duration != b.Duration ||
+		a.Inpoint != b.Inpoint || mergeKey(a.Properties) != mergeKey(b.Properties) || a.ChildrenProperties != b.ChildrenProperties ||
+		a.Metadatas != b.Metadatas {
+		return false
+	}
+	if a.TrackTypes&b.TrackTypes == 0 {
+		return true
+	}
+
+	// In tracks of the same type, sources must tell the tracks apart
+	if len(a.Sources) == 0 || len(b.Sources) == 0 {
+		return false
+	}
+	for _, sa := range a.Sources {
+		for _, sb := range b.Sources {
+			if sa.TrackID == sb.TrackID {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mergeKey returns the properties of a clip without a generated name, so
+// an unnamed clip converted in several tracks compares equal
+func mergeKey(properties string) string {
+	props, err := ParseStructure(properties)
+	if err != nil {
+		return properties
+	}
+	if name, ok := props.GetString("name"); ok && isGeneratedName(name) {
+		props.Remove("name")
+	}
+	return props.String()
+}
+
+// renumberClip changes the id of a merged clip and of its effects, along
+// with the name the encoder generated from the id
+func (e *Encoder) renumberClip(clip *Clip, id int) {
+	if props, err := ParseStructure(clip.Properties); err == nil {
+		name, _ := props.GetString("name")
+		for _, prefix := range []string{"clip", "transition"} {
+			if name == fmt.Sprintf("%s%d", prefix, clip.ID) {
+				clip.Properties = strings.Replace(clip.Properties, name, fmt.Sprintf("%s%d", prefix, id), 1)
+			}
+		}
 	}
+	clip.ID = id
+	for i := range clip.Effects {
+		clip.Effects[i].ClipID = id
+	}
+}
+
+// documentSink collects layers and clips into an in-memory timeline
+type documentSink struct {
+	timeline *Timeline
+}
+
+func (s *documentSink) startLayer(layer *Layer) error {
+	s.timeline.Layers = append(s.timeline.Layers, *layer)
+	return nil
+}
+
+func (s *documentSink) addClip(clip *Clip) error {
+	layer := &s.timeline.Layers[len(s.timeline.Layers)-1]
+	layer.Clips = append(layer.Clips, *clip)
+	return nil
+}
 
+func (s *documentSink) endLayer(layer *Layer) error {
 	return nil
 }
 
@@ -149,29 +973,100 @@ func (e *Encoder) extractFrameRate(timeline *gotio.Timeline) {
 	}
 }
 
-// buildProjectMetadatas creates project metadata string
-func (e *Encoder) buildProjectMetadatas(timeline *gotio.Timeline) string {
-	if timeline.Name() == "" {
-		return "metadatas;"
+// buildProjectMetadatas creates the project metadatas from the timeline's
+// xges metadata, with the timeline name
+func (e *Encoder) buildProjectMetadatas(timeline *gotio.Timeline) (string, error) {
+	metadata := xgesMetadata(timeline.Metadata())
+	raw, _ := metadata["raw-project-metadatas"].(string)
+	metadatas, err := e.metadataStructure("project", "metadatas", raw, metadataMap(metadata["project-metadatas"]))
+	if err != nil {
+		return "", err
+	}
+	if timeline.Name() != "" {
+		metadatas.SetString("name", timeline.Name())
+	} else {
+		metadatas.Remove("name")
 	}
+	return metadatas.String(), nil
+}
 
-	escapedName := e.escapeGstString(timeline.Name())
-	return fmt.Sprintf(`metadatas, name=(string)"%s";`, escapedName)
+// limitHeaderVersion removes the markers of the project, timeline and
+// tracks when the requested format version can't express them
+func (e *Encoder) limitHeaderVersion(ges *GES) error {
+	if e.autoVersion || !e.version.Before(versionMarkers) {
+		return nil
+	}
+	for _, metadatas := range headerMetadatas(ges) {
+		if hasMarkers(*metadatas) {
+			if err := e.warnf("project: markers dropped, they need format version %s", versionMarkers); err != nil {
+				return err
+			}
+			*metadatas = removeMarkers(*metadatas)
+		}
+	}
+	return nil
+}
+
+// setProjectFormatVersion updates the format-version project metadatas
+// field, which GES keeps next to the version of the document
+func setProjectFormatVersion(ges *GES) {
+	metadatas, err := ParseStructure(ges.Project.Metadatas)
+	if err != nil || !metadatas.Has("format-version") {
+		return
+	}
+	metadatas.SetString("format-version", ges.Version)
+	ges.Project.Metadatas = metadatas.String()
 }
 
-// buildTimelineProperties creates timeline properties string
-func (e *Encoder) buildTimelineProperties() string {
-	return "properties, auto-transition=(boolean)true;"
+// buildTimelineProperties creates the timeline properties from the
+// timeline's xges metadata. Auto-transition is turned on unless the
+// properties were decoded from XGES, where a missing one means false.
+func (e *Encoder) buildTimelineProperties(timeline *gotio.Timeline) (string, error) {
+	metadata := xgesMetadata(timeline.Metadata())
+	raw, _ := metadata["raw-properties"].(string)
+	properties, err := e.metadataStructure("timeline", "properties", raw, metadataMap(metadata["properties"]))
+	if err != nil {
+		return "", err
+	}
+	if raw == "" && !properties.Has("auto-transition") {
+		properties.SetBool("auto-transition", true)
+	}
+	return properties.String(), nil
 }
 
-// buildTimelineMetadatas creates timeline metadata string
-func (e *Encoder) buildTimelineMetadatas(timeline *gotio.Timeline) string {
-	parts := []string{"metadatas"}
+// buildTimelineMetadatas creates the timeline metadatas from the timeline's
+// xges metadata, with the duration and frame rate of the encoded timeline
+func (e *Encoder) buildTimelineMetadatas(timeline *gotio.Timeline) (string, error) {
+	metadata := xgesMetadata(timeline.Metadata())
+	raw, _ := metadata["raw-metadatas"].(string)
+	metadatas, err := e.metadataStructure("timeline", "metadatas", raw, metadataMap(metadata["metadatas"]))
+	if err != nil {
+		return "", err
+	}
 
-	// Add framerate
-	parts = append(parts, fmt.Sprintf("framerate=(fraction)%d/1", int(e.rate)))
+	// The declared duration is kept only for documents that had one
+	if metadatas.Has("duration") {
+		var duration uint64
+		if d, err := timeline.Duration(); err == nil {
+			duration = e.toNanoseconds(d)
+		}
+		metadatas.SetUint64("duration", duration)
+	}
+	num, den := rateFraction(e.rate)
+	metadatas.SetFraction("framerate", num, den)
+	return metadatas.String(), nil
+}
 
-	return strings.Join(parts, ", ") + ";"
+// metadataStructure builds a structure of an element from typed metadata
+// values, dropping values without a structure equivalent
+func (e *Encoder) metadataStructure(element, name, raw string, values map[string]interface{}) (*Structure, error) {
+	s, unsupported := structureFromMetadata(name, raw, values)
+	for _, field := range unsupported {
+		if err := e.warnf("%s %s field %q dropped", element, name, field); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
 }
 
 // buildVideoTrackProperties creates video track properties
@@ -187,15 +1082,9 @@ func (e *Encoder) buildAudioTrackProperties() string {
 	return `properties, restriction-caps=(string)"audio/x-raw\,\ rate\=\(int\)48000\,\ channels\=\(int\)2", mixing=(boolean)true;`
 }
 
-// convertTrackToLayer converts an OTIO track to an XGES layer
-func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *int, trackType int) (*Layer, error) {
-	layer := &Layer{
-		Priority:   priority,
-		Properties: "properties, auto-transition=(boolean)true;",
-		Metadatas:  "metadatas, volume=(float)1;",
-		Clips:      []Clip{},
-	}
-
+// convertTrackToLayer converts the items of an OTIO track into clips of the
+// layer with the given priority, passing each clip to the sink
+func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *int, xgesTrack *Track, sink layerSink) error {
 	var currentTime uint64 = 0
 
 	for _, child := range track.Children() {
@@ -203,7 +1092,7 @@ func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *
 		if _, isGap := child.(*gotio.Gap); isGap {
 			dur, err := child.Duration()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			currentTime += e.toNanoseconds(dur)
 			continue
@@ -211,16 +1100,18 @@ func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *
 
 		// Convert clip
 		if clip, isClip := child.(*gotio.Clip); isClip {
-			xgesClip, err := e.convertClip(clip, currentTime, priority, trackType, *clipID)
+			xgesClip, err := e.convertClip(clip, currentTime, priority, xgesTrack, *clipID)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if err := sink.addClip(xgesClip); err != nil {
+				return err
 			}
-			layer.Clips = append(layer.Clips, *xgesClip)
 			*clipID++
 
 			dur, err := clip.Duration()
 			if err != nil {
-				return nil, err
+				return err
 			}
 			currentTime += e.toNanoseconds(dur)
 			continue
@@ -228,11 +1119,13 @@ func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *
 
 		// Convert transition
 		if transition, isTrans := child.(*gotio.Transition); isTrans {
-			xgesClip, err := e.convertTransition(transition, currentTime, priority, trackType, *clipID)
+			xgesClip, err := e.convertTransition(transition, currentTime, priority, xgesTrack.TrackType, *clipID)
 			if err != nil {
-				return nil, err
+				return err
+			}
+			if err := sink.addClip(xgesClip); err != nil {
+				return err
 			}
-			layer.Clips = append(layer.Clips, *xgesClip)
 			*clipID++
 
 			// Transitions overlap, so adjust time
@@ -240,13 +1133,19 @@ func (e *Encoder) convertTrackToLayer(track *gotio.Track, priority int, clipID *
 			currentTime += e.toNanoseconds(dur)
 			continue
 		}
+
+		if err := e.warnf("%T in track %q dropped", child, track.Name()); err != nil {
+			return err
+		}
 	}
 
-	return layer, nil
+	return nil
 }
 
 // convertClip converts an OTIO Clip to an XGES Clip
-func (e *Encoder) convertClip(clip *gotio.Clip, startTime uint64, priority int, trackType int, id int) (*Clip, error) {
+func (e *Encoder) convertClip(clip *gotio.Clip, startTime uint64, priority int, xgesTrack *Track, id int) (*Clip, error) {
+	trackType := xgesTrack.TrackType
+
 	duration, err := clip.Duration()
 	if err != nil {
 		return nil, err
@@ -266,23 +1165,42 @@ func (e *Encoder) convertClip(clip *gotio.Clip, startTime uint64, priority int,
 	// Determine clip type and asset ID based on media reference
 	var assetID, typeName string
 	var childrenProps string
+	isImage := false
 
 	if ref := clip.MediaReference(); ref != nil {
 		switch mediaRef := ref.(type) {
 		case *gotio.ExternalReference:
 			// URI clip
-			assetID = mediaRef.TargetURL()
+			assetID = remapPath(mediaRef.TargetURL(), e.opts.PathMap)
 			typeName = ClipTypeURI
 			if assetID == "" {
+				if err := e.warnf("clip %q has an empty target URL", name); err != nil {
+					return nil, err
+				}
 				assetID = "file:///missing"
 			}
 
+			// GES must not look for a video stream in a still image
+			if isStillImageReference(mediaRef) {
+				isImage = true
+				inpoint = 0
+			}
+
+		case *gotio.ImageSequenceReference:
+			// Image sequence played through an imagesequence:// URI
+			typeName = ClipTypeURI
+			assetID, err = e.imageSequenceURI(mediaRef, name)
+			if err != nil {
+				return nil, err
+			}
+			inpoint = e.imageSequenceInpoint(mediaRef, clip)
+
 		case *gotio.GeneratorReference:
 			// Check if this is a title clip
 			if mediaRef.GeneratorKind() == "title" {
 				typeName = ClipTypeTitle
 				assetID = ClipTypeTitle
-				childrenProps = e.extractTitleProperties(clip)
+				childrenProps = clipChildrenProperties(clip, true)
 			} else {
 				// Test pattern/generator clip
 				typeName = ClipTypeTest
@@ -294,18 +1212,33 @@ func (e *Encoder) convertClip(clip *gotio.Clip, startTime uint64, priority int,
 
 		default:
 			// Fallback to URI clip
+			if err := e.warnf("clip %q: unsupported %T replaced by a missing asset", name, ref); err != nil {
+				return nil, err
+			}
 			assetID = "file:///missing"
 			typeName = ClipTypeURI
 		}
 	} else {
 		// No media reference - default to URI clip
+		if err := e.warnf("clip %q has no media reference", name); err != nil {
+			return nil, err
+		}
 		assetID = "file:///missing"
 		typeName = ClipTypeURI
 	}
 
-	// Extract children-properties from metadata if present
+	effects, err := e.convertTimeWarps(clip, name, xgesTrack, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(clip.Markers()) > 0 {
+		if err := e.warnf("clip %q: %d markers dropped", name, len(clip.Markers())); err != nil {
+			return nil, err
+		}
+	}
+
 	if childrenProps == "" {
-		childrenProps = e.extractChildrenProperties(clip)
+		childrenProps = clipChildrenProperties(clip, false)
 	}
 
 	xgesClip := &Clip{
@@ -318,59 +1251,81 @@ func (e *Encoder) convertClip(clip *gotio.Clip, startTime uint64, priority int,
 		Duration:      e.toNanoseconds(duration),
 		Inpoint:       inpoint,
 		Rate:          0,
-		Properties:    e.buildClipProperties(name),
+		Properties:    e.buildClipProperties(name, !clip.Enabled(), isImage),
 	}
 
 	if childrenProps != "" {
 		xgesClip.ChildrenProperties = childrenProps
 	}
+	if xgesClip.Metadatas, err = e.buildClipMetadatas(clip, name); err != nil {
+		return nil, err
+	}
+	xgesClip.Effects = effects
+	if source := clipSource(clip); source != nil {
+		xgesClip.Sources = []Source{sourceFromMetadata(source, trackType, xgesTrack.TrackID)}
+	} else if e.tracksOfType(trackType) > 1 {
+		// An empty source keeps the clip out of the other tracks of its type
+		xgesClip.Sources = []Source{{TrackID: xgesTrack.TrackID}}
+	}
 
 	return xgesClip, nil
 }
 
-// extractTitleProperties extracts title text and builds children-properties
-func (e *Encoder) extractTitleProperties(clip *gotio.Clip) string {
-	metadata := clip.Metadata()
-	if metadata == nil {
-		return ""
-	}
-
-	xgesMetadata, ok := metadata["xges"].(map[string]interface{})
-	if !ok {
-		return ""
+// buildClipMetadatas creates the clip metadatas from the "metadatas" map of
+// the clip's xges metadata, followed by the OTIO metadata of the clip in the
+// reserved namespace. Clips without either get no metadatas.
+func (e *Encoder) buildClipMetadatas(clip *gotio.Clip, name string) (string, error) {
+	metadata := xgesMetadata(clip.Metadata())
+	raw, _ := metadata["raw-metadatas"].(string)
+	metadatas, err := e.metadataStructure(fmt.Sprintf("clip %q", name), "metadatas", raw, metadataMap(metadata["metadatas"]))
+	if err != nil {
+		return "", err
 	}
-
-	// Check for existing children-properties
-	if childProps, ok := xgesMetadata["children-properties"].(string); ok {
-		return childProps
+	for _, key := range setOTIOMetadatas(metadatas, clip.Metadata()) {
+		if err := e.warnf("clip %q: metadata %q dropped", name, key); err != nil {
+			return "", err
+		}
 	}
 
-	// Build from text metadata
-	if text, ok := xgesMetadata["text"].(string); ok {
-		escapedText := e.escapeGstString(text)
-		return fmt.Sprintf(`properties, text=(string)"%s";`, escapedText)
+	if len(metadatas.Fields) == 0 && raw == "" {
+		return "", nil
 	}
-
-	return ""
+	return metadatas.String(), nil
 }
 
-// extractChildrenProperties extracts children-properties from clip metadata
-func (e *Encoder) extractChildrenProperties(clip *gotio.Clip) string {
-	metadata := clip.Metadata()
-	if metadata == nil {
-		return ""
-	}
+// convertTimeWarps converts the time warps of a clip to GES time effects in
+// one track type. Other effects are dropped.
+func (e *Encoder) convertTimeWarps(clip *gotio.Clip, name string, xgesTrack *Track, id int) ([]Effect, error) {
+	var effects []Effect
+	dropped := 0
+	for _, effect := range clip.Effects() {
+		var scalar float64
+		switch warp := effect.(type) {
+		case *gotio.FreezeFrame:
+			scalar = 0
+		case *gotio.LinearTimeWarp:
+			scalar = warp.TimeScalar()
+		default:
+			dropped++
+			continue
+		}
 
-	xgesMetadata, ok := metadata["xges"].(map[string]interface{})
-	if !ok {
-		return ""
+		xgesEffect, ok := timeEffectFor(scalar, xgesTrack.TrackType)
+		if !ok {
+			dropped++
+			continue
+		}
+		xgesEffect.ClipID = id
+		xgesEffect.TrackID = xgesTrack.TrackID
+		effects = append(effects, xgesEffect)
 	}
 
-	if childProps, ok := xgesMetadata["children-properties"].(string); ok {
-		return childProps
+	if dropped > 0 {
+		if err := e.warnf("clip %q: %d effects dropped", name, dropped); err != nil {
+			return nil, err
+		}
 	}
-
-	return ""
+	return effects, nil
 }
 
 // convertTransition converts an OTIO Transition to an XGES Clip
@@ -386,15 +1341,7 @@ func (e *Encoder) convertTransition(transition *gotio.Transition, startTime uint
 	}
 
 	// Extract children-properties from metadata if present
-	childrenProps := ""
-	metadata := transition.Metadata()
-	if metadata != nil {
-		if xgesMetadata, ok := metadata["xges"].(map[string]interface{}); ok {
-			if childProps, ok := xgesMetadata["children-properties"].(string); ok {
-				childrenProps = childProps
-			}
-		}
-	}
+	childrenProps, _ := xgesMetadata(transition.Metadata())["children-properties"].(string)
 
 	xgesClip := &Clip{
 		ID:            id,
@@ -406,7 +1353,7 @@ func (e *Encoder) convertTransition(transition *gotio.Transition, startTime uint
 		Duration:      e.toNanoseconds(duration),
 		Inpoint:       0,
 		Rate:          0,
-		Properties:    e.buildClipProperties(name),
+		Properties:    e.buildClipProperties(name, false, false),
 	}
 
 	if childrenProps != "" {
@@ -439,9 +1386,62 @@ func (e *Encoder) reverseMapTransitionType(transitionType gotio.TransitionType)
 }
 
 // buildClipProperties creates clip properties string
-func (e *Encoder) buildClipProperties(name string) string {
+func (e *Encoder) buildClipProperties(name string, mute, isImage bool) string {
 	escapedName := e.escapeGstString(name)
-	return fmt.Sprintf(`properties, name=(string)"%s", mute=(boolean)false, is-image=(boolean)false;`, escapedName)
+	return fmt.Sprintf(`properties, name=(string)"%s", mute=(boolean)%t, is-image=(boolean)%t;`, escapedName, mute, isImage)
+}
+
+// imageSequenceURI builds the imagesequence:// URI of an OTIO image sequence
+func (e *Encoder) imageSequenceURI(ref *gotio.ImageSequenceReference, name string) (string, error) {
+	if ref.FrameStep() > 1 {
+		if err := e.warnf("clip %q: image sequence frame step %d dropped", name, ref.FrameStep()); err != nil {
+			return "", err
+		}
+	}
+
+	seq := &ImageSequence{
+		URLBase:    remapPath(ref.TargetURLBase(), e.opts.PathMap),
+		Prefix:     ref.NamePrefix(),
+		Suffix:     ref.NameSuffix(),
+		Padding:    ref.FrameZeroPadding(),
+		StartFrame: ref.StartFrame(),
+		EndFrame:   -1,
+		Rate:       ref.Rate(),
+	}
+	if ref.AvailableRange() != nil {
+		seq.EndFrame = ref.EndFrame()
+	}
+	return seq.String(), nil
+}
+
+// imageSequenceInpoint returns the GES inpoint of a clip of an image
+// sequence, which counts from the first frame of the sequence
+func (e *Encoder) imageSequenceInpoint(ref *gotio.ImageSequenceReference, clip *gotio.Clip) uint64 {
+	if clip.SourceRange() == nil {
+		return 0
+	}
+	rate := ref.Rate()
+	if rate <= 0 {
+		rate = e.rate
+	}
+	start := clip.SourceRange().StartTime()
+	first := opentime.NewRationalTime(float64(ref.StartFrame()), rate)
+	if ref.AvailableRange() != nil {
+		first = ref.AvailableRange().StartTime()
+	}
+	if start.ToSeconds() <= first.ToSeconds() {
+		return 0
+	}
+	return e.toNanoseconds(start.Sub(first))
+}
+
+// isStillImageReference reports whether a reference points to a still
+// image, by its xges metadata or by the file format
+func isStillImageReference(ref *gotio.ExternalReference) bool {
+	if image, ok := metadataBool(xgesMetadata(ref.Metadata())["still-image"]); ok {
+		return image
+	}
+	return IsStillImageURI(ref.TargetURL())
 }
 
 // escapeGstString escapes strings for GStreamer structures
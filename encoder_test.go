@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+	"github.com/Avalanche-io/gotio/opentime"
+)
+
+// benchmarkTimeline builds a timeline with the given number of clips split
+// over one video and one audio track
+func benchmarkTimeline(clips int) *gotio.Timeline {
+	timeline := gotio.NewTimeline("Benchmark", nil, nil)
+	videoTrack := gotio.NewTrack("V1", nil, gotio.TrackKindVideo, nil, nil)
+	audioTrack := gotio.NewTrack("A1", nil, gotio.TrackKindAudio, nil, nil)
+
+	rate := 25.0
+	for i := 0; i < clips; i++ {
+		ref := gotio.NewExternalReference("", fmt.Sprintf("file:///media/shot%06d.mov", i), nil, nil)
+		sourceRange := opentime.NewTimeRange(
+			opentime.NewRationalTime(float64(i%100), rate),
+			opentime.NewRationalTime(48, rate),
+		)
+		clip := gotio.NewClip(fmt.Sprintf("shot%06d", i), ref, &sourceRange, nil, nil, nil, "", nil)
+		if i%2 == 0 {
+			videoTrack.AppendChild(clip)
+		} else {
+			audioTrack.AppendChild(clip)
+		}
+	}
+
+	timeline.Tracks().AppendChild(videoTrack)
+	timeline.Tracks().AppendChild(audioTrack)
+	return timeline
+}
+
+// benchmarkAVTimeline builds a timeline with the given number of clips in
+// both a video and an audio track of the same layer, as decoded from GES
+func benchmarkAVTimeline(clips int) *gotio.Timeline {
+	timeline := gotio.NewTimeline("Benchmark", nil, nil)
+	layer := func() gotio.AnyDictionary {
+		return gotio.AnyDictionary{"xges": map[string]interface{}{"layer-priority": 0}}
+	}
+	videoTrack := gotio.NewTrack("V1", nil, gotio.TrackKindVideo, nil, layer())
+	audioTrack := gotio.NewTrack("A1", nil, gotio.TrackKindAudio, nil, layer())
+
+	rate := 25.0
+	for i := 0; i < clips; i++ {
+		sourceRange := opentime.NewTimeRange(
+			opentime.NewRationalTime(float64(i%100), rate),
+			opentime.NewRationalTime(48, rate),
+		)
+		for _, track := range []*gotio.Track{videoTrack, audioTrack} {
+			ref := gotio.NewExternalReference("", fmt.Sprintf("file:///media/shot%06d.mov", i), nil, nil)
+			track.AppendChild(gotio.NewClip(fmt.Sprintf("shot%06d", i), ref, &sourceRange, nil, nil, nil, "", nil))
+		}
+	}
+
+	timeline.Tracks().AppendChild(videoTrack)
+	timeline.Tracks().AppendChild(audioTrack)
+	return timeline
+}
+
+func TestEncoder_StreamingMergesTracks(t *testing.T) {
+	timeline := benchmarkAVTimeline(10)
+
+	var streamed bytes.Buffer
+	if err := NewEncoder(&streamed).Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	ges, err := ParseDocument(&streamed)
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	layers := ges.Project.Timeline.Layers
+	if len(layers) != 1 || len(layers[0].Clips) != 10 {
+		t.Fatalf("Expected 10 clips in one layer, got %+v", layers)
+	}
+	for i, clip := range layers[0].Clips {
+		if clip.ID != i || clip.TrackTypes != TrackTypeAudio|TrackTypeVideo || clip.Name() != fmt.Sprintf("shot%06d", i) {
+			t.Errorf("Expected shot%06d as audio and video clip %d, got %+v", i, i, clip)
+		}
+	}
+}
+
+func TestEncoder_StreamingMatchesDocument(t *testing.T) {
+	timeline := benchmarkTimeline(10)
+
+	var streamed bytes.Buffer
+	if err := NewEncoder(&streamed).Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	var written bytes.Buffer
+	if err := NewWriter(&written).WriteDocument(ges); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+
+	if streamed.String() != written.String() {
+		t.Errorf("Streamed output differs from the written document:\n%s\nvs\n%s", streamed.String(), written.String())
+	}
+
+	if len(ges.Project.Timeline.Layers) != 2 {
+		t.Fatalf("Expected 2 layers, got %d", len(ges.Project.Timeline.Layers))
+	}
+	if n := len(ges.Project.Timeline.Layers[0].Clips); n != 5 {
+		t.Errorf("Expected 5 clips on the video layer, got %d", n)
+	}
+}
+
+// errWriter fails every write after the first n bytes
+type errWriter struct {
+	n int
+}
+
+func (w *errWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		written := w.n
+		w.n = 0
+		return written, io.ErrShortWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestEncoder_WriteError(t *testing.T) {
+	timeline := benchmarkTimeline(1000)
+
+	for _, n := range []int{0, 100, 10000} {
+		err := NewEncoder(&errWriter{n: n}).Encode(timeline)
+		if err == nil {
+			t.Errorf("Expected error when writer fails after %d bytes", n)
+		} else if !strings.Contains(err.Error(), io.ErrShortWrite.Error()) {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+}
+
+// BenchmarkEncode100kClipsMultiTrack encodes layers made of a video and an
+// audio track, whose clips are merged as they stream
+func BenchmarkEncode100kClipsMultiTrack(b *testing.B) {
+	timeline := benchmarkAVTimeline(100000)
+	b.ReportAllocs()
+	for b.Loop() {
+		if err := NewEncoder(io.Discard).Encode(timeline); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncode100kClips(b *testing.B) {
+	timeline := benchmarkTimeline(100000)
+
+	b.Run("streaming", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			if err := NewEncoder(io.Discard).Encode(timeline); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	// The previous implementation: build the whole document, then marshal it
+	b.Run("marshal-indent", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			ges, err := NewEncoder(nil).EncodeDocument(timeline)
+			if err != nil {
+				b.Fatal(err)
+			}
+			output, err := xml.MarshalIndent(ges, "", "  ")
+			if err != nil {
+				b.Fatal(err)
+			}
+			io.Discard.Write(output)
+		}
+	})
+}
+
+func TestEncoder_EncodeProject(t *testing.T) {
+	ges := loadTestProject(t, "markers.xges")
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	encoder.SetOptions(EncodeOptions{Version: "0.4", PathMap: map[string]string{"file:///media/": "file:///footage/"}})
+	if err := encoder.EncodeProject(ges); err != nil {
+		t.Fatalf("EncodeProject failed: %v", err)
+	}
+
+	if ges.Version != "0.4" || RequiredFormatVersion(ges) != DefaultFormatVersion {
+		t.Errorf("Expected a 0.4 project without markers, got version %s needing %s", ges.Version, RequiredFormatVersion(ges))
+	}
+	if len(encoder.Warnings()) == 0 {
+		t.Error("Expected warnings for the dropped markers")
+	}
+	if asset := ges.Project.Assets()[0]; asset.ID != "file:///footage/a.mov" {
+		t.Errorf("Expected a remapped asset, got %s", asset.ID)
+	}
+	if clip := ges.Project.Timeline.Layers[0].Clips[0]; clip.AssetID != "file:///footage/a.mov" {
+		t.Errorf("Expected a remapped clip, got %s", clip.AssetID)
+	}
+	if !strings.Contains(buf.String(), "<ges version='0.4'>") {
+		t.Errorf("Expected the project written as 0.4, got:\n%s", buf.String())
+	}
+}
@@ -71,7 +71,7 @@ func ExampleEncoder_Encode() {
 	// Check output contains expected elements
 	output := buf.String()
 	fmt.Printf("Contains <ges>: %v\n", strings.Contains(output, "<ges"))
-	fmt.Printf("Contains track: %v\n", strings.Contains(output, "track-type=\"4\""))
+	fmt.Printf("Contains track: %v\n", strings.Contains(output, "track-type='4'"))
 	fmt.Printf("Contains clip: %v\n", strings.Contains(output, "file:///media/clip001.mov"))
 
 	// Output:
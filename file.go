@@ -0,0 +1,217 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// utf8BOM is the byte order mark some editors put at the start of XML files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// ReadFile reads an XGES file and converts it to an OTIO Timeline
+func ReadFile(path string) (*gotio.Timeline, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return NewDecoder(bufio.NewReader(f)).Decode()
+}
+
+// WriteFile encodes an OTIO Timeline as XGES and writes it to path,
+// replacing any existing file
+func WriteFile(path string, timeline *gotio.Timeline) error {
+	return WriteFileWith(path, func(w io.Writer) error {
+		return NewEncoder(w).Encode(timeline)
+	})
+}
+
+// ReadString converts an XGES document held in a string to an OTIO Timeline
+func ReadString(s string) (*gotio.Timeline, error) {
+	return NewDecoder(strings.NewReader(s)).Decode()
+}
+
+// WriteString encodes an OTIO Timeline as an XGES document string
+func WriteString(timeline *gotio.Timeline) (string, error) {
+	var sb strings.Builder
+	if err := NewEncoder(&sb).Encode(timeline); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// Sniff reports whether data, the beginning of a file, looks like an XGES
+// document: a <ges> root element, optionally preceded by a byte order mark,
+// an XML declaration, comments or a doctype
+func Sniff(data []byte) bool {
+	data = bytes.TrimPrefix(data, utf8BOM)
+
+	for {
+		data = bytes.TrimLeft(data, " \t\r\n")
+
+		var end []byte
+		switch {
+		case bytes.HasPrefix(data, []byte("<?")):
+			end = []byte("?>")
+		case bytes.HasPrefix(data, []byte("<!--")):
+			end = []byte("-->")
+		case bytes.HasPrefix(data, []byte("<!")):
+			end = []byte(">")
+		default:
+			return isGESRoot(data)
+		}
+
+		i := bytes.Index(data, end)
+		if i < 0 {
+			return false
+		}
+		data = data[i+len(end):]
+	}
+}
+
+// isGESRoot reports whether data starts with a <ges> start tag
+func isGESRoot(data []byte) bool {
+	const root = "<ges"
+	if !bytes.HasPrefix(data, []byte(root)) || len(data) == len(root) {
+		return false
+	}
+
+	switch data[len(root)] {
+	case ' ', '\t', '\r', '\n', '>', '/':
+		return true
+	default:
+		return false
+	}
+}
+
+// WriteFileWith fills path through a buffered writer passed to write. The
+// content goes to a temporary file in the same directory, renamed over path
+// once written, so a failed write leaves any existing file untouched.
+func WriteFileWith(path string, write func(w io.Writer) error) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	w := bufio.NewWriter(f)
+	err = write(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// sniffLimit is how much of a file past its XML prolog is read to detect
+// its format
+const sniffLimit = 4096
+
+// sniffFile reads the start of a file for content detection. The byte order
+// mark, XML declaration, comments, processing instructions and doctype
+// before the first element are skipped whatever their length.
+func sniffFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	if err := skipProlog(r); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	buf := make([]byte, sniffLimit)
+	n, err := io.ReadFull(r, buf)
+	if n == 0 && err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return buf[:n], nil
+}
+
+// skipProlog advances r to the first element of an XML document, past the
+// byte order mark, whitespace, declarations, comments and doctype, as Sniff
+// does
+func skipProlog(r *bufio.Reader) error {
+	if bom, err := r.Peek(len(utf8BOM)); err == nil && bytes.Equal(bom, utf8BOM) {
+		r.Discard(len(utf8BOM))
+	}
+
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		r.UnreadByte()
+
+		var end string
+		switch {
+		case peekPrefix(r, "<?"):
+			end = "?>"
+		case peekPrefix(r, "<!--"):
+			end = "-->"
+		case peekPrefix(r, "<!"):
+			end = ">"
+		default:
+			return nil
+		}
+		if err := skipPast(r, end); err != nil {
+			return err
+		}
+	}
+}
+
+// peekPrefix reports whether the next bytes of r are prefix
+func peekPrefix(r *bufio.Reader, prefix string) bool {
+	b, _ := r.Peek(len(prefix))
+	return string(b) == prefix
+}
+
+// skipPast advances r past the next occurrence of end
+func skipPast(r *bufio.Reader, end string) error {
+	var tail []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		tail = append(tail, c)
+		if len(tail) > len(end) {
+			tail = tail[1:]
+		}
+		if string(tail) == end {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ImageSequenceScheme is the URI scheme GES plays image sequences through
+const ImageSequenceScheme = "imagesequence://"
+
+// ImageSequence describes the frames of an imagesequence:// URI. The files
+// are URLBase + Prefix + the zero padded frame number + Suffix.
+type ImageSequence struct {
+	URLBase    string // file:// URL of the directory, ending with a slash
+	Prefix     string
+	Suffix     string
+	Padding    int
+	StartFrame int
+	EndFrame   int // last frame, or -1 when the sequence has no stop index
+	Rate       float64
+}
+
+// framePattern matches the printf frame number pattern of a sequence
+// location, escaped as GStreamer expects or written literally
+var framePattern = regexp.MustCompile(`%(?:25)?(0\d+)?d`)
+
+// IsImageSequenceURI reports whether a GES asset id is an image sequence
+func IsImageSequenceURI(uri string) bool {
+	return strings.HasPrefix(uri, ImageSequenceScheme)
+}
+
+// ParseImageSequenceURI parses an imagesequence:// URI with its
+// start-index, stop-index and framerate parameters
+func ParseImageSequenceURI(uri string) (*ImageSequence, error) {
+	if !IsImageSequenceURI(uri) {
+		return nil, fmt.Errorf("not an image sequence URI: %s", uri)
+	}
+
+	location, query, _ := strings.Cut(strings.TrimPrefix(uri, ImageSequenceScheme), "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse image sequence URI %s: %w", uri, err)
+	}
+
+	loc := framePattern.FindStringSubmatchIndex(location)
+	if loc == nil {
+		return nil, fmt.Errorf("image sequence URI %s has no frame number pattern", uri)
+	}
+
+	seq := &ImageSequence{Suffix: location[loc[1]:], EndFrame: -1}
+	if loc[2] >= 0 {
+		seq.Padding, _ = strconv.Atoi(location[loc[2]+1 : loc[3]])
+	}
+	dir := location[:loc[0]]
+	if i := strings.LastIndex(dir, "/"); i >= 0 {
+		seq.URLBase, seq.Prefix = "file://"+dir[:i+1], dir[i+1:]
+	} else {
+		seq.Prefix = dir
+	}
+
+	if v := params.Get("start-index"); v != "" {
+		if seq.StartFrame, err = strconv.Atoi(v); err != nil {
+			return nil, fmt.Errorf("invalid start-index in image sequence URI %s", uri)
+		}
+	}
+	if v := params.Get("stop-index"); v != "" {
+		if seq.EndFrame, err = strconv.Atoi(v); err != nil || seq.EndFrame < seq.StartFrame {
+			return nil, fmt.Errorf("invalid stop-index in image sequence URI %s", uri)
+		}
+	}
+	if v := params.Get("framerate"); v != "" {
+		fraction, err := ParseStructure("s, framerate=(fraction)" + v)
+		num, den, ok := fraction.GetFraction("framerate")
+		if err != nil || !ok || num <= 0 || den <= 0 {
+			return nil, fmt.Errorf("invalid framerate in image sequence URI %s", uri)
+		}
+		seq.Rate = float64(num) / float64(den)
+	}
+
+	return seq, nil
+}
+
+// String formats the sequence as an imagesequence:// URI
+func (s *ImageSequence) String() string {
+	pattern := "%25d"
+	if s.Padding > 0 {
+		pattern = fmt.Sprintf("%%25%02dd", s.Padding)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(ImageSequenceScheme)
+	sb.WriteString(strings.TrimPrefix(s.URLBase, "file://"))
+	sb.WriteString(s.Prefix + pattern + s.Suffix)
+	sb.WriteString("?start-index=" + strconv.Itoa(s.StartFrame))
+	if s.EndFrame >= 0 {
+		sb.WriteString("&stop-index=" + strconv.Itoa(s.EndFrame))
+	}
+	if s.Rate > 0 {
+		num, den := rateFraction(s.Rate)
+		fmt.Fprintf(&sb, "&framerate=%d/%d", num, den)
+	}
+	return sb.String()
+}
+
+// rateFraction returns the fraction GStreamer uses for a frame rate,
+// recognizing the NTSC rates
+func rateFraction(rate float64) (num, den int64) {
+	if rate == math.Trunc(rate) {
+		return int64(rate), 1
+	}
+	if ntsc := math.Round(rate * 1.001); math.Abs(ntsc*1000/1001-rate) < 1e-3 {
+		return int64(ntsc) * 1000, 1001
+	}
+	num, den = int64(math.Round(rate*1000)), 1000
+	a, b := num, den
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return num / a, den / a
+}
@@ -0,0 +1,115 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+	"github.com/Avalanche-io/gotio/opentime"
+)
+
+func TestParseImageSequenceURI(t *testing.T) {
+	seq, err := ParseImageSequenceURI("imagesequence:///plates/sh010/sh010.%2504d.exr?start-index=1001&stop-index=1100&framerate=24000/1001")
+	if err != nil {
+		t.Fatalf("ParseImageSequenceURI failed: %v", err)
+	}
+	expected := ImageSequence{URLBase: "file:///plates/sh010/", Prefix: "sh010.", Suffix: ".exr", Padding: 4, StartFrame: 1001, EndFrame: 1100}
+	rate := seq.Rate
+	seq.Rate = 0
+	if *seq != expected {
+		t.Errorf("Expected %+v, got %+v", expected, *seq)
+	}
+	if rate < 23.975 || rate > 23.977 {
+		t.Errorf("Expected rate 23.976, got %v", rate)
+	}
+
+	// A literal pattern and no stop index
+	seq, err = ParseImageSequenceURI("imagesequence:///frames/%d.dpx?start-index=0")
+	if err != nil {
+		t.Fatalf("ParseImageSequenceURI failed: %v", err)
+	}
+	if seq.Prefix != "" || seq.Padding != 0 || seq.EndFrame != -1 || seq.Rate != 0 {
+		t.Errorf("Unexpected sequence %+v", *seq)
+	}
+
+	for _, uri := range []string{
+		"file:///plates/a.exr",
+		"imagesequence:///plates/a.exr",
+		"imagesequence:///plates/%2504d.exr?start-index=x",
+		"imagesequence:///plates/%2504d.exr?start-index=10&stop-index=5",
+		"imagesequence:///plates/%2504d.exr?framerate=0/1",
+	} {
+		if _, err := ParseImageSequenceURI(uri); err == nil {
+			t.Errorf("Expected an error for %s", uri)
+		}
+	}
+}
+
+func TestImageSequence_String(t *testing.T) {
+	seq := ImageSequence{URLBase: "file:///plates/", Prefix: "sh010.", Suffix: ".exr", Padding: 4, StartFrame: 1001, EndFrame: 1100, Rate: 12.5}
+	uri := seq.String()
+	if uri != "imagesequence:///plates/sh010.%2504d.exr?start-index=1001&stop-index=1100&framerate=25/2" {
+		t.Errorf("Unexpected URI %s", uri)
+	}
+
+	parsed, err := ParseImageSequenceURI(uri)
+	if err != nil || *parsed != seq {
+		t.Errorf("Round trip changed the sequence: %+v, %v", parsed, err)
+	}
+}
+
+func TestImageSequence_RoundTrip(t *testing.T) {
+	availableRange := opentime.NewTimeRange(opentime.NewRationalTime(1001, 24), opentime.NewRationalTime(100, 24))
+	ref := gotio.NewImageSequenceReference("", "file:///plates/", "sh010.", ".exr", 1001, 1, 24, 4, gotio.MissingFramePolicyError, &availableRange, nil)
+	sourceRange := opentime.NewTimeRange(opentime.NewRationalTime(1011, 24), opentime.NewRationalTime(48, 24))
+
+	timeline := gotio.NewTimeline("", nil, nil)
+	track := gotio.NewTrack("", nil, gotio.TrackKindVideo, nil, nil)
+	track.AppendChild(gotio.NewClip("plate", ref, &sourceRange, nil, nil, nil, "", nil))
+	timeline.Tracks().AppendChild(track)
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	clip := ges.Project.Timeline.Layers[0].Clips[0]
+	if !strings.HasPrefix(clip.AssetID, "imagesequence:///plates/sh010.%2504d.exr?start-index=1001&stop-index=1100") {
+		t.Errorf("Unexpected asset id %s", clip.AssetID)
+	}
+	if clip.Inpoint != 416666666 {
+		t.Errorf("Expected inpoint of 10 frames, got %d", clip.Inpoint)
+	}
+
+	decoded, err := NewDecoder(nil).DecodeDocument(ges)
+	if err != nil {
+		t.Fatalf("DecodeDocument failed: %v", err)
+	}
+	otioClip := decoded.VideoTracks()[0].Children()[0].(*gotio.Clip)
+	seqRef, ok := otioClip.MediaReference().(*gotio.ImageSequenceReference)
+	if !ok {
+		t.Fatalf("Expected an ImageSequenceReference, got %T", otioClip.MediaReference())
+	}
+	if seqRef.TargetURLBase() != "file:///plates/" || seqRef.NamePrefix() != "sh010." || seqRef.FrameZeroPadding() != 4 || seqRef.EndFrame() != 1100 {
+		t.Errorf("Unexpected reference %+v", seqRef)
+	}
+	if frame := otioClip.SourceRange().StartTime().RescaledTo(24).Value(); frame < 1010.99 || frame > 1011.01 {
+		t.Errorf("Expected the clip to start at frame 1011, got %v", frame)
+	}
+}
+
+func TestRateFraction(t *testing.T) {
+	testCases := map[float64][2]int64{
+		25:             {25, 1},
+		30000.0 / 1001: {30000, 1001},
+		23.976:         {24000, 1001},
+		12.5:           {25, 2},
+	}
+	for rate, expected := range testCases {
+		if num, den := rateFraction(rate); num != expected[0] || den != expected[1] {
+			t.Errorf("rateFraction(%v) = %d/%d, expected %d/%d", rate, num, den, expected[0], expected[1])
+		}
+	}
+}
@@ -0,0 +1,718 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// LaunchProgram is the GES command line tool that launch arguments are for
+const LaunchProgram = "ges-launch-1.0"
+
+// LaunchArgs describes a project as ges-launch-1.0 timeline arguments,
+// without the program name. Clips are listed layer by layer with +clip,
+// +test-clip and +title, followed by their +effect and set-<property>
+// arguments. A clip start is only given when the clip doesn't follow the
+// end of its layer, and transitions are left for ges-launch to create
+// from overlapping clips. Clip track types, mute and per-track sources
+// can't be expressed and are left out.
+func LaunchArgs(ges *GES) []string {
+	var args []string
+	timeline := &ges.Project.Timeline
+
+	if !defaultLaunchTracks(timeline.Tracks) {
+		for _, track := range timeline.Tracks {
+			args = append(args, "+track", launchTrackType(track.TrackType))
+			if caps, ok := track.RestrictionCaps(); ok {
+				args = append(args, "restrictions="+WrapGstString(strings.TrimSuffix(caps.String(), ";")))
+			}
+		}
+	}
+
+	layers := make([]*Layer, len(timeline.Layers))
+	for i := range timeline.Layers {
+		layers[i] = &timeline.Layers[i]
+	}
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Priority < layers[j].Priority })
+
+	for _, layer := range layers {
+		var clips []*Clip
+		for i := range layer.Clips {
+			if layer.Clips[i].TypeName != ClipTypeTransition {
+				clips = append(clips, &layer.Clips[i])
+			}
+		}
+		sort.SliceStable(clips, func(i, j int) bool { return clips[i].Start < clips[j].Start })
+
+		var end uint64
+		for _, clip := range clips {
+			args = append(args, launchClipArgs(clip, layer.Priority, end)...)
+			end = max(end, clip.End())
+		}
+	}
+
+	return args
+}
+
+// launchClipArgs describes one clip and its effects
+func launchClipArgs(clip *Clip, layer int, layerEnd uint64) []string {
+	children, _ := ParseStructure(clip.ChildrenProperties)
+	if children == nil {
+		children = NewStructure("properties")
+	}
+
+	var args []string
+	switch clip.TypeName {
+	case ClipTypeTest:
+		pattern := clip.AssetID
+		if props, err := ParseStructure(clip.Properties); err == nil {
+			if f, ok := props.Get("vpattern"); ok {
+				pattern = f.Value
+			}
+		}
+		args = append(args, "+test-clip", pattern)
+	case ClipTypeTitle:
+		var text string
+		if name, ok := children.ChildPropertyName("text"); ok {
+			text, _ = children.GetString(name)
+			children.Remove(name)
+		}
+		args = append(args, "+title", text)
+	default:
+		args = append(args, "+clip", clip.AssetID)
+	}
+
+	if props, err := ParseStructure(clip.Properties); err == nil {
+		if name, ok := props.GetString("name"); ok && !isGeneratedName(name) {
+			args = append(args, "name="+launchString(name))
+		}
+	}
+	if clip.Start != layerEnd {
+		args = append(args, "start="+formatLaunchTime(clip.Start))
+	}
+	if clip.Duration != gstClockTimeNone {
+		args = append(args, "duration="+formatLaunchTime(clip.Duration))
+	}
+	if clip.Inpoint != 0 {
+		args = append(args, "inpoint="+formatLaunchTime(clip.Inpoint))
+	}
+	if layer != 0 {
+		args = append(args, "layer="+strconv.Itoa(layer))
+	}
+	args = append(args, launchSetArgs(children)...)
+
+	for _, effect := range clip.Effects {
+		args = append(args, "+effect", effect.AssetID)
+		if props, err := ParseStructure(effect.ChildrenProperties); err == nil {
+			args = append(args, launchSetArgs(props)...)
+		}
+	}
+	return args
+}
+
+// launchSetArgs turns children properties into set-<property> arguments
+func launchSetArgs(props *Structure) []string {
+	var args []string
+	for _, f := range props.Fields {
+		value := f.Value
+		if f.Type == "string" {
+			value = launchString(value)
+		}
+		args = append(args, "set-"+f.Name+"="+value)
+	}
+	return args
+}
+
+// launchString quotes a string value when ges-launch would otherwise read
+// it as another type or split it
+func launchString(s string) string {
+	if _, err := strconv.ParseFloat(s, 64); err == nil || s == "true" || s == "false" {
+		return `"` + s + `"`
+	}
+	return WrapGstString(s)
+}
+
+// defaultLaunchTracks reports whether the tracks are the ones ges-launch
+// creates when none are given: one audio and one video track without
+// restrictions
+func defaultLaunchTracks(tracks []Track) bool {
+	if len(tracks) != 2 {
+		return false
+	}
+	types := 0
+	for _, track := range tracks {
+		if _, ok := track.RestrictionCaps(); ok {
+			return false
+		}
+		types |= track.TrackType
+	}
+	return types == TrackTypeAudio|TrackTypeVideo
+}
+
+func launchTrackType(trackType int) string {
+	switch trackType {
+	case TrackTypeAudio:
+		return "audio"
+	case TrackTypeVideo:
+		return "video"
+	case TrackTypeText:
+		return "text"
+	default:
+		return "custom"
+	}
+}
+
+// formatLaunchTime writes nanoseconds as exact decimal seconds
+func formatLaunchTime(ns uint64) string {
+	frac := strings.TrimRight(fmt.Sprintf("%09d", ns%GSTSecond), "0")
+	if frac == "" {
+		frac = "0"
+	}
+	return fmt.Sprintf("%d.%s", ns/GSTSecond, frac)
+}
+
+// parseLaunchTime reads decimal seconds as nanoseconds
+func parseLaunchTime(s string) (uint64, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > 9 {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	var seconds uint64
+	if whole != "" {
+		var err error
+		if seconds, err = strconv.ParseUint(whole, 10, 64); err != nil {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+	}
+	var nanos uint64
+	if frac != "" {
+		n, err := strconv.ParseUint(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid time %q", s)
+		}
+		nanos = n
+	}
+	return seconds*GSTSecond + nanos, nil
+}
+
+// launchParser builds a project from ges-launch arguments
+type launchParser struct {
+	ges      *GES
+	layers   map[int]*Layer
+	assets   map[string]bool
+	nextID   int
+	clip     *Clip
+	setStart bool
+	children *Structure
+	effect   *Effect
+	effectCh *Structure
+}
+
+// ParseLaunchArgs reads ges-launch-1.0 timeline arguments back into a
+// project. The program name may be included. Clips without a start are
+// placed at the end of their layer and overlapping clips of a layer are
+// joined by crossfades, as ges-launch does. A clip without a duration
+// plays the rest of its media in ges-launch: as the media isn't read, its
+// duration is left as GST_CLOCK_TIME_NONE for GES to fill in when it loads
+// the project, and the clip has to be the last of its layer.
+func ParseLaunchArgs(args []string) (*GES, error) {
+	if len(args) > 0 && strings.HasPrefix(path.Base(args[0]), "ges-launch") {
+		args = args[1:]
+	}
+
+	p := &launchParser{
+		ges:    &GES{},
+		layers: make(map[int]*Layer),
+		assets: make(map[string]bool),
+	}
+	p.ges.Project.Properties = "properties;"
+	p.ges.Project.Metadatas = "metadatas;"
+	p.ges.Project.Timeline.Properties = "properties, auto-transition=(boolean)true;"
+	p.ges.Project.Timeline.Metadatas = "metadatas;"
+
+	// Split into +command groups
+	var group []string
+	for i, arg := range args {
+		if strings.HasPrefix(arg, "+") {
+			if group != nil {
+				if err := p.command(group); err != nil {
+					return nil, err
+				}
+			}
+			group = []string{arg}
+			continue
+		}
+		if group == nil {
+			return nil, fmt.Errorf("unsupported ges-launch argument %q at position %d", arg, i)
+		}
+		group = append(group, arg)
+	}
+	if group != nil {
+		if err := p.command(group); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.finishClip(); err != nil {
+		return nil, err
+	}
+
+	return p.finish(), nil
+}
+
+// command handles one +command and its arguments
+func (p *launchParser) command(group []string) error {
+	name, args := group[0], group[1:]
+	switch name {
+	case "+clip", "+test-clip", "+title":
+		if err := p.finishClip(); err != nil {
+			return err
+		}
+		if len(args) == 0 || strings.Contains(args[0], "=") {
+			return fmt.Errorf("%s needs a %s", name, map[string]string{"+clip": "media URI", "+test-clip": "pattern", "+title": "text"}[name])
+		}
+		return p.startClip(name, args[0], args[1:])
+	case "+effect":
+		if p.clip == nil {
+			return fmt.Errorf("+effect %s comes before any clip", strings.Join(args, " "))
+		}
+		if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+			return fmt.Errorf("+effect needs a bin description")
+		}
+		p.finishEffect()
+		p.effect = &Effect{
+			AssetID:   args[0],
+			ClipID:    p.clip.ID,
+			TypeName:  EffectTypeName,
+			TrackType: effectTrackType(args[0]),
+		}
+		p.effectCh = NewStructure("properties")
+		return p.setArgs(args[1:], p.effectCh)
+	case "+track":
+		if len(args) == 0 {
+			return fmt.Errorf("+track needs a track type")
+		}
+		return p.addTrack(args[0], args[1:])
+	default:
+		return fmt.Errorf("unsupported ges-launch command %s", name)
+	}
+}
+
+func (p *launchParser) startClip(command, source string, args []string) error {
+	clip := &Clip{ID: p.nextID, TypeName: ClipTypeURI, AssetID: source}
+	p.nextID++
+	p.children = NewStructure("properties")
+	props := NewStructure("properties")
+
+	switch command {
+	case "+clip":
+		if strings.HasPrefix(source, "/") {
+			clip.AssetID = "file://" + source
+		}
+		p.assets[clip.AssetID] = true
+	case "+test-clip":
+		clip.TypeName = ClipTypeTest
+	case "+title":
+		clip.TypeName = ClipTypeTitle
+		clip.AssetID = ClipTypeTitle
+		p.children.SetString("text", source)
+	}
+
+	p.setStart = false
+	hasDuration := false
+	var rest []string
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value after %s, got %q", command, arg)
+		}
+		var err error
+		switch key {
+		case "start", "s":
+			clip.Start, err = parseLaunchTime(value)
+			p.setStart = true
+		case "duration", "d":
+			clip.Duration, err = parseLaunchTime(value)
+			hasDuration = true
+		case "inpoint", "i":
+			clip.Inpoint, err = parseLaunchTime(value)
+		case "layer", "l":
+			clip.LayerPriority, err = strconv.Atoi(value)
+			if err == nil && clip.LayerPriority < 0 {
+				err = fmt.Errorf("invalid layer %d", clip.LayerPriority)
+			}
+		case "name", "n":
+			props.SetString("name", launchValue(value))
+		default:
+			rest = append(rest, arg)
+		}
+		if err != nil {
+			return fmt.Errorf("%s %s: %w", command, source, err)
+		}
+	}
+	if !hasDuration {
+		clip.Duration = gstClockTimeNone
+	}
+	if len(props.Fields) > 0 {
+		clip.Properties = props.String()
+	}
+
+	p.clip = clip
+	return p.setArgs(rest, p.children)
+}
+
+// setArgs applies set-<property>=<value> arguments to a structure
+func (p *launchParser) setArgs(args []string, st *Structure) error {
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		name, isSet := strings.CutPrefix(key, "set-")
+		if !ok || !isSet || name == "" {
+			return fmt.Errorf("unsupported ges-launch argument %q", arg)
+		}
+		setLaunchValue(st, name, value)
+	}
+	return nil
+}
+
+// finishEffect attaches the current effect to the current clip
+func (p *launchParser) finishEffect() {
+	if p.effect == nil {
+		return
+	}
+	if len(p.effectCh.Fields) > 0 {
+		p.effect.ChildrenProperties = p.effectCh.String()
+	}
+	p.clip.Effects = append(p.clip.Effects, *p.effect)
+	p.effect = nil
+}
+
+// finishClip places the current clip on its layer
+func (p *launchParser) finishClip() error {
+	if p.clip == nil {
+		return nil
+	}
+	p.finishEffect()
+
+	clip := p.clip
+	p.clip = nil
+	if len(p.children.Fields) > 0 {
+		clip.ChildrenProperties = p.children.String()
+	}
+
+	layer := p.layer(clip.LayerPriority)
+	for _, other := range layer.Clips {
+		if !p.setStart {
+			clip.Start = max(clip.Start, other.End())
+		}
+		if other.Duration == gstClockTimeNone && clip.Start >= other.Start || clip.Duration == gstClockTimeNone && other.Start >= clip.Start {
+			return fmt.Errorf("clip %s: only the last clip of a layer can go without a duration", clip.AssetID)
+		}
+	}
+	layer.Clips = append(layer.Clips, *clip)
+	return nil
+}
+
+func (p *launchParser) layer(priority int) *Layer {
+	if layer, ok := p.layers[priority]; ok {
+		return layer
+	}
+	layer := &Layer{
+		Priority:   priority,
+		Properties: "properties, auto-transition=(boolean)true;",
+		Metadatas:  "metadatas, volume=(float)1;",
+	}
+	p.layers[priority] = layer
+	return layer
+}
+
+func (p *launchParser) addTrack(kind string, args []string) error {
+	track := Track{TrackID: len(p.ges.Project.Timeline.Tracks), Metadatas: "metadatas;"}
+	switch kind {
+	case "video":
+		track.TrackType = TrackTypeVideo
+	case "audio":
+		track.TrackType = TrackTypeAudio
+	case "text":
+		track.TrackType = TrackTypeText
+	case "custom":
+		track.TrackType = TrackTypeCustom
+	default:
+		return fmt.Errorf("unsupported track type %q", kind)
+	}
+	track.Caps = defaultCaps(track.TrackType)
+
+	props := NewStructure("properties")
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key != "restrictions" {
+			return fmt.Errorf("unsupported +track argument %q", arg)
+		}
+		props.SetString("restriction-caps", launchValue(value))
+	}
+	props.SetBool("mixing", true)
+	track.Properties = props.String()
+
+	p.ges.Project.Timeline.Tracks = append(p.ges.Project.Timeline.Tracks, track)
+	return nil
+}
+
+// finish fills in tracks, layers, track types, transitions, assets and
+// the format version
+func (p *launchParser) finish() *GES {
+	timeline := &p.ges.Project.Timeline
+	if len(timeline.Tracks) == 0 {
+		p.addTrack("video", nil)
+		p.addTrack("audio", nil)
+	}
+	trackTypes := 0
+	for _, track := range timeline.Tracks {
+		trackTypes |= track.TrackType
+	}
+
+	priorities := make([]int, 0, len(p.layers))
+	for priority := range p.layers {
+		priorities = append(priorities, priority)
+	}
+	sort.Ints(priorities)
+	// GES layers are contiguous from priority 0
+	if n := len(priorities); n > 0 {
+		for priority := 0; priority <= priorities[n-1]; priority++ {
+			timeline.Layers = append(timeline.Layers, *p.layer(priority))
+		}
+	}
+
+	for l := range timeline.Layers {
+		layer := &timeline.Layers[l]
+		for i := range layer.Clips {
+			clip := &layer.Clips[i]
+			switch clip.TypeName {
+			case ClipTypeTitle:
+				clip.TrackTypes = TrackTypeVideo & trackTypes
+			default:
+				clip.TrackTypes = (TrackTypeAudio | TrackTypeVideo) & trackTypes
+			}
+			for e := range clip.Effects {
+				if clip.Effects[e].TrackType&trackTypes == 0 {
+					clip.Effects[e].TrackType = TrackTypeVideo
+				}
+				for _, track := range timeline.Tracks {
+					if track.TrackType == clip.Effects[e].TrackType {
+						clip.Effects[e].TrackID = track.TrackID
+						break
+					}
+				}
+			}
+		}
+		updateLayerTransitions(layer, nil, &p.nextID)
+	}
+
+	var assets []Asset
+	for l := range timeline.Layers {
+		for _, clip := range timeline.Layers[l].Clips {
+			if clip.TypeName == ClipTypeURI && p.assets[clip.AssetID] {
+				assets = append(assets, Asset{ID: clip.AssetID, ExtractableTypeName: ClipTypeURI, Properties: "properties;", Metadatas: "metadatas;"})
+				p.assets[clip.AssetID] = false
+			}
+		}
+	}
+	if len(assets) > 0 {
+		p.ges.Project.Ressources = &Ressources{Assets: assets}
+	}
+
+	p.ges.Version = RequiredFormatVersion(p.ges).String()
+	return p.ges
+}
+
+// launchValue unquotes a ges-launch string value
+func launchValue(value string) string {
+	if strings.HasPrefix(value, `"`) {
+		return UnwrapGstString(value)
+	}
+	return value
+}
+
+// setLaunchValue sets a field from a set-<property> value, guessing its
+// type the way a GstStructure field without a type is read
+func setLaunchValue(st *Structure, name, value string) {
+	if strings.HasPrefix(value, `"`) {
+		st.SetString(name, UnwrapGstString(value))
+	} else if b, err := strconv.ParseBool(value); err == nil && (value == "true" || value == "false") {
+		st.SetBool(name, b)
+	} else if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		st.SetInt(name, i)
+	} else if f, err := strconv.ParseFloat(value, 64); err == nil {
+		st.SetFloat(name, f)
+	} else {
+		st.SetString(name, value)
+	}
+}
+
+// effectTrackType guesses the track of an effect from its bin description
+func effectTrackType(description string) int {
+	element := strings.Fields(description)[0]
+	if strings.HasPrefix(element, "audio") {
+		return TrackTypeAudio
+	}
+	switch element {
+	case "volume", "pitch", "scaletempo", "equalizer-3bands", "equalizer-10bands", "panorama", "freeverb":
+		return TrackTypeAudio
+	}
+	return TrackTypeVideo
+}
+
+// EncodeLaunchArgs converts an OTIO timeline to ges-launch-1.0 arguments.
+// What the arguments can't express is dropped with a warning.
+func (e *Encoder) EncodeLaunchArgs(timeline *gotio.Timeline) ([]string, error) {
+	ges, err := e.EncodeDocument(timeline)
+	if err != nil {
+		return nil, err
+	}
+	return e.launchArgs(ges)
+}
+
+// EncodeProjectLaunchArgs converts a GES project to ges-launch-1.0
+// arguments, remapping its media URIs in place. What the arguments can't
+// express is dropped with a warning.
+func (e *Encoder) EncodeProjectLaunchArgs(ges *GES) ([]string, error) {
+	e.warnings = nil
+	remapProject(ges, e.opts.PathMap)
+	return e.launchArgs(ges)
+}
+
+// launchArgs returns the launch arguments of a project, warning about the
+// clip settings they leave out
+func (e *Encoder) launchArgs(ges *GES) ([]string, error) {
+	timeline := &ges.Project.Timeline
+	trackTypes := 0
+	for _, track := range timeline.Tracks {
+		trackTypes |= track.TrackType
+	}
+	for _, layer := range timeline.Layers {
+		for i := range layer.Clips {
+			clip := &layer.Clips[i]
+			if clip.TypeName == ClipTypeTransition {
+				continue
+			}
+			// ges-launch puts clips in every track their media has
+			launchTypes := (TrackTypeAudio | TrackTypeVideo) & trackTypes
+			if clip.TypeName == ClipTypeTitle {
+				launchTypes &= TrackTypeVideo
+			} else if asset, ok := ges.Project.AssetFor(clip); ok {
+				if formats, ok := structureOf(asset.Properties).GetInt("supported-formats"); ok {
+					launchTypes &= int(formats)
+				}
+			}
+			if clip.TrackTypes != launchTypes {
+				if err := e.warnf("clip %d: track types %d dropped, ges-launch puts clips in every track of their media", clip.ID, clip.TrackTypes); err != nil {
+					return nil, err
+				}
+			}
+			if clip.Mute() {
+				if err := e.warnf("clip %d: mute dropped, ges-launch can't express it", clip.ID); err != nil {
+					return nil, err
+				}
+			}
+			if len(clip.Sources) > 0 {
+				if err := e.warnf("clip %d: per-track sources dropped, ges-launch can't express them", clip.ID); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return LaunchArgs(ges), nil
+}
+
+// DecodeLaunchArgs converts ges-launch-1.0 arguments to an OTIO timeline.
+// The decoder's reader is not used.
+func (d *Decoder) DecodeLaunchArgs(args []string) (*gotio.Timeline, error) {
+	ges, err := ParseLaunchArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	return d.DecodeDocument(ges)
+}
+
+// FormatLaunchCommand returns a shell command line running ges-launch-1.0
+// with the arguments
+func FormatLaunchCommand(args []string) string {
+	quoted := []string{LaunchProgram}
+	for _, arg := range args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// ParseLaunchCommand splits a shell command line into arguments for
+// ParseLaunchArgs
+func ParseLaunchCommand(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		case c == '\\' && i+1 < len(line):
+			i++
+			if line[i] != '\n' {
+				word.WriteByte(line[i])
+				inWord = true
+			}
+		case c == '\'':
+			end := strings.IndexByte(line[i+1:], '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote in command line")
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		case c == '"':
+			i++
+			for ; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) && strings.IndexByte("\"\\$`\n", line[i+1]) >= 0 {
+					i++
+				}
+				word.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("unterminated quote in command line")
+			}
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+// shellQuote quotes an argument for a POSIX shell when needed
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("+-_./:=,@%", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// WriteLaunchCommand writes the ges-launch-1.0 command line of a project
+func WriteLaunchCommand(w io.Writer, ges *GES) error {
+	_, err := io.WriteString(w, FormatLaunchCommand(LaunchArgs(ges))+"\n")
+	return err
+}
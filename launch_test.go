@@ -0,0 +1,299 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+	"github.com/Avalanche-io/gotio/opentime"
+)
+
+func TestParseLaunchArgs(t *testing.T) {
+	args, err := ParseLaunchCommand(`ges-launch-1.0 +clip /media/a.mov inpoint=2 duration=5 \
+		+clip /media/b.mov duration=3 start=4 +effect agingtv set-scratch-lines=5 \
+		+title "Hello, World" duration=2.5 layer=1 set-valignment=top`)
+	if err != nil {
+		t.Fatalf("ParseLaunchCommand failed: %v", err)
+	}
+
+	ges, err := ParseLaunchArgs(args)
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+
+	timeline := &ges.Project.Timeline
+	if len(timeline.Tracks) != 2 || len(timeline.Layers) != 2 {
+		t.Fatalf("Expected 2 tracks and 2 layers, got %d and %d", len(timeline.Tracks), len(timeline.Layers))
+	}
+
+	layer := timeline.Layers[0]
+	if len(layer.Clips) != 4 {
+		t.Fatalf("Expected 2 clips and 2 crossfades on layer 0, got %d clips", len(layer.Clips))
+	}
+	a, crossfade, b := layer.Clips[0], layer.Clips[2], layer.Clips[3]
+	if a.AssetID != "file:///media/a.mov" || a.Start != 0 || a.Inpoint != 2*GSTSecond || a.Duration != 5*GSTSecond {
+		t.Errorf("Unexpected first clip %+v", a)
+	}
+	if a.TrackTypes != TrackTypeAudio|TrackTypeVideo {
+		t.Errorf("Expected audio and video track types, got %d", a.TrackTypes)
+	}
+	if b.Start != 4*GSTSecond || len(b.Effects) != 1 {
+		t.Fatalf("Unexpected second clip %+v", b)
+	}
+	if effect := b.Effects[0]; effect.AssetID != "agingtv" || effect.ClipID != b.ID || effect.TrackType != TrackTypeVideo ||
+		effect.ChildrenProperties != "properties, scratch-lines=(int)5;" {
+		t.Errorf("Unexpected effect %+v", effect)
+	}
+	if crossfade.TypeName != ClipTypeTransition || crossfade.TrackTypes != TrackTypeVideo || crossfade.Start != 4*GSTSecond || crossfade.Duration != GSTSecond {
+		t.Errorf("Unexpected crossfade %+v", crossfade)
+	}
+	if audio := layer.Clips[1]; audio.TypeName != ClipTypeTransition || audio.TrackTypes != TrackTypeAudio {
+		t.Errorf("Expected an audio crossfade, got %+v", audio)
+	}
+
+	title := timeline.Layers[1].Clips[0]
+	if title.TypeName != ClipTypeTitle || title.Start != 0 || title.Duration != 2500000000 || title.TrackTypes != TrackTypeVideo {
+		t.Errorf("Unexpected title %+v", title)
+	}
+	children, _ := ParseStructure(title.ChildrenProperties)
+	if text, _ := children.GetString("text"); text != "Hello, World" {
+		t.Errorf("Unexpected title text %q", text)
+	}
+
+	if assets := ges.Project.Assets(); len(assets) != 2 {
+		t.Errorf("Expected 2 assets, got %v", assets)
+	}
+}
+
+func TestLaunchArgs_RoundTrip(t *testing.T) {
+	input := []string{
+		"+track", "video", `restrictions="video/x-raw\,\ framerate\=\(fraction\)25/1"`,
+		"+track", "audio",
+		"+clip", "file:///media/a.mov", "name=Interview", "duration=4.0", "inpoint=1.04",
+		"+clip", "file:///media/b.mov", "duration=2.5", "set-alpha=0.5",
+		"+effect", "videobalance", "set-saturation=0",
+		"+clip", "file:///media/a.mov", "start=10.0", "duration=1.0",
+		"+test-clip", "bars", "duration=3.0", "layer=2",
+	}
+
+	ges, err := ParseLaunchArgs(input)
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+	if len(ges.Project.Timeline.Layers) != 3 {
+		t.Errorf("Expected empty layer 1 to be created, got %d layers", len(ges.Project.Timeline.Layers))
+	}
+	if rate := ges.Project.Timeline.FrameRate(); rate != 25 {
+		t.Errorf("Expected restriction frame rate 25, got %v", rate)
+	}
+
+	output := LaunchArgs(ges)
+	if !reflect.DeepEqual(input, output) {
+		t.Errorf("Round trip changed the arguments:\n%q\n%q", input, output)
+	}
+}
+
+func TestLaunchArgs_Example(t *testing.T) {
+	ges := loadTestProject(t, "xges_example.xges")
+
+	args := LaunchArgs(ges)
+	parsed, err := ParseLaunchArgs(args)
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v\n%s", err, FormatLaunchCommand(args))
+	}
+	if !reflect.DeepEqual(args, LaunchArgs(parsed)) {
+		t.Errorf("Arguments changed after parsing:\n%q\n%q", args, LaunchArgs(parsed))
+	}
+
+	// The crossfade is recreated from the overlap. The clips of the launch
+	// command are in every track of their media, which adds an audio one.
+	for _, c := range changesOfKind(Diff(ges, parsed), ChangeTransitionChanged) {
+		if c.Before != nil || c.After.Asset != CrossfadeAssetID {
+			t.Errorf("Unexpected transition change %v", c)
+		}
+	}
+}
+
+func TestParseLaunchArgs_Errors(t *testing.T) {
+	for _, args := range [][]string{
+		{"-o", "out.mp4", "+clip", "a.mov", "duration=1"},
+		{"+clip", "a.mov", "+clip", "b.mov", "duration=1"},
+		{"+clip", "a.mov", "start=5", "+clip", "b.mov", "duration=1", "start=6"},
+		{"+clip", "duration=1"},
+		{"+clip", "a.mov", "duration=one"},
+		{"+clip", "a.mov", "duration=1", "layer=-1"},
+		{"+clip", "a.mov", "duration=1", "bogus"},
+		{"+effect", "agingtv"},
+		{"+clip", "a.mov", "duration=1", "+effect"},
+		{"+track", "subtitles"},
+		{"+keyframes", "a"},
+	} {
+		if _, err := ParseLaunchArgs(args); err == nil {
+			t.Errorf("Expected an error for %q", args)
+		}
+	}
+}
+
+func TestParseLaunchArgs_Tracks(t *testing.T) {
+	ges, err := ParseLaunchArgs([]string{"+track", "video", "+track", "text", "+track", "custom", "+clip", "file:///media/a.mov", "duration=1.0"})
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+	tracks := ges.Project.Timeline.Tracks
+	if len(tracks) != 3 || tracks[1].TrackType != TrackTypeText || tracks[1].Caps != "text/x-raw(ANY)" || tracks[2].TrackType != TrackTypeCustom || tracks[2].Caps != "ANY" {
+		t.Fatalf("Expected video, text and custom tracks, got %+v", tracks)
+	}
+	if clip := ges.Project.Timeline.Layers[0].Clips[0]; clip.TrackTypes != TrackTypeVideo {
+		t.Errorf("Expected a video clip, got track types %d", clip.TrackTypes)
+	}
+
+	args := LaunchArgs(ges)
+	if line := strings.Join(args, " "); !strings.HasPrefix(line, "+track video +track text +track custom +clip") {
+		t.Errorf("Unexpected arguments %s", line)
+	}
+}
+
+func TestParseLaunchArgs_NoDuration(t *testing.T) {
+	input := []string{"+clip", "file:///media/a.mov", "duration=2.0", "+clip", "file:///media/b.mov", "inpoint=1.0"}
+	ges, err := ParseLaunchArgs(input)
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+	clip := ges.Project.Timeline.Layers[0].Clips[1]
+	if clip.Start != 2*GSTSecond || clip.Duration != gstClockTimeNone || clip.End() != gstClockTimeNone {
+		t.Errorf("Expected a clip at 2s with its duration left to GES, got %+v", clip)
+	}
+	if duration := ges.Project.Timeline.Duration(); duration != 2*GSTSecond {
+		t.Errorf("Expected the timeline to last until the clip without a duration, got %d", duration)
+	}
+	if output := LaunchArgs(ges); !reflect.DeepEqual(input, output) {
+		t.Errorf("Round trip changed the arguments:\n%q\n%q", input, output)
+	}
+
+	decoder := NewDecoder(nil)
+	timeline, err := decoder.DecodeDocument(ges)
+	if err != nil {
+		t.Fatalf("DecodeDocument failed: %v", err)
+	}
+	if video := timeline.VideoTracks(); len(video) != 1 || len(video[0].Children()) != 1 || len(decoder.Warnings()) != 1 {
+		t.Errorf("Expected the clip without a duration dropped with a warning, got %d video tracks and %q", len(video), decoder.Warnings())
+	}
+}
+
+func TestEncodeLaunchArgs_Warnings(t *testing.T) {
+	ges, err := ParseLaunchArgs([]string{"+clip", "file:///media/a.mov", "duration=1.0", "+clip", "file:///media/b.mov", "duration=1.0", "+clip", "file:///media/c.mov", "duration=1.0"})
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+	clips := ges.Project.Timeline.Layers[0].Clips
+	clips[0].TrackTypes = TrackTypeAudio
+	clips[1].SetMute(true)
+	clips[2].Sources = []Source{{TrackID: 0, Properties: "properties, alpha=(double)0.5;"}}
+
+	encoder := NewEncoder(nil)
+	if _, err := encoder.EncodeProjectLaunchArgs(ges); err != nil {
+		t.Fatalf("EncodeProjectLaunchArgs failed: %v", err)
+	}
+	expected := []string{
+		"clip 0: track types 2 dropped, ges-launch puts clips in every track of their media",
+		"clip 1: mute dropped, ges-launch can't express it",
+		"clip 2: per-track sources dropped, ges-launch can't express them",
+	}
+	if !reflect.DeepEqual(encoder.Warnings(), expected) {
+		t.Errorf("Expected warnings %q, got %q", expected, encoder.Warnings())
+	}
+
+	encoder.SetOptions(EncodeOptions{Strict: true})
+	if _, err := encoder.EncodeProjectLaunchArgs(ges); err == nil {
+		t.Error("Expected an error in strict mode")
+	}
+}
+
+func TestLaunchArgs_QualifiedTitleText(t *testing.T) {
+	ges, err := ParseLaunchArgs([]string{"+title", "Hello", "duration=1.0"})
+	if err != nil {
+		t.Fatalf("ParseLaunchArgs failed: %v", err)
+	}
+	// GES and Pitivi save the text with the element name
+	clip := &ges.Project.Timeline.Layers[0].Clips[0]
+	clip.ChildrenProperties = `properties, GstTextOverlay::text=(string)"Hello\ there", GstTextOverlay::valignment=(int)1;`
+
+	line := strings.Join(LaunchArgs(ges), " ")
+	if line != "+title Hello there duration=1.0 set-GstTextOverlay::valignment=1" {
+		t.Errorf("Unexpected arguments %s", line)
+	}
+}
+
+func TestLaunchCommand(t *testing.T) {
+	args := []string{"+title", "It's here", "duration=1.0", `set-text="a\ b"`}
+	line := FormatLaunchCommand(args)
+	if line != `ges-launch-1.0 +title 'It'\''s here' duration=1.0 'set-text="a\ b"'` {
+		t.Errorf("Unexpected command line %s", line)
+	}
+
+	parsed, err := ParseLaunchCommand(line)
+	if err != nil {
+		t.Fatalf("ParseLaunchCommand failed: %v", err)
+	}
+	if !reflect.DeepEqual(parsed[1:], args) {
+		t.Errorf("Expected %q, got %q", args, parsed[1:])
+	}
+
+	if _, err := ParseLaunchCommand(`+title "unterminated`); err == nil {
+		t.Error("Expected an error for an unterminated quote")
+	}
+}
+
+func TestLaunchTime(t *testing.T) {
+	testCases := map[string]uint64{
+		"0.0":         0,
+		"2":           2 * GSTSecond,
+		"1.04":        1040000000,
+		".5":          500000000,
+		"3.000000001": 3000000001,
+	}
+	for input, expected := range testCases {
+		ns, err := parseLaunchTime(input)
+		if err != nil || ns != expected {
+			t.Errorf("parseLaunchTime(%q) = %d, %v, expected %d", input, ns, err, expected)
+		}
+	}
+	if got := formatLaunchTime(3000000001); got != "3.000000001" {
+		t.Errorf("Unexpected formatted time %s", got)
+	}
+	for _, input := range []string{"", ".", "-1", "1.0000000001", "1:00"} {
+		if _, err := parseLaunchTime(input); err == nil {
+			t.Errorf("Expected an error for %q", input)
+		}
+	}
+}
+
+func TestLaunchArgs_OTIO(t *testing.T) {
+	timeline := gotio.NewTimeline("Launch", nil, nil)
+	track := gotio.NewTrack("V1", nil, gotio.TrackKindVideo, nil, nil)
+	sourceRange := opentime.NewTimeRange(opentime.NewRationalTime(24, 24), opentime.NewRationalTime(48, 24))
+	ref := gotio.NewExternalReference("", "file:///media/a.mov", nil, nil)
+	track.AppendChild(gotio.NewClip("a", ref, &sourceRange, nil, nil, nil, "", nil))
+	timeline.Tracks().AppendChild(track)
+
+	args, err := NewEncoder(nil).EncodeLaunchArgs(timeline)
+	if err != nil {
+		t.Fatalf("EncodeLaunchArgs failed: %v", err)
+	}
+	line := strings.Join(args, " ")
+	if !strings.Contains(line, "+clip file:///media/a.mov name=a duration=2.0 inpoint=1.0") {
+		t.Errorf("Unexpected arguments %s", line)
+	}
+
+	decoded, err := NewDecoder(nil).DecodeLaunchArgs(args)
+	if err != nil {
+		t.Fatalf("DecodeLaunchArgs failed: %v", err)
+	}
+	if len(decoded.VideoTracks()) != 1 {
+		t.Errorf("Expected a video track, got %d", len(decoded.VideoTracks()))
+	}
+}
@@ -0,0 +1,509 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Severity is the importance of a lint issue
+type Severity string
+
+// Lint severities, from least to most important
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Level ranks the severity for comparisons, 0 for an unknown severity
+func (s Severity) Level() int {
+	switch s {
+	case SeverityInfo:
+		return 1
+	case SeverityWarning:
+		return 2
+	case SeverityError:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// ParseSeverity parses "info", "warning" or "error"
+func ParseSeverity(s string) (Severity, error) {
+	if severity := Severity(s); severity.Level() > 0 {
+		return severity, nil
+	}
+	return "", fmt.Errorf("unknown severity %q, expected info, warning or error", s)
+}
+
+// Issue is a problem found in a project by a lint rule
+type Issue struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Layer is the priority of the layer, or -1 for project-wide issues
+	Layer int    `json:"layer"`
+	Clips []int  `json:"clips,omitempty"`
+	Asset string `json:"asset,omitempty"`
+	// Start and Duration locate the issue on the timeline, in nanoseconds
+	Start    uint64 `json:"start"`
+	Duration uint64 `json:"duration"`
+}
+
+// LintRule is a named check run over a project
+type LintRule struct {
+	Name        string
+	Description string
+	Severity    Severity
+	// Check returns the issues found; Rule and Severity are filled in by Lint
+	Check func(ctx *LintContext) []Issue
+}
+
+// LintOptions configures Lint
+type LintOptions struct {
+	// Rate overrides the project frame rate used to measure frames
+	Rate float64
+	// MinClipFrames is the length under which a clip is a flash frame,
+	// 3 frames if zero
+	MinClipFrames int
+	// Rules replaces the built-in rules when set
+	Rules []LintRule
+	// Disable lists the names of rules not to run
+	Disable []string
+	// Severities overrides the severity of rules by name
+	Severities map[string]Severity
+}
+
+// LintContext is the project and configuration a rule checks
+type LintContext struct {
+	GES     *GES
+	Rate    float64
+	Options LintOptions
+}
+
+// Frames converts nanoseconds to frames at the lint frame rate
+func (c *LintContext) Frames(ns uint64) float64 {
+	return float64(ns) * c.Rate / GSTSecond
+}
+
+// LintRules returns the built-in lint rules
+func LintRules() []LintRule {
+	return []LintRule{
+		{"flash-frame", "clips shorter than the minimum number of frames", SeverityWarning, lintFlashFrames},
+		{"subframe-gap", "gaps shorter than a frame between clips, usually from rounding", SeverityWarning, lintSubframeGaps},
+		{"overlap", "clips overlapping on a layer without a transition", SeverityError, lintOverlaps},
+		{"trimmed-past-asset", "clips using media past the end of their asset", SeverityError, lintTrimmedPastAsset},
+		{"unused-asset", "media assets not used by any clip", SeverityInfo, lintUnusedAssets},
+		{"transition-too-long", "transitions longer than one of the clips they join", SeverityError, lintTransitionTooLong},
+		{"hidden-layer", "layers whose video is entirely covered by full-frame clips above", SeverityWarning, lintHiddenLayers},
+	}
+}
+
+// Lint checks a project for edit problems. It fails if the options name a
+// rule that doesn't exist or an unknown severity.
+func Lint(ges *GES, opts LintOptions) ([]Issue, error) {
+	rules := opts.Rules
+	if rules == nil {
+		rules = LintRules()
+	}
+
+	known := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		known[rule.Name] = true
+	}
+	disabled := make(map[string]bool, len(opts.Disable))
+	for _, name := range opts.Disable {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		disabled[name] = true
+	}
+	for name, severity := range opts.Severities {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown lint rule %q", name)
+		}
+		if severity.Level() == 0 {
+			return nil, fmt.Errorf("unknown severity %q for lint rule %s", severity, name)
+		}
+	}
+
+	if opts.MinClipFrames <= 0 {
+		opts.MinClipFrames = 3
+	}
+	ctx := &LintContext{GES: ges, Rate: opts.Rate, Options: opts}
+	if ctx.Rate <= 0 {
+		ctx.Rate = ges.Project.Timeline.FrameRate()
+	}
+	if ctx.Rate <= 0 {
+		ctx.Rate = 25.0
+	}
+
+	var issues []Issue
+	for _, rule := range rules {
+		if disabled[rule.Name] {
+			continue
+		}
+		severity := rule.Severity
+		if override, ok := opts.Severities[rule.Name]; ok {
+			severity = override
+		}
+		for _, issue := range rule.Check(ctx) {
+			issue.Rule = rule.Name
+			issue.Severity = severity
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// lintFlashFrames reports clips shorter than MinClipFrames
+func lintFlashFrames(ctx *LintContext) []Issue {
+	var issues []Issue
+	for _, layer := range ctx.GES.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			if clip.TypeName == ClipTypeTransition {
+				continue
+			}
+			if frames := ctx.Frames(clip.Duration); frames < float64(ctx.Options.MinClipFrames) {
+				issues = append(issues, Issue{
+					Message:  fmt.Sprintf("clip %d is %.3g frames long, under %d", clip.ID, frames, ctx.Options.MinClipFrames),
+					Layer:    layer.Priority,
+					Clips:    []int{clip.ID},
+					Start:    clip.Start,
+					Duration: clip.Duration,
+				})
+			}
+		}
+	}
+	return issues
+}
+
+// lintSubframeGaps reports gaps under a frame between consecutive clips
+func lintSubframeGaps(ctx *LintContext) []Issue {
+	var issues []Issue
+	forEachNeighbours(ctx.GES, func(layer *Layer, prev, next *Clip, prevEnd uint64) {
+		if next.Start <= prevEnd {
+			return
+		}
+		gap := next.Start - prevEnd
+		if frames := ctx.Frames(gap); frames < 1 {
+			issues = append(issues, Issue{
+				Message:  fmt.Sprintf("%.3g frame gap (%dns) between clips %d and %d", frames, gap, prev.ID, next.ID),
+				Layer:    layer.Priority,
+				Clips:    []int{prev.ID, next.ID},
+				Start:    prevEnd,
+				Duration: gap,
+			})
+		}
+	})
+	return issues
+}
+
+// lintOverlaps reports overlapping clips not covered by a transition
+func lintOverlaps(ctx *LintContext) []Issue {
+	var issues []Issue
+	forEachNeighbours(ctx.GES, func(layer *Layer, prev, next *Clip, prevEnd uint64) {
+		if next.Start >= prevEnd {
+			return
+		}
+		end := min(prevEnd, next.Start+next.Duration)
+		for _, t := range layer.Clips {
+			if t.TypeName == ClipTypeTransition && t.TrackTypes&prev.TrackTypes&next.TrackTypes != 0 &&
+				t.Start <= next.Start && t.Start+t.Duration >= end {
+				return
+			}
+		}
+		issues = append(issues, Issue{
+			Message:  fmt.Sprintf("clips %d and %d overlap by %.3g frames without a transition", prev.ID, next.ID, ctx.Frames(end-next.Start)),
+			Layer:    layer.Priority,
+			Clips:    []int{prev.ID, next.ID},
+			Start:    next.Start,
+			Duration: end - next.Start,
+		})
+	})
+	return issues
+}
+
+// lintTrimmedPastAsset reports clips whose out point is after the end of
+// their asset
+func lintTrimmedPastAsset(ctx *LintContext) []Issue {
+	durations := make(map[string]uint64)
+	for _, asset := range ctx.GES.Project.Assets() {
+		if duration, ok := asset.Duration(); ok && duration > 0 && duration != math.MaxUint64 {
+			durations[asset.ID] = duration
+		}
+	}
+
+	var issues []Issue
+	for _, layer := range ctx.GES.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			if clip.TypeName != ClipTypeURI {
+				continue
+			}
+			duration, ok := durations[clip.AssetID]
+			if !ok {
+				continue
+			}
+
+			// Time effects change how much of the media the clip reads
+			read, scalar := clip.Duration, 1.0
+			for _, trackType := range []int{TrackTypeVideo, TrackTypeAudio} {
+				if clip.TrackTypes&trackType != 0 && clip.SourceDuration(trackType) > read {
+					read, scalar = clip.SourceDuration(trackType), clip.TimeScalar(trackType)
+				}
+			}
+			if clip.Inpoint+read <= duration {
+				continue
+			}
+			over := clip.Inpoint + read - duration
+			overTimeline := min(uint64(float64(over)/scalar), clip.Duration)
+			issues = append(issues, Issue{
+				Message:  fmt.Sprintf("clip %d ends %.3g frames past the end of its media", clip.ID, ctx.Frames(over)),
+				Layer:    layer.Priority,
+				Clips:    []int{clip.ID},
+				Asset:    clip.AssetID,
+				Start:    clip.Start + clip.Duration - overTimeline,
+				Duration: overTimeline,
+			})
+		}
+	}
+	return issues
+}
+
+// lintUnusedAssets reports media assets no clip uses, directly or through
+// a proxy
+func lintUnusedAssets(ctx *LintContext) []Issue {
+	assets := ctx.GES.Project.Assets()
+
+	used := make(map[string]bool)
+	for _, layer := range ctx.GES.Project.Timeline.Layers {
+		for _, clip := range layer.Clips {
+			used[clip.AssetID] = true
+		}
+	}
+	// Proxies of a used asset, and originals of a used proxy, are used
+	for changed := true; changed; {
+		changed = false
+		for _, asset := range assets {
+			if asset.ProxyID == "" || used[asset.ID] == used[asset.ProxyID] {
+				continue
+			}
+			used[asset.ID], used[asset.ProxyID] = true, true
+			changed = true
+		}
+	}
+
+	var issues []Issue
+	for _, asset := range assets {
+		if asset.ExtractableTypeName != ClipTypeURI || used[asset.ID] {
+			continue
+		}
+		issues = append(issues, Issue{
+			Message: fmt.Sprintf("asset %s is not used", asset.ID),
+			Layer:   -1,
+			Asset:   asset.ID,
+		})
+	}
+	return issues
+}
+
+// lintTransitionTooLong reports transitions longer than a clip they join
+func lintTransitionTooLong(ctx *LintContext) []Issue {
+	var issues []Issue
+	for _, layer := range ctx.GES.Project.Timeline.Layers {
+		for _, t := range layer.Clips {
+			if t.TypeName != ClipTypeTransition {
+				continue
+			}
+			for _, clip := range layer.Clips {
+				if clip.TypeName == ClipTypeTransition || clip.TrackTypes&t.TrackTypes == 0 ||
+					clip.Start >= t.Start+t.Duration || clip.Start+clip.Duration <= t.Start {
+					continue
+				}
+				if t.Duration > clip.Duration {
+					issues = append(issues, Issue{
+						Message:  fmt.Sprintf("transition %d is longer than clip %d (%.3g > %.3g frames)", t.ID, clip.ID, ctx.Frames(t.Duration), ctx.Frames(clip.Duration)),
+						Layer:    layer.Priority,
+						Clips:    []int{t.ID, clip.ID},
+						Start:    t.Start,
+						Duration: t.Duration,
+					})
+				}
+			}
+		}
+	}
+	return issues
+}
+
+// lintHiddenLayers reports layers whose video clips are all covered by
+// full-frame clips on layers above them
+func lintHiddenLayers(ctx *LintContext) []Issue {
+	timeline := &ctx.GES.Project.Timeline
+	layers := make([]*Layer, len(timeline.Layers))
+	for i := range timeline.Layers {
+		layers[i] = &timeline.Layers[i]
+	}
+	sort.SliceStable(layers, func(i, j int) bool { return layers[i].Priority < layers[j].Priority })
+
+	width, height := timelineSize(timeline)
+
+	var issues []Issue
+	var above []span
+	for _, layer := range layers {
+		covered := mergeSpans(above)
+		hidden, visible := 0, false
+		var start, end uint64
+		for _, clip := range layer.Clips {
+			if clip.TypeName == ClipTypeTransition || clip.TrackTypes&TrackTypeVideo == 0 {
+				continue
+			}
+			if !covers(covered, span{clip.Start, clip.Start + clip.Duration}) {
+				visible = true
+			}
+			if hidden == 0 || clip.Start < start {
+				start = clip.Start
+			}
+			end = max(end, clip.Start+clip.Duration)
+			hidden++
+		}
+		if hidden > 0 && !visible {
+			issues = append(issues, Issue{
+				Message:  fmt.Sprintf("video of layer %d is hidden under full-frame clips", layer.Priority),
+				Layer:    layer.Priority,
+				Start:    start,
+				Duration: end - start,
+			})
+		}
+
+		for _, clip := range layer.Clips {
+			if isFullFrame(&clip, width, height) {
+				above = append(above, span{clip.Start, clip.Start + clip.Duration})
+			}
+		}
+	}
+	return issues
+}
+
+// forEachNeighbours calls fn for every clip of a layer and the clip ending
+// last before it on a shared track type. Transitions are skipped and each
+// pair is visited once.
+func forEachNeighbours(ges *GES, fn func(layer *Layer, prev, next *Clip, prevEnd uint64)) {
+	for l := range ges.Project.Timeline.Layers {
+		layer := &ges.Project.Timeline.Layers[l]
+		seen := make(map[[2]int]bool)
+		for _, trackType := range []int{TrackTypeAudio, TrackTypeVideo, TrackTypeText, TrackTypeCustom} {
+			var clips []*Clip
+			for i := range layer.Clips {
+				clip := &layer.Clips[i]
+				if clip.TypeName != ClipTypeTransition && clip.TrackTypes&trackType != 0 {
+					clips = append(clips, clip)
+				}
+			}
+			sort.SliceStable(clips, func(i, j int) bool { return clips[i].Start < clips[j].Start })
+
+			var prev *Clip
+			var prevEnd uint64
+			for _, clip := range clips {
+				if prev != nil && !seen[[2]int{prev.ID, clip.ID}] {
+					seen[[2]int{prev.ID, clip.ID}] = true
+					fn(layer, prev, clip, prevEnd)
+				}
+				if end := clip.Start + clip.Duration; prev == nil || end > prevEnd {
+					prev, prevEnd = clip, end
+				}
+			}
+		}
+	}
+}
+
+// isFullFrame reports whether a clip covers the whole frame opaquely
+func isFullFrame(clip *Clip, width, height int64) bool {
+	if clip.TrackTypes&TrackTypeVideo == 0 || (clip.TypeName != ClipTypeURI && clip.TypeName != ClipTypeTest) {
+		return false
+	}
+	// Compositing is set on the clip or, since GES 1.18, on its sources
+	settings := []string{clip.ChildrenProperties}
+	for _, source := range clip.Sources {
+		settings = append(settings, source.ChildrenProperties)
+	}
+	for _, s := range settings {
+		props, err := ParseStructure(s)
+		if err != nil {
+			continue
+		}
+		if name, ok := props.ChildPropertyName("alpha"); ok {
+			if alpha, ok := props.GetFloat(name); ok && alpha < 1 {
+				return false
+			}
+		}
+		for _, property := range []string{"posx", "posy"} {
+			if name, ok := props.ChildPropertyName(property); ok {
+				if pos, ok := props.GetInt(name); ok && pos != 0 {
+					return false
+				}
+			}
+		}
+		if name, ok := props.ChildPropertyName("width"); ok {
+			if w, ok := props.GetInt(name); ok && width > 0 && w < width {
+				return false
+			}
+		}
+		if name, ok := props.ChildPropertyName("height"); ok {
+			if h, ok := props.GetInt(name); ok && height > 0 && h < height {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// timelineSize returns the frame size from the video restriction caps
+func timelineSize(timeline *Timeline) (int64, int64) {
+	for _, track := range timeline.Tracks {
+		if track.TrackType != TrackTypeVideo {
+			continue
+		}
+		if caps, ok := track.RestrictionCaps(); ok {
+			width, _ := caps.GetInt("width")
+			height, _ := caps.GetInt("height")
+			return width, height
+		}
+	}
+	return 0, 0
+}
+
+// span is a half-open time range in nanoseconds
+type span struct {
+	start, end uint64
+}
+
+// mergeSpans sorts spans and joins the ones touching or overlapping
+func mergeSpans(spans []span) []span {
+	sorted := append([]span(nil), spans...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start < sorted[j].start })
+
+	var merged []span
+	for _, s := range sorted {
+		if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+			merged[n-1].end = max(merged[n-1].end, s.end)
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+// covers reports whether s lies inside one of the merged spans
+func covers(merged []span, s span) bool {
+	for _, m := range merged {
+		if m.start <= s.start && s.end <= m.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,231 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"testing"
+)
+
+// lintProject builds a 25fps 1920x1080 project with the given layers
+func lintProject(layers ...Layer) *GES {
+	return &GES{
+		Version: "0.3",
+		Project: Project{
+			Ressources: &Ressources{Assets: []Asset{
+				{ID: "file:///a.mov", ExtractableTypeName: ClipTypeURI, Properties: "properties, duration=(guint64)10000000000;"},
+				{ID: "file:///b.mov", ExtractableTypeName: ClipTypeURI, Properties: "properties, duration=(guint64)10000000000;"},
+			}},
+			Timeline: Timeline{
+				Tracks: []Track{{
+					Caps:       "video/x-raw(ANY)",
+					TrackType:  TrackTypeVideo,
+					Properties: `properties, restriction-caps=(string)"video/x-raw\,\ width\=\(int\)1920\,\ height\=\(int\)1080\,\ framerate\=\(fraction\)25/1";`,
+				}},
+				Layers: layers,
+			},
+		},
+	}
+}
+
+func lintClip(id int, asset string, start, duration uint64) Clip {
+	return Clip{ID: id, AssetID: asset, TypeName: ClipTypeURI, TrackTypes: TrackTypeVideo, Start: start, Duration: duration}
+}
+
+// ruleIssues returns the issues reported by one rule
+func ruleIssues(issues []Issue, rule string) []Issue {
+	var found []Issue
+	for _, issue := range issues {
+		if issue.Rule == rule {
+			found = append(found, issue)
+		}
+	}
+	return found
+}
+
+func TestLint_Clean(t *testing.T) {
+	ges := lintProject(Layer{Priority: 0, Clips: []Clip{
+		lintClip(0, "file:///a.mov", 0, 2*GSTSecond),
+		lintClip(1, "file:///b.mov", 2*GSTSecond, 2*GSTSecond),
+	}})
+
+	issues, err := Lint(ges, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestLint_Rules(t *testing.T) {
+	const frame = GSTSecond / 25
+
+	ges := lintProject(
+		Layer{Priority: 0, Clips: []Clip{
+			lintClip(0, "file:///a.mov", 0, 2*frame),                 // flash frame
+			lintClip(1, "file:///a.mov", 2*frame+frame/2, 100*frame), // sub-frame gap
+			lintClip(2, "file:///a.mov", 100*frame, 100*frame),       // overlap
+			{ID: 3, AssetID: "crossfade", TypeName: ClipTypeTransition, TrackTypes: TrackTypeVideo, Start: 190 * frame, Duration: 20 * frame},
+			lintClip(4, "file:///a.mov", 190*frame, 10*frame), // shorter than the transition
+		}},
+		Layer{Priority: 1, Clips: []Clip{
+			{ID: 5, AssetID: "file:///a.mov", TypeName: ClipTypeURI, TrackTypes: TrackTypeVideo, Start: 10 * frame, Duration: 50 * frame, Inpoint: 240 * frame},
+		}},
+	)
+	ges.Project.Ressources.Assets = append(ges.Project.Ressources.Assets, Asset{ID: "file:///c.mov", ExtractableTypeName: ClipTypeURI})
+
+	issues, err := Lint(ges, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+
+	testCases := []struct {
+		rule     string
+		severity Severity
+		clips    []int
+		asset    string
+	}{
+		{"flash-frame", SeverityWarning, []int{0}, ""},
+		{"subframe-gap", SeverityWarning, []int{0, 1}, ""},
+		{"overlap", SeverityError, []int{1, 2}, ""},
+		{"trimmed-past-asset", SeverityError, []int{5}, "file:///a.mov"},
+		{"transition-too-long", SeverityError, []int{3, 4}, ""},
+		{"hidden-layer", SeverityWarning, nil, ""},
+	}
+	for _, tc := range testCases {
+		found := ruleIssues(issues, tc.rule)
+		if len(found) != 1 {
+			t.Errorf("Expected one %s issue, got %v", tc.rule, found)
+			continue
+		}
+		issue := found[0]
+		if issue.Severity != tc.severity {
+			t.Errorf("%s: expected severity %s, got %s", tc.rule, tc.severity, issue.Severity)
+		}
+		if len(issue.Clips) != len(tc.clips) {
+			t.Errorf("%s: expected clips %v, got %v", tc.rule, tc.clips, issue.Clips)
+		} else {
+			for i := range tc.clips {
+				if issue.Clips[i] != tc.clips[i] {
+					t.Errorf("%s: expected clips %v, got %v", tc.rule, tc.clips, issue.Clips)
+				}
+			}
+		}
+		if issue.Asset != tc.asset {
+			t.Errorf("%s: expected asset %q, got %q", tc.rule, tc.asset, issue.Asset)
+		}
+	}
+
+	unused := ruleIssues(issues, "unused-asset")
+	if len(unused) != 2 {
+		t.Errorf("Expected b.mov and c.mov unused, got %v", unused)
+	}
+
+	// Layer 1 is hidden under clip 2
+	if hidden := ruleIssues(issues, "hidden-layer"); len(hidden) == 1 && hidden[0].Layer != 1 {
+		t.Errorf("Expected layer 1 hidden, got layer %d", hidden[0].Layer)
+	}
+}
+
+func TestLint_TransitionCoversOverlap(t *testing.T) {
+	ges := lintProject(Layer{Priority: 0, Clips: []Clip{
+		lintClip(0, "file:///a.mov", 0, 3*GSTSecond),
+		{ID: 1, AssetID: "crossfade", TypeName: ClipTypeTransition, TrackTypes: TrackTypeVideo, Start: 2 * GSTSecond, Duration: GSTSecond},
+		lintClip(2, "file:///b.mov", 2*GSTSecond, 3*GSTSecond),
+	}})
+
+	issues, err := Lint(ges, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+}
+
+func TestLint_HiddenLayerPartialFrame(t *testing.T) {
+	top := lintClip(0, "file:///a.mov", 0, 10*GSTSecond)
+	top.ChildrenProperties = "properties, width=(int)960, height=(int)540;"
+
+	ges := lintProject(
+		Layer{Priority: 0, Clips: []Clip{top}},
+		Layer{Priority: 1, Clips: []Clip{lintClip(1, "file:///b.mov", 0, 5*GSTSecond)}},
+	)
+
+	issues, err := Lint(ges, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if hidden := ruleIssues(issues, "hidden-layer"); len(hidden) != 0 {
+		t.Errorf("Expected no hidden layer under a scaled clip, got %v", hidden)
+	}
+}
+
+func TestLint_Options(t *testing.T) {
+	ges := lintProject(Layer{Priority: 0, Clips: []Clip{
+		lintClip(0, "file:///a.mov", 0, 2*GSTSecond/25),
+		lintClip(1, "file:///b.mov", 2*GSTSecond/25, 2*GSTSecond),
+	}})
+
+	issues, err := Lint(ges, LintOptions{Severities: map[string]Severity{"flash-frame": SeverityError}})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if len(issues) != 1 || issues[0].Severity != SeverityError {
+		t.Errorf("Expected one flash frame error, got %v", issues)
+	}
+
+	issues, _ = Lint(ges, LintOptions{MinClipFrames: 2})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues with a 2 frame minimum, got %v", issues)
+	}
+
+	issues, _ = Lint(ges, LintOptions{Disable: []string{"flash-frame"}, Rate: 10})
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues with flash-frame disabled, got %v", issues)
+	}
+
+	if _, err := Lint(ges, LintOptions{Disable: []string{"nope"}}); err == nil {
+		t.Error("Expected an error for an unknown rule")
+	}
+	if _, err := Lint(ges, LintOptions{Severities: map[string]Severity{"overlap": "fatal"}}); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+
+	custom := LintRule{Name: "no-clips", Severity: SeverityInfo, Check: func(ctx *LintContext) []Issue {
+		return []Issue{{Message: "custom", Layer: -1}}
+	}}
+	issues, _ = Lint(ges, LintOptions{Rules: []LintRule{custom}})
+	if len(issues) != 1 || issues[0].Rule != "no-clips" || issues[0].Severity != SeverityInfo {
+		t.Errorf("Expected the custom rule issue, got %v", issues)
+	}
+}
+
+func TestLint_Example(t *testing.T) {
+	ges := loadTestProject(t, "xges_example.xges")
+
+	issues, err := Lint(ges, LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	for _, issue := range issues {
+		if issue.Severity.Level() == 0 || issue.Message == "" {
+			t.Errorf("Malformed issue %+v", issue)
+		}
+	}
+}
+
+func TestParseSeverity(t *testing.T) {
+	for _, s := range []string{"info", "warning", "error"} {
+		if severity, err := ParseSeverity(s); err != nil || string(severity) != s {
+			t.Errorf("ParseSeverity(%q) = %q, %v", s, severity, err)
+		}
+	}
+	if _, err := ParseSeverity("fatal"); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+	if SeverityError.Level() <= SeverityWarning.Level() || SeverityWarning.Level() <= SeverityInfo.Level() {
+		t.Error("Severity levels out of order")
+	}
+}
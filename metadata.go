@@ -0,0 +1,325 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// xgesMetadata returns the "xges" namespace of OTIO metadata, or nil
+func xgesMetadata(metadata gotio.AnyDictionary) map[string]interface{} {
+	return metadataMap(metadata["xges"])
+}
+
+// metadataMap reads a dictionary stored in metadata, or returns nil
+func metadataMap(v interface{}) map[string]interface{} {
+	switch m := v.(type) {
+	case map[string]interface{}:
+		return m
+	case gotio.AnyDictionary:
+		return m
+	}
+	return nil
+}
+
+// metadataInt reads an integer stored in metadata, which JSON
+// serialization may have turned into a float
+func metadataInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	case float64:
+		if n == math.Trunc(n) {
+			return int64(n), true
+		}
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// metadataFloat reads a number stored in metadata
+func metadataFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	}
+	if i, ok := metadataInt(v); ok {
+		return float64(i), true
+	}
+	return 0, false
+}
+
+// metadataBool reads a boolean stored in metadata
+func metadataBool(v interface{}) (bool, bool) {
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// metadataHolder is an OTIO object carrying metadata
+type metadataHolder interface {
+	Metadata() gotio.AnyDictionary
+	SetMetadata(metadata gotio.AnyDictionary)
+}
+
+// setXgesMetadata returns the "xges" namespace of an object's metadata,
+// creating it if needed
+func setXgesMetadata(obj metadataHolder) map[string]interface{} {
+	metadata := obj.Metadata()
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		obj.SetMetadata(metadata)
+	}
+	xgesMetadata := xgesMetadata(metadata)
+	if xgesMetadata == nil {
+		xgesMetadata = make(map[string]interface{})
+	}
+	metadata["xges"] = xgesMetadata
+	return xgesMetadata
+}
+
+// GstStructure types by the Go type their values are given in metadata
+var (
+	boolTypes  = map[string]bool{"boolean": true, "gboolean": true, "bool": true, "b": true}
+	intTypes   = map[string]bool{"int": true, "gint": true, "uint": true, "guint": true, "int64": true, "gint64": true, "uint64": true, "guint64": true, "long": true, "glong": true, "ulong": true, "gulong": true, "i": true, "u": true}
+	floatTypes = map[string]bool{"float": true, "gfloat": true, "double": true, "gdouble": true, "f": true, "d": true}
+)
+
+// fieldValue converts a structure field to a metadata value. Booleans,
+// integers, floating point numbers and strings get the matching Go type,
+// other values keep their serialized form.
+func fieldValue(f Field) interface{} {
+	switch {
+	case boolTypes[f.Type]:
+		if v, err := strconv.ParseBool(f.Value); err == nil {
+			return v
+		}
+	case intTypes[f.Type]:
+		if v, err := strconv.ParseInt(f.Value, 0, 64); err == nil {
+			return v
+		}
+		if v, err := strconv.ParseUint(f.Value, 0, 64); err == nil {
+			return v
+		}
+	case floatTypes[f.Type]:
+		if v, err := strconv.ParseFloat(f.Value, 64); err == nil {
+			return v
+		}
+	}
+	return f.Value
+}
+
+// formatFieldValue serializes a metadata value as a field of the given
+// type. It returns false if the value doesn't fit the type.
+func formatFieldValue(typ string, v interface{}) (string, bool) {
+	switch {
+	case boolTypes[typ]:
+		b, ok := metadataBool(v)
+		return strconv.FormatBool(b), ok
+	case intTypes[typ]:
+		if u, ok := v.(uint64); ok {
+			return strconv.FormatUint(u, 10), true
+		}
+		i, ok := metadataInt(v)
+		return strconv.FormatInt(i, 10), ok
+	case floatTypes[typ]:
+		f, ok := metadataFloat(v)
+		return strconv.FormatFloat(f, 'g', -1, 64), ok
+	}
+	str, ok := v.(string)
+	return str, ok
+}
+
+// fieldType returns the GstStructure type for a metadata value that has
+// none yet, or false if the value has no structure equivalent
+func fieldType(v interface{}) (string, bool) {
+	switch v.(type) {
+	case bool:
+		return "boolean", true
+	case string:
+		return "string", true
+	case uint64:
+		return "guint64", true
+	case float64, float32, json.Number:
+		return "double", true
+	}
+	if i, ok := metadataInt(v); ok {
+		if i < math.MinInt32 || i > math.MaxInt32 {
+			return "gint64", true
+		}
+		return "int", true
+	}
+	return "", false
+}
+
+// structureMetadata converts the fields of a structure to metadata values
+func structureMetadata(s *Structure) map[string]interface{} {
+	values := make(map[string]interface{}, len(s.Fields))
+	for _, f := range s.Fields {
+		values[f.Name] = fieldValue(f)
+	}
+	return values
+}
+
+// structureFromMetadata builds a structure from metadata values. The fields
+// of the structure it was decoded from, raw, keep their position and type;
+// fields missing from values are removed and new ones are added in name
+// order. Values without a structure equivalent are returned by name.
+func structureFromMetadata(name, raw string, values map[string]interface{}) (*Structure, []string) {
+	s, err := ParseStructure(raw)
+	if err != nil || raw == "" {
+		s = NewStructure(name)
+	}
+
+	var fields []Field
+	for _, f := range s.Fields {
+		v, ok := values[f.Name]
+		if !ok {
+			continue
+		}
+		if formatted, ok := formatFieldValue(f.Type, v); ok {
+			f.Value = formatted
+		} else if typ, ok := fieldType(v); ok {
+			f.Type = typ
+			f.Value, _ = formatFieldValue(typ, v)
+		}
+		fields = append(fields, f)
+	}
+	s.Fields = fields
+
+	var names, unsupported []string
+	for name := range values {
+		if !s.Has(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		typ, ok := fieldType(values[name])
+		if !ok {
+			unsupported = append(unsupported, name)
+			continue
+		}
+		value, _ := formatFieldValue(typ, values[name])
+		s.Set(name, typ, value)
+	}
+	return s, unsupported
+}
+
+// OTIOMetadataNamespace prefixes the clip metadatas fields holding the OTIO
+// metadata of a clip. Maps and lists are stored as JSON strings in fields
+// prefixed with OTIOMetadataNamespace + "json::".
+const OTIOMetadataNamespace = "otio::"
+
+// otioJSONNamespace prefixes the clip metadatas fields holding JSON values
+const otioJSONNamespace = OTIOMetadataNamespace + "json::"
+
+// validFieldName reports whether a name can be a GstStructure field name
+func validFieldName(name string) bool {
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if !isGstStringChar(name[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitClipMetadatas splits clip metadatas into the OTIO metadata stored in
+// the reserved namespace and the other fields, both typed
+func splitClipMetadatas(metadatas *Structure) (otio, fields map[string]interface{}) {
+	otio = make(map[string]interface{})
+	fields = make(map[string]interface{})
+	for _, f := range metadatas.Fields {
+		switch {
+		case strings.HasPrefix(f.Name, otioJSONNamespace):
+			dec := json.NewDecoder(strings.NewReader(f.Value))
+			dec.UseNumber()
+			var v interface{}
+			if err := dec.Decode(&v); err != nil {
+				fields[f.Name] = f.Value
+				continue
+			}
+			otio[strings.TrimPrefix(f.Name, otioJSONNamespace)] = jsonValue(v)
+		case strings.HasPrefix(f.Name, OTIOMetadataNamespace):
+			otio[strings.TrimPrefix(f.Name, OTIOMetadataNamespace)] = fieldValue(f)
+		default:
+			fields[f.Name] = fieldValue(f)
+		}
+	}
+	return otio, fields
+}
+
+// jsonValue converts decoded JSON to metadata values, with integers as
+// int64 and other numbers as float64
+func jsonValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, item := range t {
+			t[k] = jsonValue(item)
+		}
+	case []interface{}:
+		for i, item := range t {
+			t[i] = jsonValue(item)
+		}
+	}
+	return v
+}
+
+// setOTIOMetadatas stores OTIO metadata in clip metadatas under the
+// reserved namespace, in key order. Booleans, numbers and strings become
+// typed fields, other values JSON strings. The keys that can't be stored
+// are returned.
+func setOTIOMetadatas(metadatas *Structure, metadata gotio.AnyDictionary) []string {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		if key != "xges" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var dropped []string
+	for _, key := range keys {
+		value := metadata[key]
+		if typ, ok := fieldType(value); ok && validFieldName(OTIOMetadataNamespace+key) {
+			formatted, _ := formatFieldValue(typ, value)
+			metadatas.Set(OTIOMetadataNamespace+key, typ, formatted)
+			continue
+		}
+		data, err := json.Marshal(value)
+		if err != nil || !validFieldName(otioJSONNamespace+key) {
+			dropped = append(dropped, key)
+			continue
+		}
+		metadatas.SetString(otioJSONNamespace+key, string(data))
+	}
+	return dropped
+}
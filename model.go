@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"path"
+	"strconv"
+	"strings"
+)
+
+// FrameRate returns the frame rate declared by the timeline: the framerate
+// of the first video track restriction caps, else the framerate timeline
+// metadata. It returns 0 if neither is set.
+func (t *Timeline) FrameRate() float64 {
+	for _, track := range t.Tracks {
+		if track.TrackType != TrackTypeVideo {
+			continue
+		}
+		if caps, ok := track.RestrictionCaps(); ok {
+			if num, den, ok := caps.GetFraction("framerate"); ok && num > 0 {
+				return float64(num) / float64(den)
+			}
+		}
+	}
+
+	if metadatas, err := ParseStructure(t.Metadatas); err == nil {
+		if num, den, ok := metadatas.GetFraction("framerate"); ok && num > 0 {
+			return float64(num) / float64(den)
+		}
+	}
+
+	return 0
+}
+
+// Duration returns the end time of the last clip in nanoseconds. Clips
+// whose duration is left to GES only count up to their start.
+func (t *Timeline) Duration() uint64 {
+	var duration uint64
+	for _, layer := range t.Layers {
+		for _, clip := range layer.Clips {
+			end := clip.End()
+			if clip.Duration == gstClockTimeNone {
+				end = clip.Start
+			}
+			duration = max(duration, end)
+		}
+	}
+	return duration
+}
+
+// DeactivatedTrackIDs returns the ids of the tracks the layer is
+// deactivated in
+func (l *Layer) DeactivatedTrackIDs() map[int]bool {
+	ids := make(map[int]bool)
+	for _, field := range strings.Fields(l.DeactivatedTracks) {
+		if id, err := strconv.Atoi(field); err == nil {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// RestrictionCaps returns the parsed restriction-caps property of the track
+func (t *Track) RestrictionCaps() (*Structure, bool) {
+	props, err := ParseStructure(t.Properties)
+	if err != nil {
+		return nil, false
+	}
+	return props.GetStructure("restriction-caps")
+}
+
+// Duration returns the duration property of the asset in nanoseconds
+func (a *Asset) Duration() (uint64, bool) {
+	props, err := ParseStructure(a.Properties)
+	if err != nil {
+		return 0, false
+	}
+	return props.GetUint64("duration")
+}
+
+// stillImageExtensions are the file extensions of still image formats
+var stillImageExtensions = map[string]bool{
+	".png": true, ".jpg": true, ".jpeg": true, ".tif": true, ".tiff": true,
+	".bmp": true, ".webp": true, ".svg": true, ".tga": true, ".exr": true, ".dpx": true,
+}
+
+// IsStillImageURI reports whether a URI names a file in a still image format
+func IsStillImageURI(uri string) bool {
+	if IsImageSequenceURI(uri) {
+		return false
+	}
+	if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	return stillImageExtensions[strings.ToLower(path.Ext(uri))]
+}
+
+// IsStillImage reports whether the asset is a still image: a video-only
+// asset in a still image format, or one GES could not give a duration
+func (a *Asset) IsStillImage() bool {
+	props, err := ParseStructure(a.Properties)
+	if err != nil {
+		return false
+	}
+	if image, ok := props.GetBool("is-image"); ok {
+		return image
+	}
+	formats, ok := props.GetInt("supported-formats")
+	if !ok || formats != TrackTypeVideo {
+		return false
+	}
+	if duration, ok := props.GetUint64("duration"); !ok || duration == 0 || duration == gstClockTimeNone {
+		return true
+	}
+	return IsStillImageURI(a.ID)
+}
+
+// gstClockTimeNone is GST_CLOCK_TIME_NONE, the duration of streams without one
+const gstClockTimeNone = ^uint64(0)
+
+// Assets returns the assets listed in the project resources
+func (p *Project) Assets() []Asset {
+	if p.Ressources == nil {
+		return nil
+	}
+	return p.Ressources.Assets
+}
+
+// AllGroups returns the groups of the timeline
+func (t *Timeline) AllGroups() []Group {
+	if t.Groups == nil {
+		return nil
+	}
+	return t.Groups.Groups
+}
@@ -0,0 +1,78 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"encoding/xml"
+	"os"
+	"testing"
+)
+
+// loadTestProject parses an XGES file from testdata into the document model
+func loadTestProject(t *testing.T, name string) *GES {
+	t.Helper()
+
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+
+	var ges GES
+	if err := xml.Unmarshal(data, &ges); err != nil {
+		t.Fatalf("Failed to parse %s: %v", name, err)
+	}
+	return &ges
+}
+
+func TestModel_Helpers(t *testing.T) {
+	ges := loadTestProject(t, "xges_example.xges")
+	timeline := &ges.Project.Timeline
+
+	if rate := timeline.FrameRate(); rate != 25 {
+		t.Errorf("Expected frame rate 25, got %f", rate)
+	}
+	if duration := timeline.Duration(); duration != 36215932868 {
+		t.Errorf("Expected duration 36215932868, got %d", duration)
+	}
+
+	assets := ges.Project.Assets()
+	if len(assets) != 7 {
+		t.Fatalf("Expected 7 assets, got %d", len(assets))
+	}
+	if d, ok := assets[0].Duration(); !ok || d != 126615510204 {
+		t.Errorf("Unexpected asset duration %d", d)
+	}
+	if assets[1].ProxyID == "" {
+		t.Error("Expected proxy-id on the second asset")
+	}
+
+	groups := timeline.AllGroups()
+	if len(groups) != 1 || groups[0].ID != 7 || len(groups[0].Children) != 2 {
+		t.Errorf("Unexpected groups %+v", groups)
+	}
+
+	// Falls back to the timeline metadatas without restriction caps
+	noCaps := Timeline{Metadatas: "metadatas, framerate=(fraction)30000/1001;"}
+	if rate := noCaps.FrameRate(); rate < 29.97 || rate > 29.98 {
+		t.Errorf("Expected frame rate 29.97, got %f", rate)
+	}
+}
+
+func TestAsset_IsStillImage(t *testing.T) {
+	testCases := []struct {
+		asset    Asset
+		expected bool
+	}{
+		{Asset{ID: "file:///logo.png", Properties: "properties, supported-formats=(int)4, duration=(guint64)3000000000;"}, true},
+		{Asset{ID: "file:///frame", Properties: "properties, supported-formats=(int)4, duration=(guint64)18446744073709551615;"}, true},
+		{Asset{ID: "file:///clip.mov", Properties: "properties, supported-formats=(int)4, duration=(guint64)3000000000;"}, false},
+		{Asset{ID: "file:///cover.jpg", Properties: "properties, supported-formats=(int)6, duration=(guint64)3000000000;"}, false},
+		{Asset{ID: "file:///music.flac", Properties: "properties, supported-formats=(int)2;"}, false},
+	}
+	for _, tc := range testCases {
+		if got := tc.asset.IsStillImage(); got != tc.expected {
+			t.Errorf("IsStillImage(%s) = %v, expected %v", tc.asset.ID, got, tc.expected)
+		}
+	}
+}
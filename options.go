@@ -0,0 +1,63 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"sort"
+	"strings"
+)
+
+// DecodeOptions controls how XGES projects are converted to OTIO
+type DecodeOptions struct {
+	// Rate is the frame rate of the produced OTIO times. Zero detects it
+	// from the video track restriction caps, falling back to 25.
+	Rate float64
+	// Strict makes lossy conversions fail instead of recording warnings
+	Strict bool
+	// UseProxies references proxy media instead of the original assets
+	UseProxies bool
+	// PathMap rewrites media URIs starting with a key to start with its value
+	PathMap map[string]string
+}
+
+// EncodeOptions controls how OTIO timelines are converted to XGES
+type EncodeOptions struct {
+	// Rate overrides the frame rate detected from the timeline's clips
+	Rate float64
+	// Strict makes lossy conversions fail instead of recording warnings
+	Strict bool
+	// PathMap rewrites media URIs starting with a key to start with its value
+	PathMap map[string]string
+	// Version is the XGES format version to write, e.g. "0.4". Content the
+	// version can't express is dropped with a warning. Empty picks the
+	// oldest version the content fits in.
+	Version string
+	// AutoTransitions adds the transitions GES creates between overlapping
+	// clips of layers with auto-transition, in a timeline with
+	// auto-transition, as UpdateAutoTransitions does.
+	// Transitions joining no overlapping clips are dropped with a warning.
+	AutoTransitions bool
+}
+
+// remapPath rewrites uri with the longest matching prefix in pathMap
+func remapPath(uri string, pathMap map[string]string) string {
+	if len(pathMap) == 0 {
+		return uri
+	}
+
+	prefixes := make([]string, 0, len(pathMap))
+	for prefix := range pathMap {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Slice(prefixes, func(i, j int) bool {
+		return len(prefixes[i]) > len(prefixes[j])
+	})
+
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(uri, prefix) {
+			return pathMap[prefix] + uri[len(prefix):]
+		}
+	}
+	return uri
+}
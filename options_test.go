@@ -0,0 +1,246 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+	"github.com/Avalanche-io/gotio/opentime"
+)
+
+// targetURLs returns the media URLs of all clips in the timeline
+func targetURLs(timeline *gotio.Timeline) []string {
+	var urls []string
+	for _, child := range timeline.Tracks().Children() {
+		track, ok := child.(*gotio.Track)
+		if !ok {
+			continue
+		}
+		for _, item := range track.Children() {
+			if clip, ok := item.(*gotio.Clip); ok {
+				if ref, ok := clip.MediaReference().(*gotio.ExternalReference); ok {
+					urls = append(urls, ref.TargetURL())
+				}
+			}
+		}
+	}
+	return urls
+}
+
+func TestDecodeOptions_Proxies(t *testing.T) {
+	data, err := os.ReadFile("testdata/xges_example.xges")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+
+	const proxy = "raw_video.avi.11523200.proxy.mkv"
+
+	// By default clips using a proxy point at the original media
+	timeline, err := NewDecoder(bytes.NewReader(data)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	for _, url := range targetURLs(timeline) {
+		if strings.HasSuffix(url, proxy) {
+			t.Errorf("Expected original media instead of proxy %s", url)
+		}
+	}
+
+	decoder := NewDecoder(bytes.NewReader(data))
+	decoder.SetOptions(DecodeOptions{UseProxies: true})
+	timeline, err = decoder.Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	found := false
+	for _, url := range targetURLs(timeline) {
+		if strings.HasSuffix(url, "samples.multimedia.cx_testsuite_iv31.avi") {
+			continue
+		}
+		if !strings.Contains(url, ".proxy.mkv") && !strings.HasSuffix(url, ".flac") {
+			t.Errorf("Expected proxy media, got %s", url)
+		}
+		if strings.HasSuffix(url, proxy) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Proxy %s not referenced", proxy)
+	}
+}
+
+func TestDecodeOptions_PathMapAndRate(t *testing.T) {
+	decoder := NewDecoder(strings.NewReader(simpleXGES))
+	decoder.SetOptions(DecodeOptions{
+		Rate: 50,
+		PathMap: map[string]string{
+			"file:///example/":      "file:///mnt/a/",
+			"file:///example/video": "file:///mnt/v/video",
+		},
+	})
+	timeline, err := decoder.Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	if decoder.rate != 50 {
+		t.Errorf("Expected rate 50, got %f", decoder.rate)
+	}
+
+	urls := strings.Join(targetURLs(timeline), " ")
+	for _, expected := range []string{"file:///mnt/v/video.mp4", "file:///mnt/v/video2.mp4", "file:///mnt/a/audio.wav"} {
+		if !strings.Contains(urls, expected) {
+			t.Errorf("Expected %s in %s", expected, urls)
+		}
+	}
+}
+
+func TestEncodeOptions_NTSCRestrictionRate(t *testing.T) {
+	timeline, err := ReadString(simpleXGES)
+	if err != nil {
+		t.Fatalf("ReadString failed: %v", err)
+	}
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{Rate: 30000.0 / 1001})
+	ges, err := encoder.EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	caps, ok := ges.Project.Timeline.Tracks[0].RestrictionCaps()
+	if !ok {
+		t.Fatal("Expected video restriction caps")
+	}
+	if f, _ := caps.Get("framerate"); f.Value != "30000/1001" {
+		t.Errorf("Expected framerate 30000/1001, got %s", caps)
+	}
+}
+
+func TestDecodeOptions_Strict(t *testing.T) {
+	input := strings.Replace(simpleXGES, "GESUriClip", "GESOverlayClip", 1)
+
+	decoder := NewDecoder(strings.NewReader(input))
+	if _, err := decoder.Decode(); err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if len(decoder.Warnings()) != 1 || !strings.Contains(decoder.Warnings()[0], "GESOverlayClip") {
+		t.Errorf("Expected one warning about GESOverlayClip, got %v", decoder.Warnings())
+	}
+
+	decoder = NewDecoder(strings.NewReader(input))
+	decoder.SetOptions(DecodeOptions{Strict: true})
+	if _, err := decoder.Decode(); err == nil {
+		t.Error("Expected strict decode to fail")
+	}
+}
+
+func TestEncodeOptions(t *testing.T) {
+	timeline := gotio.NewTimeline("Options", nil, nil)
+	track := gotio.NewTrack("V1", nil, gotio.TrackKindVideo, nil, nil)
+	sourceRange := opentime.NewTimeRange(
+		opentime.NewRationalTime(0, 24),
+		opentime.NewRationalTime(24, 24),
+	)
+	ref := gotio.NewExternalReference("", "file:///Volumes/media/a.mov", nil, nil)
+	track.AppendChild(gotio.NewClip("a", ref, &sourceRange, nil, nil, nil, "", nil))
+	track.AppendChild(gotio.NewClip("b", nil, &sourceRange, nil, nil, nil, "", nil))
+	timeline.Tracks().AppendChild(track)
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	encoder.SetOptions(EncodeOptions{
+		Rate:    30,
+		PathMap: map[string]string{"file:///Volumes/media/": "file:///srv/media/"},
+	})
+	if err := encoder.Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+
+	output := buf.String()
+	if !strings.Contains(output, "file:///srv/media/a.mov") {
+		t.Error("Output missing remapped path")
+	}
+	if !strings.Contains(output, `framerate\=\(fraction\)30/1`) {
+		t.Error("Output missing forced frame rate")
+	}
+	if len(encoder.Warnings()) != 1 {
+		t.Errorf("Expected one warning for the clip without media, got %v", encoder.Warnings())
+	}
+
+	encoder = NewEncoder(&bytes.Buffer{})
+	encoder.SetOptions(EncodeOptions{Strict: true})
+	if err := encoder.Encode(timeline); err == nil {
+		t.Error("Expected strict encode to fail")
+	}
+}
+
+func TestEncodeOptions_AutoTransitions(t *testing.T) {
+	timeline := gotio.NewTimeline("Transitions", nil, nil)
+	track := gotio.NewTrack("V1", nil, gotio.TrackKindVideo, nil, nil)
+	sourceRange := opentime.NewTimeRange(
+		opentime.NewRationalTime(0, 24),
+		opentime.NewRationalTime(48, 24),
+	)
+	ref := gotio.NewExternalReference("", "file:///media/a.mov", nil, nil)
+	half := opentime.NewRationalTime(6, 24)
+	track.AppendChild(gotio.NewClip("a", ref, &sourceRange, nil, nil, nil, "", nil))
+	track.AppendChild(gotio.NewTransition("dissolve", gotio.TransitionTypeSMPTEDissolve, half, half, nil))
+	track.AppendChild(gotio.NewClip("b", ref, &sourceRange, nil, nil, nil, "", nil))
+	timeline.Tracks().AppendChild(track)
+
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{AutoTransitions: true})
+	ges, err := encoder.EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	// The transition is written between the clips, which GES would not keep
+	if n := len(transitions(&ges.Project.Timeline.Layers[0])); n != 0 {
+		t.Errorf("Expected the transition to be dropped, got %d transitions", n)
+	}
+	if warnings := encoder.Warnings(); len(warnings) != 1 || !strings.Contains(warnings[0], "dissolve") {
+		t.Errorf("Expected a warning for the dropped transition, got %v", warnings)
+	}
+
+	var streamed, written bytes.Buffer
+	encoder = NewEncoder(&streamed)
+	encoder.SetOptions(EncodeOptions{AutoTransitions: true})
+	if err := encoder.Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if err := WriteDocument(&written, ges); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+	if streamed.String() != written.String() {
+		t.Errorf("Encode output differs from the document:\n%s\nvs\n%s", streamed.String(), written.String())
+	}
+
+	encoder.SetOptions(EncodeOptions{AutoTransitions: true, Strict: true})
+	if err := encoder.Encode(timeline); err == nil {
+		t.Error("Expected strict encode to fail")
+	}
+}
+
+func TestRemapPath(t *testing.T) {
+	pathMap := map[string]string{
+		"file:///a/":   "file:///x/",
+		"file:///a/b/": "file:///y/",
+		"":             "ignored",
+	}
+
+	testCases := map[string]string{
+		"file:///a/c.mov":   "file:///x/c.mov",
+		"file:///a/b/c.mov": "file:///y/c.mov",
+		"file:///z/c.mov":   "file:///z/c.mov",
+	}
+	for input, expected := range testCases {
+		if got := remapPath(input, pathMap); got != expected {
+			t.Errorf("remapPath(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
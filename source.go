@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strconv"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// VideoSource holds the compositing settings of a clip in a video track
+type VideoSource struct {
+	PosX     int64
+	PosY     int64
+	Width    int64 // 0 keeps the size of the media
+	Height   int64
+	Alpha    float64
+	Operator string // compositor blending, such as "over" or "add"
+}
+
+// AudioSource holds the level of a clip in an audio track
+type AudioSource struct {
+	Volume float64
+	Mute   bool
+}
+
+// sourceField is a child property of a source that is given a type in
+// OTIO metadata
+type sourceField struct {
+	name      string
+	element   string // GType name qualifying the property
+	fieldType string
+}
+
+// sourceFields are the typed child properties of sources, by track type
+var sourceFields = map[int][]sourceField{
+	TrackTypeVideo: {
+		{"posx", "GstFramePositioner", "int"},
+		{"posy", "GstFramePositioner", "int"},
+		{"width", "GstFramePositioner", "int"},
+		{"height", "GstFramePositioner", "int"},
+		{"alpha", "GstFramePositioner", "double"},
+		{"operator", "GstCompositorPad", "GstCompositorOperator"},
+	},
+	TrackTypeAudio: {
+		{"volume", "GstVolume", "double"},
+		{"mute", "GstVolume", "boolean"},
+	},
+}
+
+// sourceMetadata converts a source element to the "source" entry of OTIO
+// clip metadata. The raw attributes are kept so properties without a typed
+// field survive a round trip.
+func sourceMetadata(source *Source, trackType int) map[string]interface{} {
+	metadata := map[string]interface{}{
+		"children-properties": source.ChildrenProperties,
+	}
+	if source.Properties != "" {
+		metadata["properties"] = source.Properties
+	}
+
+	children, err := ParseStructure(source.ChildrenProperties)
+	if err != nil {
+		return metadata
+	}
+	for _, field := range sourceFields[trackType] {
+		name, ok := children.ChildPropertyName(field.name)
+		if !ok {
+			continue
+		}
+		switch field.fieldType {
+		case "int":
+			if v, ok := children.GetInt(name); ok {
+				metadata[field.name] = v
+			}
+		case "double":
+			if v, ok := children.GetFloat(name); ok {
+				metadata[field.name] = v
+			}
+		case "boolean":
+			if v, ok := children.GetBool(name); ok {
+				metadata[field.name] = v
+			}
+		default:
+			f, _ := children.Get(name)
+			metadata[field.name] = f.Value
+		}
+	}
+	return metadata
+}
+
+// sourceFromMetadata builds the source element of a clip in one track from
+// the "source" entry of OTIO clip metadata
+func sourceFromMetadata(metadata map[string]interface{}, trackType, trackID int) Source {
+	source := Source{TrackID: trackID}
+	source.Properties, _ = metadata["properties"].(string)
+
+	raw, _ := metadata["children-properties"].(string)
+	children, err := ParseStructure(raw)
+	if err != nil || raw == "" {
+		children = NewStructure("properties")
+	}
+	for _, field := range sourceFields[trackType] {
+		value, ok := metadata[field.name]
+		if !ok {
+			continue
+		}
+
+		var formatted string
+		switch field.fieldType {
+		case "int":
+			v, ok := metadataInt(value)
+			if !ok {
+				continue
+			}
+			formatted = strconv.FormatInt(v, 10)
+		case "double":
+			v, ok := metadataFloat(value)
+			if !ok {
+				continue
+			}
+			formatted = strconv.FormatFloat(v, 'g', -1, 64)
+		case "boolean":
+			v, ok := metadataBool(value)
+			if !ok {
+				continue
+			}
+			formatted = strconv.FormatBool(v)
+		default:
+			v, ok := value.(string)
+			if !ok {
+				continue
+			}
+			formatted = v
+		}
+
+		// Keep the name and type the property was read with
+		name, typ := field.element+"::"+field.name, field.fieldType
+		if existing, ok := children.ChildPropertyName(field.name); ok {
+			f, _ := children.Get(existing)
+			name, typ = existing, f.Type
+		}
+		children.Set(name, typ, formatted)
+	}
+	source.ChildrenProperties = children.String()
+	return source
+}
+
+// clipSource returns the "source" entry of an OTIO clip's metadata
+func clipSource(clip *gotio.Clip) map[string]interface{} {
+	switch m := xgesMetadata(clip.Metadata())["source"].(type) {
+	case map[string]interface{}:
+		return m
+	case gotio.AnyDictionary:
+		return m
+	}
+	return nil
+}
+
+// setClipSource stores typed source settings in an OTIO clip's metadata
+func setClipSource(clip *gotio.Clip, values map[string]interface{}) {
+	xgesMetadata := setXgesMetadata(clip)
+	source := clipSource(clip)
+	if source == nil {
+		source = make(map[string]interface{})
+		xgesMetadata["source"] = source
+	}
+	for name, value := range values {
+		source[name] = value
+	}
+}
+
+// ClipVideoSource returns the compositing settings of an OTIO clip in a
+// video track. Settings the clip doesn't have take their GES defaults.
+func ClipVideoSource(clip *gotio.Clip) (VideoSource, bool) {
+	source := clipSource(clip)
+	settings := VideoSource{Alpha: 1, Operator: "over"}
+	if source == nil {
+		return settings, false
+	}
+	settings.PosX, _ = metadataInt(source["posx"])
+	settings.PosY, _ = metadataInt(source["posy"])
+	settings.Width, _ = metadataInt(source["width"])
+	settings.Height, _ = metadataInt(source["height"])
+	if alpha, ok := metadataFloat(source["alpha"]); ok {
+		settings.Alpha = alpha
+	}
+	if operator, ok := source["operator"].(string); ok {
+		settings.Operator = operator
+	}
+	return settings, true
+}
+
+// SetClipVideoSource sets the compositing settings of an OTIO clip in a
+// video track
+func SetClipVideoSource(clip *gotio.Clip, settings VideoSource) {
+	setClipSource(clip, map[string]interface{}{
+		"posx":     settings.PosX,
+		"posy":     settings.PosY,
+		"width":    settings.Width,
+		"height":   settings.Height,
+		"alpha":    settings.Alpha,
+		"operator": settings.Operator,
+	})
+}
+
+// ClipAudioSource returns the level of an OTIO clip in an audio track.
+// Settings the clip doesn't have take their GES defaults.
+func ClipAudioSource(clip *gotio.Clip) (AudioSource, bool) {
+	source := clipSource(clip)
+	settings := AudioSource{Volume: 1}
+	if source == nil {
+		return settings, false
+	}
+	if volume, ok := metadataFloat(source["volume"]); ok {
+		settings.Volume = volume
+	}
+	settings.Mute, _ = metadataBool(source["mute"])
+	return settings, true
+}
+
+// SetClipAudioSource sets the level of an OTIO clip in an audio track
+func SetClipAudioSource(clip *gotio.Clip, settings AudioSource) {
+	setClipSource(clip, map[string]interface{}{
+		"volume": settings.Volume,
+		"mute":   settings.Mute,
+	})
+}
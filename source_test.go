@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+const sourcesXGES = `<?xml version="1.0" ?>
+<ges version='0.4'>
+  <project properties='properties;'>
+    <timeline properties='properties;' metadatas='metadatas, framerate=(fraction)25/1;'>
+      <track caps='video/x-raw(ANY)' track-type='4' track-id='0'/>
+      <track caps='audio/x-raw(ANY)' track-type='2' track-id='1'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///a.mov' type-name='GESUriClip' layer-priority='0' track-types='6' start='0' duration='2000000000' inpoint='0' rate='0' properties='properties;'>
+          <source track-id='0' children-properties='properties, GstFramePositioner::alpha=(double)0.5, GstCompositorPad::operator=(GstCompositorOperator)over, GstFramePositioner::posx=(int)960, GstFramePositioner::posy=(int)0, GstFramePositioner::width=(int)960, GstFramePositioner::height=(int)540, GstDeinterlace::mode=(GstDeinterlaceModes)auto;'/>
+          <source track-id='1' children-properties='properties, GstVolume::mute=(boolean)true, GstVolume::volume=(double)0.25;'/>
+        </clip>
+      </layer>
+    </timeline>
+  </project>
+</ges>
+`
+
+func TestDecoder_Sources(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(sourcesXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	video := timeline.VideoTracks()[0].Children()[0].(*gotio.Clip)
+	settings, ok := ClipVideoSource(video)
+	expected := VideoSource{PosX: 960, Width: 960, Height: 540, Alpha: 0.5, Operator: "over"}
+	if !ok || settings != expected {
+		t.Errorf("Expected video source %+v, got %+v", expected, settings)
+	}
+
+	audio := timeline.AudioTracks()[0].Children()[0].(*gotio.Clip)
+	level, ok := ClipAudioSource(audio)
+	if !ok || level != (AudioSource{Volume: 0.25, Mute: true}) {
+		t.Errorf("Unexpected audio source %+v", level)
+	}
+
+	// Clips without sources report the GES defaults
+	bare := gotio.NewClip("", nil, nil, nil, nil, nil, "", nil)
+	if settings, ok := ClipVideoSource(bare); ok || settings.Alpha != 1 {
+		t.Errorf("Unexpected default video source %+v", settings)
+	}
+}
+
+func TestEncoder_Sources(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(sourcesXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	video := timeline.VideoTracks()[0].Children()[0].(*gotio.Clip)
+	settings, _ := ClipVideoSource(video)
+	settings.PosY = 540
+	SetClipVideoSource(video, settings)
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+
+	clips := ges.Project.Timeline.Layers[0].Clips
+	if len(clips) != 1 || len(clips[0].Sources) != 2 {
+		t.Fatalf("Expected one clip with a video and an audio source, got %+v", clips)
+	}
+	if source := clips[0].Sources[0]; source.TrackID != 0 || source.ChildrenProperties != "properties, GstFramePositioner::alpha=(double)0.5, GstCompositorPad::operator=(GstCompositorOperator)over, GstFramePositioner::posx=(int)960, GstFramePositioner::posy=(int)540, GstFramePositioner::width=(int)960, GstFramePositioner::height=(int)540, GstDeinterlace::mode=(GstDeinterlaceModes)auto;" {
+		t.Errorf("Unexpected video source %+v", source)
+	}
+	if source := clips[0].Sources[1]; source.TrackID != 1 || source.ChildrenProperties != "properties, GstVolume::mute=(boolean)true, GstVolume::volume=(double)0.25;" {
+		t.Errorf("Unexpected audio source %+v", source)
+	}
+
+	// New settings are written with their qualified names
+	clip := gotio.NewClip("", nil, nil, nil, nil, nil, "", nil)
+	SetClipAudioSource(clip, AudioSource{Volume: 2})
+	source := sourceFromMetadata(clipSource(clip), TrackTypeAudio, 3)
+	if source.TrackID != 3 || source.ChildrenProperties != "properties, GstVolume::volume=(double)2, GstVolume::mute=(boolean)false;" {
+		t.Errorf("Unexpected new source %+v", source)
+	}
+}
+
+func TestLint_SourceCompositing(t *testing.T) {
+	pip := lintClip(1, "file:///b.mov", 0, 4*GSTSecond)
+	pip.Sources = []Source{{TrackID: 0, ChildrenProperties: "properties, GstFramePositioner::width=(int)960, GstFramePositioner::height=(int)540;"}}
+
+	issues, err := Lint(lintProject(
+		Layer{Priority: 0, Clips: []Clip{pip}},
+		Layer{Priority: 1, Clips: []Clip{lintClip(0, "file:///a.mov", 0, 4*GSTSecond)}},
+	), LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	if found := ruleIssues(issues, "hidden-layer"); len(found) != 0 {
+		t.Errorf("Expected a picture in picture not to hide the layer below, got %+v", found)
+	}
+}
@@ -0,0 +1,205 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"strconv"
+)
+
+// SkipLayer can be returned from a StreamHandler Layer or Clip callback to
+// skip the remaining clips of the current layer
+var SkipLayer = errors.New("skip this layer")
+
+// SkipAll can be returned from any StreamHandler callback to stop walking
+// the document. Walk then returns nil. Both sentinels are recognized with
+// errors.Is, so callbacks may wrap them.
+var SkipAll = errors.New("skip everything")
+
+// StreamHandler receives XGES elements as a StreamDecoder reaches them.
+// Nil callbacks are ignored. Container elements (project, timeline, layer)
+// are passed with their attributes only; their children arrive through the
+// other callbacks.
+type StreamHandler struct {
+	Project  func(project *Project) error
+	Timeline func(timeline *Timeline) error
+	Track    func(track *Track) error
+	Layer    func(layer *Layer) error
+	Clip     func(layer *Layer, clip *Clip) error
+}
+
+// StreamDecoder reads XGES XML token by token and hands out tracks, layers
+// and clips as they are parsed, without building the whole GES tree or an
+// OTIO timeline. Memory use stays bounded by the largest single element.
+type StreamDecoder struct {
+	dec           *xml.Decoder
+	version       string
+	formatVersion FormatVersion
+}
+
+// NewStreamDecoder creates a new streaming XGES decoder
+func NewStreamDecoder(r io.Reader) *StreamDecoder {
+	return &StreamDecoder{dec: xml.NewDecoder(r)}
+}
+
+// Version returns the format version of the document, once the root
+// element has been read
+func (s *StreamDecoder) Version() string {
+	return s.version
+}
+
+// Walk reads the document and calls the handler for each element in file
+// order. Walk can only be called once per StreamDecoder.
+func (s *StreamDecoder) Walk(h StreamHandler) error {
+	err := s.walk(h)
+	if errors.Is(err, SkipAll) {
+		return nil
+	}
+	return err
+}
+
+// Clips returns an iterator over every clip in the document, in file order.
+// A decoding error is yielded once, with a nil clip, and ends the sequence.
+func (s *StreamDecoder) Clips() iter.Seq2[*Clip, error] {
+	return func(yield func(*Clip, error) bool) {
+		err := s.Walk(StreamHandler{
+			Clip: func(_ *Layer, clip *Clip) error {
+				if !yield(clip, nil) {
+					return SkipAll
+				}
+				return nil
+			},
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+func (s *StreamDecoder) walk(h StreamHandler) error {
+	var layer *Layer
+	sawRoot := false
+
+	for {
+		tok, err := s.dec.Token()
+		if err == io.EOF {
+			if !sawRoot {
+				return fmt.Errorf("failed to decode XGES XML: no <ges> element found")
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode XGES XML: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if err := s.startElement(t, h, &layer, &sawRoot); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			if t.Name.Local == "layer" {
+				layer = nil
+			}
+		}
+	}
+}
+
+// startElement dispatches a single start tag to the matching callback
+func (s *StreamDecoder) startElement(se xml.StartElement, h StreamHandler, layer **Layer, sawRoot *bool) error {
+	switch se.Name.Local {
+	case "ges":
+		*sawRoot = true
+		s.version = attrValue(se, "version")
+		var err error
+		s.formatVersion, err = documentVersion(s.version)
+		return err
+
+	case "project":
+		project := &Project{
+			Properties: attrValue(se, "properties"),
+			Metadatas:  attrValue(se, "metadatas"),
+		}
+		return callHandler(h.Project, project)
+
+	case "timeline":
+		timeline := &Timeline{
+			Properties: attrValue(se, "properties"),
+			Metadatas:  attrValue(se, "metadatas"),
+		}
+		return callHandler(h.Timeline, timeline)
+
+	case "track":
+		var track Track
+		if err := s.dec.DecodeElement(&track, &se); err != nil {
+			return fmt.Errorf("failed to decode XGES track: %w", err)
+		}
+		return callHandler(h.Track, &track)
+
+	case "layer":
+		*layer = &Layer{
+			Properties:        attrValue(se, "properties"),
+			Metadatas:         attrValue(se, "metadatas"),
+			DeactivatedTracks: attrValue(se, "deactivated-tracks"),
+		}
+		if v := attrValue(se, "priority"); v != "" {
+			priority, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("failed to decode XGES layer priority: %w", err)
+			}
+			(*layer).Priority = priority
+		}
+		if h.Layer == nil {
+			return nil
+		}
+		err := h.Layer(*layer)
+		if errors.Is(err, SkipLayer) {
+			*layer = nil
+			return s.dec.Skip()
+		}
+		return err
+
+	case "clip":
+		var clip Clip
+		if err := s.dec.DecodeElement(&clip, &se); err != nil {
+			return fmt.Errorf("failed to decode XGES clip: %w", err)
+		}
+		normalizeClip(&clip, s.formatVersion)
+		if h.Clip == nil {
+			return nil
+		}
+		err := h.Clip(*layer, &clip)
+		if errors.Is(err, SkipLayer) {
+			*layer = nil
+			return s.dec.Skip()
+		}
+		return err
+
+	default:
+		// Resources, groups, encoding profiles and anything unknown
+		return s.dec.Skip()
+	}
+}
+
+// callHandler invokes an optional container callback
+func callHandler[T any](fn func(*T) error, v *T) error {
+	if fn == nil {
+		return nil
+	}
+	return fn(v)
+}
+
+// attrValue returns the value of the named attribute, or "" if absent
+func attrValue(se xml.StartElement, name string) string {
+	for _, attr := range se.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
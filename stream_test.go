@@ -0,0 +1,163 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStreamDecoder_Walk(t *testing.T) {
+	stream := NewStreamDecoder(strings.NewReader(simpleXGES))
+
+	var tracks []int
+	clipsByLayer := make(map[int][]string)
+	projectSeen := false
+
+	err := stream.Walk(StreamHandler{
+		Project: func(p *Project) error {
+			projectSeen = true
+			if !strings.Contains(p.Metadatas, "Test\\ Project") {
+				t.Errorf("Unexpected project metadatas '%s'", p.Metadatas)
+			}
+			return nil
+		},
+		Track: func(track *Track) error {
+			tracks = append(tracks, track.TrackType)
+			return nil
+		},
+		Clip: func(layer *Layer, clip *Clip) error {
+			if layer == nil {
+				t.Fatal("Clip delivered without a layer")
+			}
+			clipsByLayer[layer.Priority] = append(clipsByLayer[layer.Priority], clip.AssetID)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	if stream.Version() != "0.3" {
+		t.Errorf("Expected version '0.3', got '%s'", stream.Version())
+	}
+	if !projectSeen {
+		t.Error("Project callback not called")
+	}
+	if len(tracks) != 2 || tracks[0] != TrackTypeVideo || tracks[1] != TrackTypeAudio {
+		t.Errorf("Unexpected tracks %v", tracks)
+	}
+	if len(clipsByLayer[0]) != 2 {
+		t.Errorf("Expected 2 clips on layer 0, got %d", len(clipsByLayer[0]))
+	}
+	if len(clipsByLayer[1]) != 1 || clipsByLayer[1][0] != "file:///example/audio.wav" {
+		t.Errorf("Unexpected clips on layer 1: %v", clipsByLayer[1])
+	}
+}
+
+func TestStreamDecoder_SkipLayer(t *testing.T) {
+	stream := NewStreamDecoder(strings.NewReader(simpleXGES))
+
+	var names []string
+	err := stream.Walk(StreamHandler{
+		Layer: func(layer *Layer) error {
+			if layer.Priority == 1 {
+				return SkipLayer
+			}
+			return nil
+		},
+		Clip: func(layer *Layer, clip *Clip) error {
+			names = append(names, clip.AssetID)
+			// Only look at the first clip of each layer
+			return SkipLayer
+		},
+	})
+	if err != nil {
+		t.Fatalf("Walk failed: %v", err)
+	}
+
+	if len(names) != 1 || names[0] != "file:///example/video.mp4" {
+		t.Errorf("Expected only the first clip of layer 0, got %v", names)
+	}
+}
+
+func TestStreamDecoder_WrappedSkip(t *testing.T) {
+	stream := NewStreamDecoder(strings.NewReader(simpleXGES))
+
+	var names []string
+	err := stream.Walk(StreamHandler{
+		Clip: func(layer *Layer, clip *Clip) error {
+			names = append(names, clip.AssetID)
+			if layer.Priority == 0 {
+				return fmt.Errorf("enough of layer %d: %w", layer.Priority, SkipLayer)
+			}
+			return fmt.Errorf("done: %w", SkipAll)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected the wrapped sentinels to skip, got %v", err)
+	}
+
+	if len(names) != 2 || names[0] != "file:///example/video.mp4" || names[1] != "file:///example/audio.wav" {
+		t.Errorf("Expected the first clip of each layer, got %v", names)
+	}
+}
+
+func TestStreamDecoder_Clips(t *testing.T) {
+	f, err := os.Open("testdata/xges_example.xges")
+	if err != nil {
+		t.Fatalf("Failed to open test data: %v", err)
+	}
+	defer f.Close()
+
+	// Resources, encoding profiles and groups are skipped
+	count := 0
+	transitions := 0
+	for clip, err := range NewStreamDecoder(f).Clips() {
+		if err != nil {
+			t.Fatalf("Clips failed: %v", err)
+		}
+		count++
+		if clip.TypeName == ClipTypeTransition {
+			transitions++
+		}
+	}
+
+	if count != 7 {
+		t.Errorf("Expected 7 clips, got %d", count)
+	}
+	if transitions != 1 {
+		t.Errorf("Expected 1 transition, got %d", transitions)
+	}
+
+	// Breaking out of the loop stops the walk
+	seen := 0
+	for range NewStreamDecoder(strings.NewReader(simpleXGES)).Clips() {
+		seen++
+		break
+	}
+	if seen != 1 {
+		t.Errorf("Expected iteration to stop after 1 clip, got %d", seen)
+	}
+}
+
+func TestStreamDecoder_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"<notges/>",
+		"<ges><project><timeline><layer priority='x'></layer></timeline></project></ges>",
+		"<ges><project><timeline><layer priority='0'><clip id='0'",
+	} {
+		for _, err := range NewStreamDecoder(strings.NewReader(input)).Clips() {
+			if err == nil {
+				t.Errorf("Expected error for %q", input)
+			}
+		}
+		if err := NewStreamDecoder(strings.NewReader(input)).Walk(StreamHandler{}); err == nil {
+			t.Errorf("Expected Walk error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,496 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Structure is a parsed GstStructure, the `name, field=(type)value, ...;`
+// text XGES uses for properties, metadatas, children-properties and caps
+type Structure struct {
+	Name   string
+	Fields []Field
+}
+
+// Field is a single field of a Structure. Value holds the serialized value
+// as written in the file, except for strings and quoted values which are
+// unquoted and unescaped. Type may be empty when the file leaves it out.
+type Field struct {
+	Name  string
+	Type  string
+	Value string
+	// Quoted records that the value was quoted in the file, as GStreamer
+	// writes nested structures, date times and GES marker lists. It is
+	// written back quoted whatever its type.
+	Quoted bool
+}
+
+// NewStructure creates an empty structure
+func NewStructure(name string) *Structure {
+	return &Structure{Name: name}
+}
+
+// ParseStructure parses a serialized GstStructure. Only the first structure
+// of a caps string is parsed.
+func ParseStructure(s string) (*Structure, error) {
+	p := &structureParser{s: s}
+	p.skipSpace()
+
+	st := &Structure{Name: p.readName()}
+	if st.Name == "" {
+		return nil, fmt.Errorf("invalid structure %q: missing name", s)
+	}
+
+	for {
+		p.skipSpace()
+		if p.done() || p.peek() == ';' {
+			return st, nil
+		}
+		if p.peek() != ',' {
+			return nil, fmt.Errorf("invalid structure %q: expected ',' at offset %d", s, p.pos)
+		}
+		p.pos++
+		p.skipSpace()
+		if p.done() || p.peek() == ';' {
+			return st, nil
+		}
+
+		field, err := p.readField()
+		if err != nil {
+			return nil, fmt.Errorf("invalid structure %q: %w", s, err)
+		}
+		st.Fields = append(st.Fields, field)
+	}
+}
+
+// String serializes the structure the way GStreamer does
+func (s *Structure) String() string {
+	var sb strings.Builder
+	sb.WriteString(s.Name)
+	for _, f := range s.Fields {
+		sb.WriteString(", ")
+		sb.WriteString(f.Name)
+		sb.WriteByte('=')
+		if f.Type != "" {
+			sb.WriteString("(" + f.Type + ")")
+		}
+		switch {
+		case f.Quoted:
+			sb.WriteString(quoteGstString(f.Value))
+		case isStringType(f.Type):
+			sb.WriteString(WrapGstString(f.Value))
+		default:
+			sb.WriteString(f.Value)
+		}
+	}
+	sb.WriteByte(';')
+	return sb.String()
+}
+
+// Get returns the named field
+func (s *Structure) Get(name string) (Field, bool) {
+	if i := s.index(name); i >= 0 {
+		return s.Fields[i], true
+	}
+	return Field{}, false
+}
+
+// Has reports whether the structure has the named field
+func (s *Structure) Has(name string) bool {
+	return s.index(name) >= 0
+}
+
+// Set adds or replaces a field. A replaced field keeps its position.
+func (s *Structure) Set(name, typ, value string) {
+	field := Field{Name: name, Type: typ, Value: value}
+	if i := s.index(name); i >= 0 {
+		s.Fields[i] = field
+		return
+	}
+	s.Fields = append(s.Fields, field)
+}
+
+// ChildPropertyName returns the name of the field setting a child
+// property, either bare or qualified with the element type as GES writes it,
+// as in GstFramePositioner::alpha
+func (s *Structure) ChildPropertyName(property string) (string, bool) {
+	for _, f := range s.Fields {
+		if f.Name == property || strings.HasSuffix(f.Name, "::"+property) {
+			return f.Name, true
+		}
+	}
+	return "", false
+}
+
+// Remove deletes the named field, if present
+func (s *Structure) Remove(name string) {
+	if i := s.index(name); i >= 0 {
+		s.Fields = append(s.Fields[:i], s.Fields[i+1:]...)
+	}
+}
+
+// GetString returns the value of a string field
+func (s *Structure) GetString(name string) (string, bool) {
+	f, ok := s.Get(name)
+	if !ok || !isStringType(f.Type) {
+		return "", false
+	}
+	return f.Value, true
+}
+
+// GetInt returns the value of a signed integer field
+func (s *Structure) GetInt(name string) (int64, bool) {
+	f, ok := s.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(f.Value, 0, 64)
+	return v, err == nil
+}
+
+// GetUint64 returns the value of an unsigned integer field, such as the
+// guint64 times and durations used throughout XGES
+func (s *Structure) GetUint64(name string) (uint64, bool) {
+	f, ok := s.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(f.Value, 0, 64)
+	return v, err == nil
+}
+
+// GetBool returns the value of a boolean field
+func (s *Structure) GetBool(name string) (bool, bool) {
+	f, ok := s.Get(name)
+	if !ok {
+		return false, false
+	}
+	switch strings.ToLower(f.Value) {
+	case "true", "yes", "t", "1":
+		return true, true
+	case "false", "no", "f", "0":
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// GetFloat returns the value of a float or double field
+func (s *Structure) GetFloat(name string) (float64, bool) {
+	f, ok := s.Get(name)
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(f.Value, 64)
+	return v, err == nil
+}
+
+// GetFraction returns the numerator and denominator of a fraction field
+func (s *Structure) GetFraction(name string) (num, den int64, ok bool) {
+	f, found := s.Get(name)
+	if !found {
+		return 0, 0, false
+	}
+	n, d, cut := strings.Cut(f.Value, "/")
+	if !cut {
+		d = "1"
+	}
+	num, err1 := strconv.ParseInt(strings.TrimSpace(n), 10, 64)
+	den, err2 := strconv.ParseInt(strings.TrimSpace(d), 10, 64)
+	if err1 != nil || err2 != nil || den == 0 {
+		return 0, 0, false
+	}
+	return num, den, true
+}
+
+// GetStructure parses a string field holding a nested structure or caps
+func (s *Structure) GetStructure(name string) (*Structure, bool) {
+	v, ok := s.GetString(name)
+	if !ok {
+		f, found := s.Get(name)
+		if !found {
+			return nil, false
+		}
+		v = f.Value
+	}
+	nested, err := ParseStructure(v)
+	return nested, err == nil
+}
+
+// SetString sets a string field
+func (s *Structure) SetString(name, value string) {
+	s.Set(name, "string", value)
+}
+
+// SetInt sets an int field
+func (s *Structure) SetInt(name string, value int64) {
+	s.Set(name, "int", strconv.FormatInt(value, 10))
+}
+
+// SetUint64 sets a guint64 field
+func (s *Structure) SetUint64(name string, value uint64) {
+	s.Set(name, "guint64", strconv.FormatUint(value, 10))
+}
+
+// SetBool sets a boolean field
+func (s *Structure) SetBool(name string, value bool) {
+	s.Set(name, "boolean", strconv.FormatBool(value))
+}
+
+// SetFloat sets a double field, or keeps the type of an existing float field
+func (s *Structure) SetFloat(name string, value float64) {
+	typ := "double"
+	if f, ok := s.Get(name); ok && f.Type == "float" {
+		typ = "float"
+	}
+	s.Set(name, typ, strconv.FormatFloat(value, 'g', -1, 64))
+}
+
+// SetFraction sets a fraction field
+func (s *Structure) SetFraction(name string, num, den int64) {
+	s.Set(name, "fraction", fmt.Sprintf("%d/%d", num, den))
+}
+
+// index returns the position of the named field, or -1
+func (s *Structure) index(name string) int {
+	for i, f := range s.Fields {
+		if f.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// isStringType reports whether values of the GType are serialized as strings
+func isStringType(typ string) bool {
+	return typ == "string" || typ == "gchararray" || typ == "s" || typ == "str"
+}
+
+// isGstStringChar reports whether c can appear unescaped in a GStreamer
+// string (GST_ASCII_IS_STRING)
+func isGstStringChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-' || c == '+' || c == '/' || c == ':' || c == '.'
+}
+
+// WrapGstString serializes a string value like GStreamer: plain strings are
+// written as is, anything else is quoted with special characters escaped by
+// a backslash and non-printable bytes as octal escapes
+func WrapGstString(s string) string {
+	simple := s != "" && s != "NULL"
+	for i := 0; i < len(s) && simple; i++ {
+		simple = isGstStringChar(s[i])
+	}
+	if simple {
+		return s
+	}
+	return quoteGstString(s)
+}
+
+// quoteGstString quotes a value the way GStreamer wraps strings, even when
+// it needs no quotes
+func quoteGstString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isGstStringChar(c):
+			sb.WriteByte(c)
+		case c < 0x20 || c >= 0x7f:
+			fmt.Fprintf(&sb, "\\%03o", c)
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// UnwrapGstString undoes WrapGstString. Surrounding quotes are optional.
+func UnwrapGstString(s string) string {
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
+	}
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i+2 < len(s) && isOctal(s[i]) && isOctal(s[i+1]) && isOctal(s[i+2]) {
+			sb.WriteByte((s[i]-'0')<<6 | (s[i+1]-'0')<<3 | (s[i+2] - '0'))
+			i += 2
+			continue
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func isOctal(c byte) bool {
+	return c >= '0' && c <= '7'
+}
+
+// structureParser reads a serialized GstStructure
+type structureParser struct {
+	s   string
+	pos int
+}
+
+func (p *structureParser) done() bool {
+	return p.pos >= len(p.s)
+}
+
+func (p *structureParser) peek() byte {
+	return p.s[p.pos]
+}
+
+func (p *structureParser) skipSpace() {
+	for !p.done() && strings.IndexByte(" \t\r\n", p.peek()) >= 0 {
+		p.pos++
+	}
+}
+
+// readName reads the structure name, including any caps features such as
+// video/x-raw(ANY)
+func (p *structureParser) readName() string {
+	start := p.pos
+	depth := 0
+	for !p.done() {
+		c := p.peek()
+		if c == '(' {
+			depth++
+		} else if c == ')' && depth > 0 {
+			depth--
+		} else if depth == 0 && (c == ',' || c == ';' || c == ' ' || c == '\t' || c == '\n' || c == '\r') {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// readField reads `name=(type)value`
+func (p *structureParser) readField() (Field, error) {
+	eq := strings.IndexByte(p.s[p.pos:], '=')
+	if eq < 0 {
+		return Field{}, fmt.Errorf("missing '=' at offset %d", p.pos)
+	}
+	field := Field{Name: strings.TrimSpace(p.s[p.pos : p.pos+eq])}
+	if field.Name == "" {
+		return Field{}, fmt.Errorf("missing field name at offset %d", p.pos)
+	}
+	p.pos += eq + 1
+	p.skipSpace()
+
+	if !p.done() && p.peek() == '(' {
+		end := strings.IndexByte(p.s[p.pos:], ')')
+		if end < 0 {
+			return Field{}, fmt.Errorf("unterminated type of field %s", field.Name)
+		}
+		field.Type = strings.TrimSpace(p.s[p.pos+1 : p.pos+end])
+		p.pos += end + 1
+		p.skipSpace()
+	}
+
+	field.Quoted = !p.done() && p.peek() == '"'
+	value, err := p.readValue(isStringType(field.Type))
+	if err != nil {
+		return Field{}, fmt.Errorf("field %s: %w", field.Name, err)
+	}
+	field.Value = value
+	return field, nil
+}
+
+// readValue reads a quoted string, a bracketed list, array or range, or a
+// bare value up to the next separator. Bare values are unescaped only when
+// they are strings, other values are kept as written.
+func (p *structureParser) readValue(str bool) (string, error) {
+	if p.done() {
+		return "", nil
+	}
+
+	switch p.peek() {
+	case '"':
+		start := p.pos
+		p.pos++
+		for !p.done() && p.peek() != '"' {
+			if p.peek() == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.done() {
+			return "", fmt.Errorf("unterminated string")
+		}
+		p.pos++
+		return UnwrapGstString(p.s[start:p.pos]), nil
+
+	case '{', '<', '[':
+		start := p.pos
+		if err := p.skipBracketed(); err != nil {
+			return "", err
+		}
+		return p.s[start:p.pos], nil
+	}
+
+	start := p.pos
+	for !p.done() && p.peek() != ',' && p.peek() != ';' {
+		if p.peek() == '\\' {
+			p.pos++
+		}
+		p.pos++
+	}
+	if p.pos > len(p.s) {
+		p.pos = len(p.s)
+	}
+	value := strings.TrimRight(p.s[start:p.pos], " \t\r\n")
+	if str {
+		return UnwrapGstString(value), nil
+	}
+	return value, nil
+}
+
+// skipBracketed moves past a balanced {...}, <...> or [...] group
+func (p *structureParser) skipBracketed() error {
+	closing := map[byte]byte{'{': '}', '<': '>', '[': ']'}
+	open := p.peek()
+	var stack []byte
+	for !p.done() {
+		c := p.peek()
+		switch {
+		case c == '"':
+			p.pos++
+			for !p.done() && p.peek() != '"' {
+				if p.peek() == '\\' {
+					p.pos++
+				}
+				p.pos++
+			}
+		case c == '\\':
+			p.pos++
+		case closing[c] != 0:
+			stack = append(stack, closing[c])
+		case len(stack) > 0 && c == stack[len(stack)-1]:
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				p.pos++
+				return nil
+			}
+		}
+		p.pos++
+	}
+	return fmt.Errorf("unterminated %c", open)
+}
@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"testing"
+)
+
+func TestParseStructure(t *testing.T) {
+	st, err := ParseStructure(`metadatas, author=(string)"Thibault\ saunier", render-scale=(double)100, format-version=(string)0.3, duration=(guint64)36215932868, framerate=(fraction)25/1, flag=(boolean)true, list=(int){ 1, 2 };`)
+	if err != nil {
+		t.Fatalf("ParseStructure failed: %v", err)
+	}
+
+	if st.Name != "metadatas" {
+		t.Errorf("Expected name 'metadatas', got '%s'", st.Name)
+	}
+	if len(st.Fields) != 7 {
+		t.Fatalf("Expected 7 fields, got %d: %v", len(st.Fields), st.Fields)
+	}
+
+	if v, ok := st.GetString("author"); !ok || v != "Thibault saunier" {
+		t.Errorf("Unexpected author %q", v)
+	}
+	if v, ok := st.GetString("format-version"); !ok || v != "0.3" {
+		t.Errorf("Unexpected format-version %q", v)
+	}
+	if v, ok := st.GetFloat("render-scale"); !ok || v != 100 {
+		t.Errorf("Unexpected render-scale %v", v)
+	}
+	if v, ok := st.GetUint64("duration"); !ok || v != 36215932868 {
+		t.Errorf("Unexpected duration %v", v)
+	}
+	if num, den, ok := st.GetFraction("framerate"); !ok || num != 25 || den != 1 {
+		t.Errorf("Unexpected framerate %d/%d", num, den)
+	}
+	if v, ok := st.GetBool("flag"); !ok || !v {
+		t.Errorf("Unexpected flag %v", v)
+	}
+	if f, _ := st.Get("list"); f.Value != "{ 1, 2 }" {
+		t.Errorf("Unexpected list %q", f.Value)
+	}
+	if _, ok := st.GetString("render-scale"); ok {
+		t.Error("GetString accepted a double field")
+	}
+}
+
+func TestParseStructure_Caps(t *testing.T) {
+	props, err := ParseStructure(`properties, restriction-caps=(string)"video/x-raw\,\ width\=\(int\)384\,\ height\=\(int\)288\,\ framerate\=\(fraction\)24000/1001", mixing=(boolean)true;`)
+	if err != nil {
+		t.Fatalf("ParseStructure failed: %v", err)
+	}
+
+	caps, ok := props.GetStructure("restriction-caps")
+	if !ok {
+		t.Fatal("restriction-caps not parsed")
+	}
+	if caps.Name != "video/x-raw" {
+		t.Errorf("Unexpected caps name %q", caps.Name)
+	}
+	if v, ok := caps.GetInt("width"); !ok || v != 384 {
+		t.Errorf("Unexpected width %v", v)
+	}
+	if num, den, ok := caps.GetFraction("framerate"); !ok || num != 24000 || den != 1001 {
+		t.Errorf("Unexpected framerate %d/%d", num, den)
+	}
+
+	features, err := ParseStructure("video/x-raw(ANY)")
+	if err != nil || features.Name != "video/x-raw(ANY)" {
+		t.Errorf("Unexpected caps features parse: %v, %v", features, err)
+	}
+}
+
+func TestStructure_String(t *testing.T) {
+	st := NewStructure("properties")
+	st.SetString("name", "uriclip43")
+	st.SetBool("mute", false)
+	st.SetString("text", `Hello, "World"`)
+	st.SetUint64("duration", 42)
+	st.SetFloat("volume", 1)
+
+	expected := `properties, name=(string)uriclip43, mute=(boolean)false, text=(string)"Hello\,\ \"World\"", duration=(guint64)42, volume=(double)1;`
+	if st.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, st.String())
+	}
+
+	// Round trip, and replace in place
+	parsed, err := ParseStructure(st.String())
+	if err != nil {
+		t.Fatalf("ParseStructure failed: %v", err)
+	}
+	parsed.SetBool("mute", true)
+	parsed.Remove("duration")
+	expected = `properties, name=(string)uriclip43, mute=(boolean)true, text=(string)"Hello\,\ \"World\"", volume=(double)1;`
+	if parsed.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, parsed.String())
+	}
+
+	if empty := NewStructure("metadatas"); empty.String() != "metadatas;" {
+		t.Errorf("Unexpected empty structure %q", empty.String())
+	}
+}
+
+func TestStructure_QuotedRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input string
+		value string
+	}{
+		{"empty marker list", `metadatas, markers=(GESMarkerList)"EMPTY";`, "EMPTY"},
+		{"marker list", `metadatas, markers=(GESMarkerList)"marker-times\=\(guint64\)\<\ 1\ \>";`, "marker-times=(guint64)< 1 >"},
+		{"nested marker metadatas", `metadatas, markers=(GESMarkerList)"0:metadatas\,\ name\=\(string\)\"a\\\,\\\ b\"\;";`, `0:metadatas, name=(string)"a\,\ b";`},
+		{"structure", `properties, s=(GstStructure)"foo\,\ a\=\(int\)1\;";`, "foo, a=(int)1;"},
+		{"date time", `metadatas, date=(GstDateTime)"2021-01-01T10:00:00Z";`, "2021-01-01T10:00:00Z"},
+		{"caps", `properties, caps=(GstCaps)"video/x-raw\,\ width\=\(int\)1920";`, "video/x-raw, width=(int)1920"},
+		{"quoted simple string", `properties, name=(string)"clip";`, "clip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			st, err := ParseStructure(tc.input)
+			if err != nil {
+				t.Fatalf("ParseStructure failed: %v", err)
+			}
+			if f := st.Fields[0]; f.Value != tc.value || !f.Quoted {
+				t.Errorf("Expected quoted value %q, got %+v", tc.value, f)
+			}
+			if st.String() != tc.input {
+				t.Errorf("Expected\n%s\ngot\n%s", tc.input, st.String())
+			}
+		})
+	}
+
+	// Bare values of other types are kept as written
+	input := `properties, v=(GstValueArray)\<\ 1\ \>;`
+	if st, err := ParseStructure(input); err != nil || st.String() != input {
+		t.Errorf("Expected %s to round trip, got %v", input, st)
+	}
+}
+
+func TestWrapGstString(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"simple", "simple"},
+		{"0.3", "0.3"},
+		{"", `""`},
+		{"with space", `"with\ space"`},
+		{"café", `"caf\303\251"`},
+		{"tab\there", `"tab\011here"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			wrapped := WrapGstString(tc.input)
+			if wrapped != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, wrapped)
+			}
+			if unwrapped := UnwrapGstString(wrapped); unwrapped != tc.input {
+				t.Errorf("Round trip gave %q", unwrapped)
+			}
+		})
+	}
+}
+
+func TestParseStructure_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		", a=(int)1;",
+		`properties, name=(string)"unterminated;`,
+		"properties, novalue;",
+		"properties, list=(int){ 1, 2;",
+		"properties name=(int)1;",
+	} {
+		if _, err := ParseStructure(input); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Avalanche-io/gotio"
+)
+
+// Time effects change the rate at which a clip reads its source. As in OTIO,
+// the clip duration stays the duration in the timeline and the inpoint stays
+// a position in the source, so a clip of duration d at rate r reads d*r of
+// its source from the inpoint on.
+
+// timeEffect describes a GES element that changes the playback rate
+type timeEffect struct {
+	typeName   string   // GType name used in children-properties
+	rateFields []string // properties multiplied into the rate
+	freeze     bool     // holds a single frame
+}
+
+// timeEffects are the GES time effects, by bin description
+var timeEffects = map[string]timeEffect{
+	"videorate":   {typeName: "GstVideoRate", rateFields: []string{"rate"}},
+	"scaletempo":  {typeName: "GstScaletempo", rateFields: []string{"rate"}},
+	"pitch":       {typeName: "GstPitch", rateFields: []string{"rate", "tempo"}},
+	"imagefreeze": {typeName: "GstImageFreeze", freeze: true},
+}
+
+// IsTimeEffect reports whether a GES effect changes the playback rate
+func IsTimeEffect(effect *Effect) bool {
+	_, ok := timeEffects[effectElement(effect.AssetID)]
+	return ok
+}
+
+// effectElement returns the element name of an effect bin description
+func effectElement(description string) string {
+	fields := strings.Fields(description)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// TimeScalar returns how fast an effect reads its source: 1 for effects that
+// don't change the rate and 0 for freeze frames
+func (effect *Effect) TimeScalar() float64 {
+	te, ok := timeEffects[effectElement(effect.AssetID)]
+	if !ok {
+		return 1
+	}
+	if te.freeze {
+		return 0
+	}
+
+	// Properties set in the bin description, overridden by children-properties
+	values := make(map[string]float64)
+	for _, field := range strings.Fields(effect.AssetID)[1:] {
+		name, value, ok := strings.Cut(field, "=")
+		if v, err := strconv.ParseFloat(value, 64); ok && err == nil {
+			values[name] = v
+		}
+	}
+	if children, err := ParseStructure(effect.ChildrenProperties); err == nil {
+		for _, f := range children.Fields {
+			name := f.Name
+			if i := strings.LastIndex(name, "::"); i >= 0 {
+				name = name[i+2:]
+			}
+			if v, ok := children.GetFloat(f.Name); ok {
+				values[name] = v
+			}
+		}
+	}
+
+	scalar := 1.0
+	for _, name := range te.rateFields {
+		if v, ok := values[name]; ok && v > 0 {
+			scalar *= v
+		}
+	}
+	return scalar
+}
+
+// TimeScalar returns how fast the clip reads its source in one track type,
+// combining its time effects
+func (c *Clip) TimeScalar(trackType int) float64 {
+	scalar := 1.0
+	for i := range c.Effects {
+		if c.Effects[i].TrackType == trackType {
+			scalar *= c.Effects[i].TimeScalar()
+		}
+	}
+	return scalar
+}
+
+// SourceDuration returns how much of its source the clip reads in one track
+// type, taking time effects into account
+func (c *Clip) SourceDuration(trackType int) uint64 {
+	scalar := c.TimeScalar(trackType)
+	if scalar == 1 {
+		return c.Duration
+	}
+	return uint64(float64(c.Duration) * scalar)
+}
+
+// timeWarps converts the time effects among the effects of a clip in one
+// track to OTIO time warps
+func timeWarps(trackEffects []Effect) []gotio.Effect {
+	var effects []gotio.Effect
+	for i := range trackEffects {
+		effect := &trackEffects[i]
+		if !IsTimeEffect(effect) {
+			continue
+		}
+		metadata := map[string]interface{}{
+			"xges": map[string]interface{}{"asset-id": effect.AssetID},
+		}
+		if scalar := effect.TimeScalar(); scalar == 0 {
+			effects = append(effects, gotio.NewFreezeFrame("", metadata))
+		} else {
+			effects = append(effects, gotio.NewLinearTimeWarp("", "LinearTimeWarp", scalar, metadata))
+		}
+	}
+	return effects
+}
+
+// timeEffectFor builds the GES time effect for an OTIO time warp in one
+// track type. It returns false if the track type has no matching effect.
+func timeEffectFor(scalar float64, trackType int) (Effect, bool) {
+	effect := Effect{TypeName: EffectTypeName, TrackType: trackType}
+	switch {
+	case scalar == 0 && trackType == TrackTypeVideo:
+		effect.AssetID = "imagefreeze"
+		return effect, true
+	case scalar <= 0:
+		// Freezing audio or playing backwards
+		return effect, false
+	case trackType == TrackTypeVideo:
+		effect.AssetID = "videorate"
+	case trackType == TrackTypeAudio:
+		effect.AssetID = "scaletempo"
+	default:
+		return effect, false
+	}
+
+	children := NewStructure("properties")
+	children.SetFloat(timeEffects[effect.AssetID].typeName+"::rate", scalar)
+	effect.ChildrenProperties = children.String()
+	return effect, true
+}
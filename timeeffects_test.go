@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"testing"
+
+	"github.com/Avalanche-io/gotio"
+	"github.com/Avalanche-io/gotio/opentime"
+)
+
+func TestEffect_TimeScalar(t *testing.T) {
+	testCases := []struct {
+		effect   Effect
+		expected float64
+	}{
+		{Effect{AssetID: "videorate", ChildrenProperties: "properties, GstVideoRate::rate=(double)2;"}, 2},
+		{Effect{AssetID: "scaletempo", ChildrenProperties: "properties, rate=(double)0.5;"}, 0.5},
+		{Effect{AssetID: "pitch tempo=1.5", ChildrenProperties: "properties, GstPitch::rate=(float)2;"}, 3},
+		{Effect{AssetID: "videorate rate=4"}, 4},
+		{Effect{AssetID: "imagefreeze"}, 0},
+		{Effect{AssetID: "agingtv", ChildrenProperties: "properties, rate=(double)2;"}, 1},
+	}
+	for _, tc := range testCases {
+		if got := tc.effect.TimeScalar(); got != tc.expected {
+			t.Errorf("TimeScalar(%s) = %v, expected %v", tc.effect.AssetID, got, tc.expected)
+		}
+	}
+
+	clip := Clip{Duration: 4 * GSTSecond, Effects: []Effect{
+		{AssetID: "videorate", TrackType: TrackTypeVideo, ChildrenProperties: "properties, GstVideoRate::rate=(double)2;"},
+		{AssetID: "agingtv", TrackType: TrackTypeVideo},
+	}}
+	if d := clip.SourceDuration(TrackTypeVideo); d != 8*GSTSecond {
+		t.Errorf("Expected the clip to read 8s of video, got %d", d)
+	}
+	if d := clip.SourceDuration(TrackTypeAudio); d != 4*GSTSecond {
+		t.Errorf("Expected the clip to read 4s of audio, got %d", d)
+	}
+}
+
+func TestTimeEffects_RoundTrip(t *testing.T) {
+	sourceRange := opentime.NewTimeRange(opentime.NewRationalTime(25, 25), opentime.NewRationalTime(50, 25))
+	fast := gotio.NewClip("fast", gotio.NewExternalReference("", "file:///a.mov", nil, nil), &sourceRange, nil,
+		[]gotio.Effect{gotio.NewLinearTimeWarp("", "LinearTimeWarp", 2, nil)}, nil, "", nil)
+	frozen := gotio.NewClip("frozen", gotio.NewExternalReference("", "file:///b.mov", nil, nil), &sourceRange, nil,
+		[]gotio.Effect{gotio.NewFreezeFrame("", nil)}, nil, "", nil)
+
+	timeline := gotio.NewTimeline("", nil, nil)
+	video := gotio.NewTrack("", nil, gotio.TrackKindVideo, nil, nil)
+	video.AppendChild(fast)
+	video.AppendChild(frozen)
+	timeline.Tracks().AppendChild(video)
+
+	encoder := NewEncoder(nil)
+	ges, err := encoder.EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if len(encoder.Warnings()) != 0 {
+		t.Errorf("Unexpected warnings %v", encoder.Warnings())
+	}
+
+	clips := ges.Project.Timeline.Layers[0].Clips
+	if len(clips[0].Effects) != 1 || clips[0].Effects[0].AssetID != "videorate" || clips[0].Effects[0].ChildrenProperties != "properties, GstVideoRate::rate=(double)2;" {
+		t.Fatalf("Unexpected effects %+v", clips[0].Effects)
+	}
+	if clips[0].Inpoint != GSTSecond || clips[0].Duration != 2*GSTSecond || clips[0].SourceDuration(TrackTypeVideo) != 4*GSTSecond {
+		t.Errorf("Expected 2s in the timeline reading 4s from 1s, got %+v", clips[0])
+	}
+	if len(clips[1].Effects) != 1 || clips[1].Effects[0].AssetID != "imagefreeze" || clips[1].Effects[0].ClipID != clips[1].ID {
+		t.Errorf("Unexpected freeze effects %+v", clips[1].Effects)
+	}
+
+	decoder := NewDecoder(nil)
+	decoded, err := decoder.DecodeDocument(ges)
+	if err != nil {
+		t.Fatalf("DecodeDocument failed: %v", err)
+	}
+	if len(decoder.Warnings()) != 0 {
+		t.Errorf("Unexpected warnings %v", decoder.Warnings())
+	}
+
+	children := decoded.VideoTracks()[0].Children()
+	clip := children[0].(*gotio.Clip)
+	warp, ok := clip.Effects()[0].(*gotio.LinearTimeWarp)
+	if !ok || warp.TimeScalar() != 2 {
+		t.Errorf("Expected a time warp of 2, got %v", clip.Effects())
+	}
+	if duration, _ := clip.Duration(); duration.ToSeconds() != 2 || clip.SourceRange().StartTime().ToSeconds() != 1 {
+		t.Errorf("Unexpected source range %v", clip.SourceRange())
+	}
+	if _, ok := children[1].(*gotio.Clip).Effects()[0].(*gotio.FreezeFrame); !ok {
+		t.Errorf("Expected a freeze frame, got %v", children[1].(*gotio.Clip).Effects())
+	}
+}
+
+func TestTimeEffects_Unsupported(t *testing.T) {
+	sourceRange := opentime.NewTimeRange(opentime.NewRationalTime(0, 25), opentime.NewRationalTime(50, 25))
+	clip := gotio.NewClip("reverse", gotio.NewExternalReference("", "file:///a.wav", nil, nil), &sourceRange, nil,
+		[]gotio.Effect{gotio.NewLinearTimeWarp("", "LinearTimeWarp", -1, nil), gotio.NewFreezeFrame("", nil)}, nil, "", nil)
+
+	timeline := gotio.NewTimeline("", nil, nil)
+	audio := gotio.NewTrack("", nil, gotio.TrackKindAudio, nil, nil)
+	audio.AppendChild(clip)
+	timeline.Tracks().AppendChild(audio)
+
+	encoder := NewEncoder(nil)
+	ges, err := encoder.EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if n := len(ges.Project.Timeline.Layers[0].Clips[0].Effects); n != 0 {
+		t.Errorf("Expected no effects, got %d", n)
+	}
+	if len(encoder.Warnings()) != 1 {
+		t.Errorf("Expected a warning for the dropped effects, got %v", encoder.Warnings())
+	}
+}
+
+func TestLint_TimeEffects(t *testing.T) {
+	clip := lintClip(0, "file:///a.mov", 0, 4*GSTSecond)
+	clip.Inpoint = 4 * GSTSecond
+	clip.Effects = []Effect{{AssetID: "videorate", TrackType: TrackTypeVideo, ChildrenProperties: "properties, GstVideoRate::rate=(double)2;"}}
+
+	issues, err := Lint(lintProject(Layer{Priority: 0, Clips: []Clip{clip}}), LintOptions{})
+	if err != nil {
+		t.Fatalf("Lint failed: %v", err)
+	}
+	found := ruleIssues(issues, "trimmed-past-asset")
+	if len(found) != 1 || found[0].Start != 3*GSTSecond || found[0].Duration != GSTSecond {
+		t.Errorf("Expected the last second to read past the media, got %+v", found)
+	}
+}
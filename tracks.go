@@ -0,0 +1,70 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"github.com/Avalanche-io/gotio"
+)
+
+// OTIO track kinds for the XGES track types OTIO doesn't define
+const (
+	TrackKindText   = "Text"
+	TrackKindCustom = "Custom"
+)
+
+// trackKinds maps the XGES track types that become OTIO tracks to track
+// kinds, in the order their tracks are put in the stack
+var trackKinds = []struct {
+	trackType int
+	kind      string
+}{
+	{TrackTypeVideo, gotio.TrackKindVideo},
+	{TrackTypeAudio, gotio.TrackKindAudio},
+	{TrackTypeText, TrackKindText},
+	{TrackTypeCustom, TrackKindCustom},
+}
+
+// trackKind returns the OTIO track kind of an XGES track type
+func trackKind(trackType int) (string, bool) {
+	for _, k := range trackKinds {
+		if k.trackType == trackType {
+			return k.kind, true
+		}
+	}
+	return "", false
+}
+
+// kindTrackType returns the XGES track type of an OTIO track kind
+func kindTrackType(kind string) (int, bool) {
+	for _, k := range trackKinds {
+		if k.kind == kind {
+			return k.trackType, true
+		}
+	}
+	return 0, false
+}
+
+// tracksOfKind returns the tracks of one kind in the timeline stack
+func tracksOfKind(timeline *gotio.Timeline, kind string) []*gotio.Track {
+	var tracks []*gotio.Track
+	for _, child := range timeline.Tracks().Children() {
+		if track, ok := child.(*gotio.Track); ok && track.Kind() == kind {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks
+}
+
+// defaultCaps returns the caps of a new XGES track of one type
+func defaultCaps(trackType int) string {
+	switch trackType {
+	case TrackTypeVideo:
+		return "video/x-raw(ANY)"
+	case TrackTypeAudio:
+		return "audio/x-raw(ANY)"
+	case TrackTypeText:
+		return "text/x-raw(ANY)"
+	}
+	return "ANY"
+}
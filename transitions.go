@@ -0,0 +1,215 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// CrossfadeAssetID is the id of the transition asset GES uses for the
+// transitions it creates between overlapping clips
+const CrossfadeAssetID = "crossfade"
+
+// UpdateAutoTransitions recomputes the transitions of every layer with
+// auto-transition, as GES does when it loads a project: overlapping clips
+// get a crossfade in each track type they share, and transitions joining
+// no clips are removed. Transitions already joining the same clips are
+// kept with their settings, resized to the overlap. When the timeline's
+// auto-transition is false no layer is updated, as GES turns it off on
+// every layer of the timeline.
+func (g *GES) UpdateAutoTransitions() {
+	timeline := &g.Project.Timeline
+	g.updateTransitions(func(layer *Layer) bool { return timeline.autoTransitions(layer) })
+}
+
+// autoTransitions reports whether GES creates the transitions of a layer of
+// the timeline: both the timeline and the layer need auto-transition
+func (t *Timeline) autoTransitions(layer *Layer) bool {
+	return t.AutoTransition() && layer.AutoTransition()
+}
+
+// UpdateLayerTransitions recomputes the transitions of the layer of a
+// priority as UpdateAutoTransitions does, whether or not the layer has
+// auto-transition
+func (g *GES) UpdateLayerTransitions(priority int) error {
+	if _, ok := g.Project.Timeline.Layer(priority); !ok {
+		return fmt.Errorf("no layer of priority %d", priority)
+	}
+	g.updateTransitions(func(layer *Layer) bool { return layer.Priority == priority })
+	return nil
+}
+
+// updateTransitions recomputes the transitions of the layers update accepts
+func (g *GES) updateTransitions(update func(layer *Layer) bool) {
+	timeline := &g.Project.Timeline
+	existing := make(map[transitionKey]Clip)
+	for i := range timeline.Layers {
+		layerTransitions(&timeline.Layers[i], existing)
+	}
+	nextID := timeline.NextClipID()
+	for i := range timeline.Layers {
+		if layer := &timeline.Layers[i]; update(layer) {
+			updateLayerTransitions(layer, existing, &nextID)
+		}
+	}
+	addCrossfadeAsset(&g.Project)
+}
+
+// transitionKey identifies the transition joining two clips in a track type
+type transitionKey struct {
+	prev, next int // clip ids
+	trackType  int
+}
+
+// layerTransitions adds the transitions of a layer to transitions, by the
+// clips they join in each of their track types. Stale transitions, joining
+// no clips, are left out.
+func layerTransitions(layer *Layer, transitions map[transitionKey]Clip) {
+	starts := make(map[uint64][]*Clip)
+	ends := make(map[uint64][]*Clip)
+	for i := range layer.Clips {
+		if clip := &layer.Clips[i]; clip.TypeName != ClipTypeTransition {
+			starts[clip.Start] = append(starts[clip.Start], clip)
+			ends[clip.End()] = append(ends[clip.End()], clip)
+		}
+	}
+
+	for _, tr := range layer.Clips {
+		if tr.TypeName != ClipTypeTransition {
+			continue
+		}
+		for _, trackType := range trackTypeFlags(tr.TrackTypes) {
+			prev := clipInTrack(ends[tr.End()], trackType, func(c *Clip) bool { return c.Start < tr.Start })
+			next := clipInTrack(starts[tr.Start], trackType, func(c *Clip) bool { return c.End() > tr.End() })
+			if prev != nil && next != nil {
+				transitions[transitionKey{prev.ID, next.ID, trackType}] = tr
+			}
+		}
+	}
+}
+
+// clipInTrack returns the first of the clips in a track type accepted by ok
+func clipInTrack(clips []*Clip, trackType int, ok func(c *Clip) bool) *Clip {
+	for _, clip := range clips {
+		if clip.TrackTypes&trackType != 0 && ok(clip) {
+			return clip
+		}
+	}
+	return nil
+}
+
+// updateLayerTransitions replaces the transitions of a layer with one for
+// each overlap of two clips in each track type, as GES does for layers with
+// auto-transition. Transitions already joining the same clips are kept with
+// their settings, resized to the overlap.
+func updateLayerTransitions(layer *Layer, existing map[transitionKey]Clip, nextID *int) {
+	clips := make([]Clip, 0, len(layer.Clips))
+	for _, clip := range layer.Clips {
+		if clip.TypeName != ClipTypeTransition {
+			clips = append(clips, clip)
+		}
+	}
+
+	used := make(map[int]bool)
+	var transitions []Clip
+	for _, trackType := range trackTypeFlags(allTrackTypes(clips)) {
+		var inTrack []*Clip
+		for i := range clips {
+			if clips[i].TrackTypes&trackType != 0 {
+				inTrack = append(inTrack, &clips[i])
+			}
+		}
+		sort.SliceStable(inTrack, func(i, j int) bool { return inTrack[i].Start < inTrack[j].Start })
+
+		for i := 1; i < len(inTrack); i++ {
+			prev, next := inTrack[i-1], inTrack[i]
+			if next.Start >= prev.End() {
+				continue
+			}
+			tr, ok := existing[transitionKey{prev.ID, next.ID, trackType}]
+			if !ok || used[tr.ID] {
+				tr = newTransition(*nextID, trackType)
+				*nextID++
+			}
+			used[tr.ID] = true
+			tr.LayerPriority = layer.Priority
+			tr.TrackTypes = trackType
+			tr.Start = next.Start
+			tr.Duration = min(prev.End(), next.End()) - next.Start
+			transitions = append(transitions, tr)
+		}
+	}
+
+	// Transitions come before the clip they lead into, as GES saves them
+	layer.Clips = append(clips, transitions...)
+	sort.SliceStable(layer.Clips, func(i, j int) bool {
+		a, b := &layer.Clips[i], &layer.Clips[j]
+		if a.Start != b.Start {
+			return a.Start < b.Start
+		}
+		return a.TypeName == ClipTypeTransition && b.TypeName != ClipTypeTransition
+	})
+}
+
+// newTransition creates a crossfade as GES adds it for an overlap in a
+// track type, with the properties of the video transition element
+func newTransition(id, trackType int) Clip {
+	tr := Clip{
+		ID:         id,
+		AssetID:    CrossfadeAssetID,
+		TypeName:   ClipTypeTransition,
+		Properties: "properties;",
+		Metadatas:  "metadatas;",
+	}
+	tr.SetName("transitionclip" + strconv.Itoa(id))
+	if trackType == TrackTypeVideo {
+		tr.ChildrenProperties = "properties, GESVideoTransition::border=(uint)0, GESVideoTransition::invert=(boolean)false;"
+	}
+	return tr
+}
+
+// addCrossfadeAsset lists the crossfade asset in the project resources if
+// a transition uses it, as GES saves it. Projects without resources, such
+// as the encoder writes, are left without.
+func addCrossfadeAsset(p *Project) {
+	if p.Ressources == nil {
+		return
+	}
+	crossfade := &Clip{AssetID: CrossfadeAssetID, TypeName: ClipTypeTransition}
+	if _, ok := p.AssetFor(crossfade); ok {
+		return
+	}
+	for i := range p.Timeline.Layers {
+		for _, clip := range p.Timeline.Layers[i].Clips {
+			if clip.AssetID == crossfade.AssetID && clip.TypeName == crossfade.TypeName {
+				asset := p.AddAsset(CrossfadeAssetID, ClipTypeTransition)
+				asset.Metadatas = "metadatas, description=(string)GES_VIDEO_STANDARD_TRANSITION_TYPE_CROSSFADE;"
+				return
+			}
+		}
+	}
+}
+
+// allTrackTypes returns the track types any of the clips is in
+func allTrackTypes(clips []Clip) int {
+	trackTypes := 0
+	for _, clip := range clips {
+		trackTypes |= clip.TrackTypes
+	}
+	return trackTypes
+}
+
+// trackTypeFlags splits track types into single track type flags, in
+// increasing order
+func trackTypeFlags(trackTypes int) []int {
+	var flags []int
+	for flag := TrackTypeUnknown; flag <= TrackTypeCustom; flag <<= 1 {
+		if trackTypes&flag != 0 {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
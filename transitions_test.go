@@ -0,0 +1,127 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpdateAutoTransitions(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{3, 8})
+	timeline := &ges.Project.Timeline
+	stale := Clip{AssetID: CrossfadeAssetID, TypeName: ClipTypeTransition, TrackTypes: TrackTypeVideo, Start: 10 * GSTSecond, Duration: GSTSecond}
+	if _, err := timeline.AddClip(0, stale); err != nil {
+		t.Fatalf("AddClip failed: %v", err)
+	}
+
+	ges.UpdateAutoTransitions()
+	layer := &timeline.Layers[0]
+	trs := transitions(layer)
+	if len(trs) != 2 || trs[0].TrackTypes != TrackTypeAudio || trs[1].TrackTypes != TrackTypeVideo {
+		t.Fatalf("Expected an audio and a video transition instead of the stale one, got %+v", trs)
+	}
+	video := trs[1]
+	if video.Start != 3*GSTSecond || video.Duration != GSTSecond || video.Name() != "transitionclip4" {
+		t.Errorf("Expected transitionclip4 at 3-4s, got %+v", video)
+	}
+	if border, ok := video.ChildProperty("border"); !ok || border.Value != "0" {
+		t.Errorf("Expected the video transition border, got %s", video.ChildrenProperties)
+	}
+	if trs[0].ChildrenProperties != "" {
+		t.Errorf("Expected no children properties on the audio transition, got %s", trs[0].ChildrenProperties)
+	}
+	asset, ok := ges.Project.AssetFor(&video)
+	if !ok {
+		t.Fatal("Expected the crossfade asset in the project resources")
+	}
+	if description, _ := structureOf(asset.Metadatas).GetString("description"); description != "GES_VIDEO_STANDARD_TRANSITION_TYPE_CROSSFADE" {
+		t.Errorf("Unexpected crossfade asset metadatas %s", asset.Metadatas)
+	}
+
+	// Updating again changes nothing
+	clips := append([]Clip(nil), layer.Clips...)
+	ges.UpdateAutoTransitions()
+	if !reflect.DeepEqual(clips, layer.Clips) || len(ges.Project.Assets()) != 2 {
+		t.Errorf("Expected the same transitions, got %+v", layer.Clips)
+	}
+}
+
+func TestUpdateLayerTransitions(t *testing.T) {
+	ges := newEditDocument(t)
+	timeline := &ges.Project.Timeline
+	for _, start := range []uint64{0, 3} {
+		clip := Clip{AssetID: "file:///a.mov", TypeName: ClipTypeURI, TrackTypes: TrackTypeVideo, Start: start * GSTSecond, Duration: 4 * GSTSecond}
+		if _, err := timeline.AddClip(1, clip); err != nil {
+			t.Fatalf("AddClip failed: %v", err)
+		}
+	}
+	layer := &timeline.Layers[1]
+	layer.SetAutoTransition(false)
+
+	ges.UpdateAutoTransitions()
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions without auto-transition, got %+v", trs)
+	}
+
+	if err := ges.UpdateLayerTransitions(1); err != nil {
+		t.Fatalf("UpdateLayerTransitions failed: %v", err)
+	}
+	if trs := transitions(layer); len(trs) != 1 || trs[0].TrackTypes != TrackTypeVideo || trs[0].LayerPriority != 1 {
+		t.Errorf("Expected a video transition in layer 1, got %+v", trs)
+	}
+	if err := ges.UpdateLayerTransitions(5); err == nil {
+		t.Error("Expected an error for a missing layer")
+	}
+}
+
+func TestUpdateAutoTransitions_TimelineProperty(t *testing.T) {
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{3, 8})
+	timeline := &ges.Project.Timeline
+	timeline.SetAutoTransition(false)
+	layer := &timeline.Layers[0]
+	if !layer.AutoTransition() {
+		t.Fatal("Expected the layer to keep its auto-transition")
+	}
+
+	ges.UpdateAutoTransitions()
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions when the timeline has no auto-transition, got %+v", trs)
+	}
+	if err := ges.Edit(layer.Clips[1].ID, -1, EditNormal, EdgeNone, 2*GSTSecond); err != nil {
+		t.Fatalf("Edit failed: %v", err)
+	}
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions after an edit, got %+v", trs)
+	}
+
+	timeline.SetAutoTransition(true)
+	ges.UpdateAutoTransitions()
+	if trs := transitions(layer); len(trs) != 2 {
+		t.Errorf("Expected audio and video transitions with auto-transition back on, got %+v", trs)
+	}
+}
+
+func TestUpdateAutoTransitions_MissingProperty(t *testing.T) {
+	// Scripts often leave the properties out, which GES reads as false
+	ges := newEditDocument(t, [2]uint64{0, 4}, [2]uint64{3, 8})
+	timeline := &ges.Project.Timeline
+	timeline.Properties = "properties;"
+	layer := &timeline.Layers[0]
+	layer.Properties = "properties;"
+	if timeline.AutoTransition() || layer.AutoTransition() {
+		t.Fatal("Expected auto-transition false without the properties")
+	}
+
+	ges.UpdateAutoTransitions()
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions, got %+v", trs)
+	}
+
+	timeline.SetAutoTransition(true)
+	ges.UpdateAutoTransitions()
+	if trs := transitions(layer); len(trs) != 0 {
+		t.Errorf("Expected no transitions on a layer without auto-transition, got %+v", trs)
+	}
+}
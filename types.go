@@ -14,9 +14,56 @@ type GES struct {
 
 // Project represents the project element
 type Project struct {
-	Properties string   `xml:"properties,attr,omitempty"`
-	Metadatas  string   `xml:"metadatas,attr,omitempty"`
-	Timeline   Timeline `xml:"timeline"`
+	Properties       string            `xml:"properties,attr,omitempty"`
+	Metadatas        string            `xml:"metadatas,attr,omitempty"`
+	EncodingProfiles *EncodingProfiles `xml:"encoding-profiles"`
+	Ressources       *Ressources       `xml:"ressources"`
+	Timeline         Timeline          `xml:"timeline"`
+}
+
+// EncodingProfiles represents the render settings saved with a project
+type EncodingProfiles struct {
+	Profiles []EncodingProfile `xml:"encoding-profile"`
+}
+
+// EncodingProfile represents a container encoding profile and its streams
+type EncodingProfile struct {
+	Name        string          `xml:"name,attr"`
+	Description string          `xml:"description,attr"`
+	Type        string          `xml:"type,attr"`
+	Preset      string          `xml:"preset,attr,omitempty"`
+	PresetName  string          `xml:"preset-name,attr,omitempty"`
+	Format      string          `xml:"format,attr,omitempty"`
+	Streams     []StreamProfile `xml:"stream-profile"`
+}
+
+// StreamProfile represents the encoding of one stream of a container.
+// Pass and VariableFramerate are only set for video streams.
+type StreamProfile struct {
+	Parent            string `xml:"parent,attr"`
+	ID                int    `xml:"id,attr"`
+	Type              string `xml:"type,attr"`
+	Presence          int    `xml:"presence,attr"`
+	Format            string `xml:"format,attr,omitempty"`
+	Preset            string `xml:"preset,attr,omitempty"`
+	PresetName        string `xml:"preset-name,attr,omitempty"`
+	Restriction       string `xml:"restriction,attr,omitempty"`
+	Pass              string `xml:"pass,attr,omitempty"`
+	VariableFramerate string `xml:"variableframerate,attr,omitempty"`
+}
+
+// Ressources represents the project resources element (spelled as in GES)
+type Ressources struct {
+	Assets []Asset `xml:"asset"`
+}
+
+// Asset represents an asset element in the project resources
+type Asset struct {
+	ID                  string `xml:"id,attr"`
+	ExtractableTypeName string `xml:"extractable-type-name,attr"`
+	Properties          string `xml:"properties,attr,omitempty"`
+	Metadatas           string `xml:"metadatas,attr,omitempty"`
+	ProxyID             string `xml:"proxy-id,attr,omitempty"`
 }
 
 // Timeline represents the timeline element
@@ -25,6 +72,26 @@ type Timeline struct {
 	Metadatas  string  `xml:"metadatas,attr,omitempty"`
 	Tracks     []Track `xml:"track"`
 	Layers     []Layer `xml:"layer"`
+	Groups     *Groups `xml:"groups"`
+}
+
+// Groups represents the groups element of a timeline
+type Groups struct {
+	Groups []Group `xml:"group"`
+}
+
+// Group represents a group of clips or other groups
+type Group struct {
+	ID         int          `xml:"id,attr"`
+	Properties string       `xml:"properties,attr,omitempty"`
+	Metadatas  string       `xml:"metadatas,attr,omitempty"`
+	Children   []GroupChild `xml:"child"`
+}
+
+// GroupChild references a clip or group belonging to a group by its id
+type GroupChild struct {
+	ID   int    `xml:"id,attr"`
+	Name string `xml:"name,attr,omitempty"`
 }
 
 // Track represents a track element (video/audio)
@@ -38,23 +105,48 @@ type Track struct {
 
 // Layer represents a layer element
 type Layer struct {
-	Priority   int    `xml:"priority,attr"`
-	Properties string `xml:"properties,attr,omitempty"`
-	Metadatas  string `xml:"metadatas,attr,omitempty"`
-	Clips      []Clip `xml:"clip"`
+	Priority          int    `xml:"priority,attr"`
+	Properties        string `xml:"properties,attr,omitempty"`
+	Metadatas         string `xml:"metadatas,attr,omitempty"`
+	DeactivatedTracks string `xml:"deactivated-tracks,attr,omitempty"`
+	Clips             []Clip `xml:"clip"`
 }
 
+// LayerNameField is the layer metadatas field Pitivi stores the layer name in
+const LayerNameField = "video::name"
+
 // Clip represents a clip element
 type Clip struct {
-	ID                 int    `xml:"id,attr"`
+	ID                 int      `xml:"id,attr"`
+	AssetID            string   `xml:"asset-id,attr"`
+	TypeName           string   `xml:"type-name,attr"`
+	LayerPriority      int      `xml:"layer-priority,attr"`
+	TrackTypes         int      `xml:"track-types,attr"`
+	Start              uint64   `xml:"start,attr"`
+	Duration           uint64   `xml:"duration,attr"`
+	Inpoint            uint64   `xml:"inpoint,attr"`
+	Rate               int      `xml:"rate,attr"`
+	Properties         string   `xml:"properties,attr,omitempty"`
+	Metadatas          string   `xml:"metadatas,attr,omitempty"`
+	ChildrenProperties string   `xml:"children-properties,attr,omitempty"`
+	Sources            []Source `xml:"source"`
+	Effects            []Effect `xml:"effect"`
+}
+
+// Source represents the settings of a clip in one track
+type Source struct {
+	TrackID            int    `xml:"track-id,attr"`
+	Properties         string `xml:"properties,attr,omitempty"`
+	ChildrenProperties string `xml:"children-properties,attr,omitempty"`
+}
+
+// Effect represents an effect element applied to a clip in one track
+type Effect struct {
 	AssetID            string `xml:"asset-id,attr"`
+	ClipID             int    `xml:"clip-id,attr"`
 	TypeName           string `xml:"type-name,attr"`
-	LayerPriority      int    `xml:"layer-priority,attr"`
-	TrackTypes         int    `xml:"track-types,attr"`
-	Start              uint64 `xml:"start,attr"`
-	Duration           uint64 `xml:"duration,attr"`
-	Inpoint            uint64 `xml:"inpoint,attr"`
-	Rate               int    `xml:"rate,attr"`
+	TrackType          int    `xml:"track-type,attr"`
+	TrackID            int    `xml:"track-id,attr"`
 	Properties         string `xml:"properties,attr,omitempty"`
 	Metadatas          string `xml:"metadatas,attr,omitempty"`
 	ChildrenProperties string `xml:"children-properties,attr,omitempty"`
@@ -68,6 +160,9 @@ const (
 	ClipTypeTitle      = "GESTitleClip"
 )
 
+// EffectTypeName is the type name of effects described by a bin
+const EffectTypeName = "GESEffect"
+
 // Track types (as bitmask)
 const (
 	TrackTypeUnknown = 1 << 0
@@ -0,0 +1,241 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatVersion is the version of the XGES format, as in <ges version='0.4'>
+type FormatVersion struct {
+	Major int
+	Minor int
+}
+
+// XGES format versions
+var (
+	// DefaultFormatVersion is written when the content needs nothing newer
+	DefaultFormatVersion = FormatVersion{0, 3}
+	// LatestFormatVersion is the newest version the adapter understands
+	LatestFormatVersion = FormatVersion{0, 7}
+)
+
+// Format versions introducing the features the adapter converts
+var (
+	versionSources           = FormatVersion{0, 4} // per-track <source> elements
+	versionMarkers           = FormatVersion{0, 5} // GESMarkerList metadatas
+	versionTimeEffects       = FormatVersion{0, 6} // time effects
+	versionDeactivatedTracks = FormatVersion{0, 7} // deactivated-tracks on layers
+)
+
+// ParseFormatVersion parses a "major.minor" format version. A missing minor
+// version is 0.
+func ParseFormatVersion(s string) (FormatVersion, error) {
+	major, minor, hasMinor := strings.Cut(strings.TrimSpace(s), ".")
+	var v FormatVersion
+	var err error
+	if v.Major, err = strconv.Atoi(major); err != nil || v.Major < 0 {
+		return v, fmt.Errorf("invalid XGES format version %q", s)
+	}
+	if hasMinor {
+		if v.Minor, err = strconv.Atoi(minor); err != nil || v.Minor < 0 {
+			return v, fmt.Errorf("invalid XGES format version %q", s)
+		}
+	}
+	return v, nil
+}
+
+// String formats the version as written in the version attribute
+func (v FormatVersion) String() string {
+	return fmt.Sprintf("%d.%d", v.Major, v.Minor)
+}
+
+// Before reports whether v is older than other
+func (v FormatVersion) Before(other FormatVersion) bool {
+	return v.Major < other.Major || (v.Major == other.Major && v.Minor < other.Minor)
+}
+
+// Supported reports whether the adapter can read and write the version
+func (v FormatVersion) Supported() bool {
+	return v.Major == LatestFormatVersion.Major && !LatestFormatVersion.Before(v)
+}
+
+// laterVersion returns the later of two versions
+func laterVersion(a, b FormatVersion) FormatVersion {
+	if a.Before(b) {
+		return b
+	}
+	return a
+}
+
+// RequiredFormatVersion returns the oldest format version that can express
+// the content of a document, and at least DefaultFormatVersion
+func RequiredFormatVersion(ges *GES) FormatVersion {
+	version := headerVersion(ges)
+	timeline := &ges.Project.Timeline
+	for i := range timeline.Layers {
+		layer := &timeline.Layers[i]
+		version = laterVersion(version, layerVersion(layer))
+		for j := range layer.Clips {
+			version = laterVersion(version, clipVersion(&layer.Clips[j]))
+		}
+	}
+	return version
+}
+
+// headerVersion returns the format version the project, timeline, track
+// and asset elements need
+func headerVersion(ges *GES) FormatVersion {
+	version := DefaultFormatVersion
+	for _, metadatas := range headerMetadatas(ges) {
+		if hasMarkers(*metadatas) {
+			version = laterVersion(version, versionMarkers)
+		}
+	}
+	return version
+}
+
+// headerMetadatas returns the metadatas of the elements above the layers
+func headerMetadatas(ges *GES) []*string {
+	project := &ges.Project
+	metadatas := []*string{&project.Metadatas, &project.Timeline.Metadatas}
+	for i := range project.Timeline.Tracks {
+		metadatas = append(metadatas, &project.Timeline.Tracks[i].Metadatas)
+	}
+	if project.Ressources != nil {
+		for i := range project.Ressources.Assets {
+			metadatas = append(metadatas, &project.Ressources.Assets[i].Metadatas)
+		}
+	}
+	return metadatas
+}
+
+// layerVersion returns the format version a layer element needs
+func layerVersion(layer *Layer) FormatVersion {
+	version := DefaultFormatVersion
+	if layer.DeactivatedTracks != "" {
+		version = laterVersion(version, versionDeactivatedTracks)
+	}
+	if hasMarkers(layer.Metadatas) {
+		version = laterVersion(version, versionMarkers)
+	}
+	return version
+}
+
+// clipVersion returns the format version a clip element needs
+func clipVersion(clip *Clip) FormatVersion {
+	version := DefaultFormatVersion
+	if len(clip.Sources) > 0 {
+		version = laterVersion(version, versionSources)
+	}
+	if hasMarkers(clip.Metadatas) {
+		version = laterVersion(version, versionMarkers)
+	}
+	for i := range clip.Effects {
+		if IsTimeEffect(&clip.Effects[i]) {
+			version = laterVersion(version, versionTimeEffects)
+		}
+	}
+	return version
+}
+
+// hasMarkers reports whether metadatas hold a GES marker list
+func hasMarkers(metadatas string) bool {
+	if !strings.Contains(metadatas, "GESMarkerList") {
+		return false
+	}
+	s, err := ParseStructure(metadatas)
+	if err != nil {
+		return false
+	}
+	for _, f := range s.Fields {
+		if f.Type == "GESMarkerList" {
+			return true
+		}
+	}
+	return false
+}
+
+// removeMarkers removes the marker lists from metadatas
+func removeMarkers(metadatas string) string {
+	s, err := ParseStructure(metadatas)
+	if err != nil {
+		return metadatas
+	}
+	for _, f := range append([]Field(nil), s.Fields...) {
+		if f.Type == "GESMarkerList" {
+			s.Remove(f.Name)
+		}
+	}
+	return s.String()
+}
+
+// legacyTypeNames maps the GES 0.10 type names older documents may use to
+// the current ones
+var legacyTypeNames = map[string]string{
+	"GESTimelineFileSource":         ClipTypeURI,
+	"GESTimelineTestSource":         ClipTypeTest,
+	"GESTimelineTitleSource":        ClipTypeTitle,
+	"GESTimelineStandardTransition": ClipTypeTransition,
+	"GESTrackParseLaunchEffect":     EffectTypeName,
+}
+
+// documentVersion returns the format version of a document. Documents
+// without a version are the oldest format, 0.1.
+func documentVersion(version string) (FormatVersion, error) {
+	if version == "" {
+		return FormatVersion{0, 1}, nil
+	}
+	v, err := ParseFormatVersion(version)
+	if err != nil {
+		return v, err
+	}
+	if v.Major != LatestFormatVersion.Major {
+		return v, fmt.Errorf("unsupported XGES format version %s: only %d.x documents can be read", version, LatestFormatVersion.Major)
+	}
+	return v, nil
+}
+
+// normalizeClip upgrades a clip of an older format version to the current
+// model
+func normalizeClip(clip *Clip, version FormatVersion) {
+	if !version.Before(FormatVersion{0, 2}) {
+		return
+	}
+	if name, ok := legacyTypeNames[clip.TypeName]; ok {
+		clip.TypeName = name
+	}
+	for i := range clip.Effects {
+		if name, ok := legacyTypeNames[clip.Effects[i].TypeName]; ok {
+			clip.Effects[i].TypeName = name
+		}
+	}
+}
+
+// normalizeDocument checks the format version of a document and upgrades
+// the content of older versions to the current model. It returns the
+// version of the document.
+func normalizeDocument(ges *GES) (FormatVersion, error) {
+	version, err := documentVersion(ges.Version)
+	if err != nil {
+		return version, err
+	}
+	if version.Before(FormatVersion{0, 2}) {
+		for i := range ges.Project.Assets() {
+			asset := &ges.Project.Ressources.Assets[i]
+			if name, ok := legacyTypeNames[asset.ExtractableTypeName]; ok {
+				asset.ExtractableTypeName = name
+			}
+		}
+	}
+	for i := range ges.Project.Timeline.Layers {
+		layer := &ges.Project.Timeline.Layers[i]
+		for j := range layer.Clips {
+			normalizeClip(&layer.Clips[j], version)
+		}
+	}
+	return version, nil
+}
@@ -0,0 +1,226 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFormatVersion(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected FormatVersion
+		wantErr  bool
+	}{
+		{"0.3", FormatVersion{0, 3}, false},
+		{"0.10", FormatVersion{0, 10}, false},
+		{"1", FormatVersion{1, 0}, false},
+		{"", FormatVersion{}, true},
+		{"0.x", FormatVersion{}, true},
+	}
+
+	for _, tt := range tests {
+		v, err := ParseFormatVersion(tt.input)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseFormatVersion(%q): expected an error", tt.input)
+			}
+			continue
+		}
+		if err != nil || v != tt.expected {
+			t.Errorf("ParseFormatVersion(%q) = %v, %v, expected %v", tt.input, v, err, tt.expected)
+		}
+	}
+
+	if !(FormatVersion{0, 4}).Before(FormatVersion{0, 10}) || (FormatVersion{1, 0}).Supported() {
+		t.Error("Expected versions to compare numerically and 1.0 to be unsupported")
+	}
+}
+
+func TestRequiredFormatVersion(t *testing.T) {
+	clip := Clip{TypeName: ClipTypeURI}
+	tests := []struct {
+		name     string
+		edit     func(ges *GES)
+		expected string
+	}{
+		{"plain", func(ges *GES) {}, "0.3"},
+		{"sources", func(ges *GES) {
+			ges.Project.Timeline.Layers[0].Clips[0].Sources = []Source{{TrackID: 0}}
+		}, "0.4"},
+		{"markers", func(ges *GES) {
+			ges.Project.Timeline.Layers[0].Clips[0].Metadatas = `metadatas, markers=(GESMarkerList)"EMPTY";`
+		}, "0.5"},
+		{"project markers", func(ges *GES) {
+			ges.Project.Metadatas = `metadatas, markers=(GESMarkerList)"EMPTY";`
+		}, "0.5"},
+		{"timeline markers", func(ges *GES) {
+			ges.Project.Timeline.Metadatas = `metadatas, markers=(GESMarkerList)"marker-times\=\(guint64\)\<\ 1\ \>";`
+		}, "0.5"},
+		{"markers in a string", func(ges *GES) {
+			ges.Project.Metadatas = `metadatas, note=(string)"\(GESMarkerList\)";`
+		}, "0.3"},
+		{"time effects", func(ges *GES) {
+			ges.Project.Timeline.Layers[0].Clips[0].Effects = []Effect{{AssetID: "videorate", TypeName: EffectTypeName}}
+		}, "0.6"},
+		{"deactivated tracks", func(ges *GES) {
+			ges.Project.Timeline.Layers[0].DeactivatedTracks = "1"
+		}, "0.7"},
+	}
+
+	for _, tt := range tests {
+		ges := &GES{}
+		ges.Project.Timeline.Layers = []Layer{{Clips: []Clip{clip}}}
+		tt.edit(ges)
+		if v := RequiredFormatVersion(ges).String(); v != tt.expected {
+			t.Errorf("%s: expected version %s, got %s", tt.name, tt.expected, v)
+		}
+	}
+}
+
+func TestEncoder_FormatVersion(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(layersXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	// The deactivated layer needs 0.7
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if ges.Version != "0.7" {
+		t.Errorf("Expected version 0.7, got %s", ges.Version)
+	}
+
+	// An older version drops the deactivation with a warning
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{Version: "0.4"})
+	if ges, err = encoder.EncodeDocument(timeline); err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if ges.Version != "0.4" {
+		t.Errorf("Expected version 0.4, got %s", ges.Version)
+	}
+	for _, layer := range ges.Project.Timeline.Layers {
+		if layer.DeactivatedTracks != "" {
+			t.Errorf("Expected no deactivated tracks in 0.4, got %q", layer.DeactivatedTracks)
+		}
+	}
+	if len(encoder.Warnings()) != 1 || !strings.Contains(encoder.Warnings()[0], "deactivated tracks dropped") {
+		t.Errorf("Expected a warning about deactivated tracks, got %v", encoder.Warnings())
+	}
+
+	// Strict mode fails instead, and unknown versions are rejected
+	for _, opts := range []EncodeOptions{{Version: "0.4", Strict: true}, {Version: "1.0"}, {Version: "0.8"}} {
+		encoder := NewEncoder(nil)
+		encoder.SetOptions(opts)
+		if _, err := encoder.EncodeDocument(timeline); err == nil {
+			t.Errorf("Expected an error encoding with %+v", opts)
+		}
+	}
+}
+
+func TestEncoder_MarkersFormatVersion(t *testing.T) {
+	timeline, err := ToOTIO(loadTestProject(t, "markers.xges"), DecodeOptions{})
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	ges, err := NewEncoder(nil).EncodeDocument(timeline)
+	if err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if ges.Version != "0.5" {
+		t.Errorf("Expected version 0.5 for the markers, got %s", ges.Version)
+	}
+
+	// Downgrading drops every marker list and keeps the other fields as read
+	encoder := NewEncoder(nil)
+	encoder.SetOptions(EncodeOptions{Version: "0.4"})
+	if ges, err = encoder.EncodeDocument(timeline); err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if v := RequiredFormatVersion(ges).String(); v != "0.3" {
+		t.Errorf("Expected no content needing more than 0.3, got %s", v)
+	}
+	expected := `metadatas, name=(string)markers, author=(string)"Jane\ Doe", date=(GstDateTime)"2021-01-01T10:00:00Z", format-version=(string)0.4;`
+	if ges.Project.Metadatas != expected {
+		t.Errorf("Expected project metadatas\n%s\ngot\n%s", expected, ges.Project.Metadatas)
+	}
+	if n := len(encoder.Warnings()); n != 4 {
+		t.Errorf("Expected 4 warnings about markers, got %v", encoder.Warnings())
+	}
+
+	// Streaming writes the same document
+	var streamed, written strings.Builder
+	encoder = NewEncoder(&streamed)
+	if err := encoder.Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if ges, err = NewEncoder(nil).EncodeDocument(timeline); err != nil {
+		t.Fatalf("EncodeDocument failed: %v", err)
+	}
+	if err := WriteDocument(&written, ges); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+	if streamed.String() != written.String() {
+		t.Errorf("Streamed output differs from the document:\n%s\nvs\n%s", streamed.String(), written.String())
+	}
+}
+
+func TestEncoder_StreamedFormatVersion(t *testing.T) {
+	timeline, err := NewDecoder(strings.NewReader(layersXGES)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+
+	var buf strings.Builder
+	encoder := NewEncoder(&buf)
+	if err := encoder.Encode(timeline); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "<ges version='0.7'>") || !strings.Contains(buf.String(), "deactivated-tracks='0'") {
+		t.Errorf("Expected a 0.7 document with deactivated tracks, got:\n%s", buf.String())
+	}
+	if len(encoder.Warnings()) != 0 {
+		t.Errorf("Expected no warnings, got %v", encoder.Warnings())
+	}
+}
+
+func TestDecoder_FormatVersion(t *testing.T) {
+	legacy := `<ges version='0.1'>
+  <project>
+    <timeline>
+      <track caps='video/x-raw' track-type='4' track-id='0'/>
+      <layer priority='0'>
+        <clip id='0' asset-id='file:///a.mov' type-name='GESTimelineFileSource' layer-priority='0' track-types='4' start='0' duration='1000000000' inpoint='0' rate='0'/>
+      </layer>
+    </timeline>
+  </project>
+</ges>`
+
+	timeline, err := NewDecoder(strings.NewReader(legacy)).Decode()
+	if err != nil {
+		t.Fatalf("Decode failed: %v", err)
+	}
+	if n := len(timeline.VideoTracks()); n != 1 || len(timeline.VideoTracks()[0].Children()) != 1 {
+		t.Errorf("Expected the legacy file source to become a clip")
+	}
+
+	// Newer minor versions are read with a warning, other majors rejected
+	decoder := NewDecoder(strings.NewReader(strings.Replace(legacy, "0.1", "0.9", 1)))
+	if _, err := decoder.Decode(); err != nil || len(decoder.Warnings()) == 0 || !strings.Contains(decoder.Warnings()[0], "newer than 0.7") {
+		t.Errorf("Expected a warning for version 0.9, got %v, %v", err, decoder.Warnings())
+	}
+	_, err = NewDecoder(strings.NewReader(strings.Replace(legacy, "0.1", "1.0", 1))).Decode()
+	if err == nil || !strings.Contains(err.Error(), "unsupported XGES format version 1.0") {
+		t.Errorf("Expected version 1.0 to be rejected, got %v", err)
+	}
+	stream := NewStreamDecoder(strings.NewReader(strings.Replace(legacy, "0.1", "1.0", 1)))
+	if err := stream.Walk(StreamHandler{}); err == nil {
+		t.Error("Expected the stream decoder to reject version 1.0")
+	}
+}
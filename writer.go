@@ -0,0 +1,317 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Writer writes GES documents formatted the way GES saves them, so files
+// saved by GES or Pitivi diff cleanly against our output: attributes are
+// single-quoted and in GES's order, values are escaped as GLib escapes
+// markup and elements are indented by two spaces per level. The quirks of
+// GES's formatter are kept too: assets, stream profiles and clips without
+// children close with " />", proxy-id and children-properties follow two
+// spaces, and the project end tag is not indented.
+type Writer struct {
+	w     *bufio.Writer
+	depth int
+	err   error
+}
+
+// NewWriter creates a GES document writer
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// WriteDocument writes a whole GES document and flushes the writer
+func (w *Writer) WriteDocument(ges *GES) error {
+	// GES writes no XML declaration
+	w.startGES(ges)
+	w.startProject(&ges.Project)
+	if ges.Project.EncodingProfiles != nil {
+		w.writeEncodingProfiles(ges.Project.EncodingProfiles)
+	}
+	if ges.Project.Ressources != nil {
+		w.open("ressources")
+		for i := range ges.Project.Ressources.Assets {
+			w.writeAsset(&ges.Project.Ressources.Assets[i])
+		}
+		w.close("ressources")
+	}
+
+	timeline := &ges.Project.Timeline
+	w.startTimeline(timeline)
+	for i := range timeline.Tracks {
+		w.writeTrack(&timeline.Tracks[i])
+	}
+	for i := range timeline.Layers {
+		layer := &timeline.Layers[i]
+		w.startLayer(layer)
+		for j := range layer.Clips {
+			w.writeClip(&layer.Clips[j])
+		}
+		w.close("layer")
+	}
+	if timeline.Groups != nil {
+		w.writeGroups(timeline.Groups)
+	}
+
+	w.close("timeline")
+	w.close("project")
+	w.close("ges")
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write XGES: %w", err)
+	}
+	return nil
+}
+
+// Flush writes any buffered data, returning the first write error
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.w.Flush()
+	}
+	return w.err
+}
+
+// attr is an attribute in the order GES writes it
+type attr struct {
+	name     string
+	value    string
+	optional bool // omitted when empty
+	apart    bool // preceded by two spaces
+}
+
+// required creates an attribute that is always written
+func required(name, value string) attr {
+	return attr{name: name, value: value}
+}
+
+// optional creates an attribute that is omitted when empty
+func optional(name, value string) attr {
+	return attr{name: name, value: value, optional: true}
+}
+
+// apart creates an optional attribute GES separates by two spaces
+func apart(name, value string) attr {
+	return attr{name: name, value: value, optional: true, apart: true}
+}
+
+func (w *Writer) startGES(ges *GES) {
+	w.open("ges", required("version", ges.Version))
+}
+
+func (w *Writer) startProject(project *Project) {
+	w.open("project",
+		optional("properties", project.Properties),
+		optional("metadatas", project.Metadatas))
+}
+
+func (w *Writer) startTimeline(timeline *Timeline) {
+	w.open("timeline",
+		optional("properties", timeline.Properties),
+		optional("metadatas", timeline.Metadatas))
+}
+
+func (w *Writer) writeEncodingProfiles(profiles *EncodingProfiles) {
+	w.open("encoding-profiles")
+	for i := range profiles.Profiles {
+		profile := &profiles.Profiles[i]
+		w.writeTag("encoding-profile", []attr{
+			required("name", profile.Name),
+			required("description", profile.Description),
+			required("type", profile.Type),
+			optional("preset", profile.Preset),
+			optional("preset-name", profile.PresetName),
+			optional("format", profile.Format),
+		}, " >\n")
+		w.depth++
+		for j := range profile.Streams {
+			stream := &profile.Streams[j]
+			w.writeTag("stream-profile", []attr{
+				required("parent", stream.Parent),
+				required("id", strconv.Itoa(stream.ID)),
+				required("type", stream.Type),
+				required("presence", strconv.Itoa(stream.Presence)),
+				optional("format", stream.Format),
+				optional("preset", stream.Preset),
+				optional("preset-name", stream.PresetName),
+				optional("restriction", stream.Restriction),
+				optional("pass", stream.Pass),
+				optional("variableframerate", stream.VariableFramerate),
+			}, " />\n")
+		}
+		w.close("encoding-profile")
+	}
+	w.close("encoding-profiles")
+}
+
+func (w *Writer) writeAsset(asset *Asset) {
+	w.writeTag("asset", []attr{
+		required("id", asset.ID),
+		required("extractable-type-name", asset.ExtractableTypeName),
+		optional("properties", asset.Properties),
+		optional("metadatas", asset.Metadatas),
+		apart("proxy-id", asset.ProxyID),
+	}, " />\n")
+}
+
+func (w *Writer) writeTrack(track *Track) {
+	w.empty("track",
+		required("caps", track.Caps),
+		required("track-type", strconv.Itoa(track.TrackType)),
+		required("track-id", strconv.Itoa(track.TrackID)),
+		optional("properties", track.Properties),
+		optional("metadatas", track.Metadatas))
+}
+
+func (w *Writer) startLayer(layer *Layer) {
+	w.open("layer",
+		required("priority", strconv.Itoa(layer.Priority)),
+		optional("properties", layer.Properties),
+		optional("metadatas", layer.Metadatas),
+		optional("deactivated-tracks", layer.DeactivatedTracks))
+}
+
+// writeClip writes a clip with its effects, then its sources
+func (w *Writer) writeClip(clip *Clip) {
+	attrs := []attr{
+		required("id", strconv.Itoa(clip.ID)),
+		required("asset-id", clip.AssetID),
+		required("type-name", clip.TypeName),
+		required("layer-priority", strconv.Itoa(clip.LayerPriority)),
+		required("track-types", strconv.Itoa(clip.TrackTypes)),
+		required("start", strconv.FormatUint(clip.Start, 10)),
+		required("duration", strconv.FormatUint(clip.Duration, 10)),
+		required("inpoint", strconv.FormatUint(clip.Inpoint, 10)),
+		required("rate", strconv.Itoa(clip.Rate)),
+		optional("properties", clip.Properties),
+		optional("metadatas", clip.Metadatas),
+		apart("children-properties", clip.ChildrenProperties),
+	}
+	if len(clip.Effects) == 0 && len(clip.Sources) == 0 {
+		w.writeTag("clip", attrs, " />\n")
+		return
+	}
+
+	w.open("clip", attrs...)
+	for i := range clip.Effects {
+		effect := &clip.Effects[i]
+		w.open("effect",
+			required("asset-id", effect.AssetID),
+			required("clip-id", strconv.Itoa(effect.ClipID)),
+			required("type-name", effect.TypeName),
+			required("track-type", strconv.Itoa(effect.TrackType)),
+			required("track-id", strconv.Itoa(effect.TrackID)),
+			optional("properties", effect.Properties),
+			optional("metadatas", effect.Metadatas),
+			optional("children-properties", effect.ChildrenProperties))
+		w.close("effect")
+	}
+	for i := range clip.Sources {
+		source := &clip.Sources[i]
+		w.open("source",
+			required("track-id", strconv.Itoa(source.TrackID)),
+			optional("properties", source.Properties),
+			optional("children-properties", source.ChildrenProperties))
+		w.close("source")
+	}
+
+	w.close("clip")
+}
+
+func (w *Writer) writeGroups(groups *Groups) {
+	w.open("groups")
+	for i := range groups.Groups {
+		group := &groups.Groups[i]
+		w.open("group",
+			required("id", strconv.Itoa(group.ID)),
+			optional("properties", group.Properties),
+			optional("metadatas", group.Metadatas))
+		for _, child := range group.Children {
+			w.empty("child", required("id", strconv.Itoa(child.ID)), optional("name", child.Name))
+		}
+		w.close("group")
+	}
+	w.close("groups")
+}
+
+// open writes a start tag and indents the following elements
+func (w *Writer) open(name string, attrs ...attr) {
+	w.writeTag(name, attrs, ">\n")
+	w.depth++
+}
+
+// close writes an end tag on its own line. GES doesn't indent the end of
+// the project, nor end the document with a newline.
+func (w *Writer) close(name string) {
+	w.depth--
+	indent, end := strings.Repeat("  ", w.depth), "\n"
+	switch name {
+	case "project":
+		indent = ""
+	case "ges":
+		end = ""
+	}
+	w.writeString(indent + "</" + name + ">" + end)
+}
+
+// empty writes a self-closing element
+func (w *Writer) empty(name string, attrs ...attr) {
+	w.writeTag(name, attrs, "/>\n")
+}
+
+func (w *Writer) writeTag(name string, attrs []attr, end string) {
+	var sb strings.Builder
+	sb.WriteString(strings.Repeat("  ", w.depth))
+	sb.WriteString("<" + name)
+	for _, a := range attrs {
+		if a.optional && a.value == "" {
+			continue
+		}
+		if a.apart {
+			sb.WriteByte(' ')
+		}
+		sb.WriteString(" " + a.name + "='")
+		escapeMarkup(&sb, a.value)
+		sb.WriteString("'")
+	}
+	sb.WriteString(end)
+	w.writeString(sb.String())
+}
+
+func (w *Writer) writeString(s string) {
+	if w.err == nil {
+		_, w.err = w.w.WriteString(s)
+	}
+}
+
+// escapeMarkup escapes a value as g_markup_escape_text does: the five XML
+// special characters, with &#39; for the apostrophe, and character
+// references for control characters
+func escapeMarkup(sb *strings.Builder, s string) {
+	for _, r := range s {
+		switch {
+		case r == '&':
+			sb.WriteString("&amp;")
+		case r == '<':
+			sb.WriteString("&lt;")
+		case r == '>':
+			sb.WriteString("&gt;")
+		case r == '\'':
+			sb.WriteString("&#39;")
+		case r == '"':
+			sb.WriteString("&quot;")
+		case (r >= 0x1 && r <= 0x8) || r == 0xb || r == 0xc || (r >= 0xe && r <= 0x1f) ||
+			(r >= 0x7f && r <= 0x84) || (r >= 0x86 && r <= 0x9f):
+			fmt.Fprintf(sb, "&#x%x;", r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+}
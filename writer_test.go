@@ -0,0 +1,52 @@
+// SPDX-License-Identifier: Apache-2.0
+// Copyright Contributors to the OpenTimelineIO project
+
+package xges
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriter_ReproducesGESFormatting(t *testing.T) {
+	// The example project was saved by Pitivi
+	data, err := os.ReadFile("testdata/xges_example.xges")
+	if err != nil {
+		t.Fatalf("Failed to read test data: %v", err)
+	}
+	ges, err := ParseDocument(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := NewWriter(&buf).WriteDocument(ges); err != nil {
+		t.Fatalf("WriteDocument failed: %v", err)
+	}
+	if buf.String() != string(data) {
+		t.Errorf("Expected the document unchanged, got:\n%s", buf.String())
+	}
+}
+
+func TestEscapeMarkup(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a & b`, `a &amp; b`},
+		{`<"it's">`, `&lt;&quot;it&#39;s&quot;&gt;`},
+		{"tab\tnew\nline", "tab\tnew\nline"},
+		{"bell\x07", "bell&#x7;"},
+		{"é", "é"},
+	}
+
+	for _, tt := range tests {
+		var sb strings.Builder
+		escapeMarkup(&sb, tt.input)
+		if sb.String() != tt.expected {
+			t.Errorf("escapeMarkup(%q) = %q, expected %q", tt.input, sb.String(), tt.expected)
+		}
+	}
+}